@@ -0,0 +1,75 @@
+// Command m2a-fake serves an in-memory fake of the M2A Media APIs for offline
+// development. Point the MCP server at it with M2A_BASE_URL.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/fake"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:9090", "address to listen on")
+	apiKey := flag.String("api-key", "", "API key clients must send as a bearer token (empty accepts any)")
+	startDelay := flag.Duration("start-delay", 5*time.Second, "how long channels spend STARTING")
+	stopDelay := flag.Duration("stop-delay", 3*time.Second, "how long channels spend STOPPING")
+	seed := flag.Bool("seed", true, "load sample sources, channels and captures")
+	flag.Parse()
+
+	fakeServer := fake.New(fake.Options{
+		APIKey:     *apiKey,
+		StartDelay: *startDelay,
+		StopDelay:  *stopDelay,
+		Seed:       *seed,
+	})
+
+	httpServer := &http.Server{
+		Addr:              *listen,
+		Handler:           logRequests(fakeServer),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Fake M2A API listening on http://%s", *listen)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Server error: %v", err)
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs one line per request
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("%s %s %d %s", r.Method, r.URL.RequestURI(), rec.status, time.Since(started).Round(time.Millisecond))
+	})
+}
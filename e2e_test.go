@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+func TestToolListing(t *testing.T) {
+	h := newHarness(t)
+	assertGolden(t, "tools", h.tools())
+}
+
+func TestReadOnlyToolListing(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) { cfg.ReadOnly = true })
+
+	var names []string
+	for _, tool := range h.tools() {
+		if !isReadOnlyTool(tool) {
+			t.Errorf("read-only mode registered mutating tool %s", tool.Name)
+		}
+		names = append(names, tool.Name)
+	}
+	assertGolden(t, "tools_read_only", names)
+}
+
+// A mutating tool that slips past read-only mode is refused by the client,
+// and the result says why
+func TestReadOnlyClientRefusal(t *testing.T) {
+	var cfg *config.Config
+	h := newHarness(t, func(c *config.Config) { cfg = c })
+	// Registered with every tool, then switched to read-only
+	cfg.ReadOnly = true
+
+	h.mustFail("start_channel", map[string]interface{}{"channel_id": "ch-0001"}, "read_only")
+	if channel := h.mustSucceed("get_channel", map[string]interface{}{"channel_id": "ch-0001"}); channel["state"] != "IDLE" {
+		t.Errorf("channel is %v after a refused start, want IDLE", channel["state"])
+	}
+}
+
+// Every tool with required parameters must reject a call without them as an
+// invalid argument rather than calling the API or failing some other way
+func TestRequiredArguments(t *testing.T) {
+	h := newHarness(t)
+
+	for _, tool := range h.tools() {
+		if len(tool.InputSchema.Required) == 0 {
+			continue
+		}
+		t.Run(tool.Name, func(t *testing.T) {
+			h.t = t
+			toolErr := h.mustFail(tool.Name, nil, "invalid_argument")
+
+			message, _ := toolErr["message"].(string)
+			mentioned := false
+			for _, param := range tool.InputSchema.Required {
+				mentioned = mentioned || strings.Contains(message, param)
+			}
+			if !mentioned {
+				t.Errorf("error %q names none of the required parameters %v", message, tool.InputSchema.Required)
+			}
+		})
+	}
+}
+
+// Every tool without required parameters must succeed with no arguments
+func TestOptionalArguments(t *testing.T) {
+	h := newHarness(t)
+
+	for _, tool := range h.tools() {
+		if len(tool.InputSchema.Required) > 0 {
+			continue
+		}
+		t.Run(tool.Name, func(t *testing.T) {
+			h.t = t
+			h.mustSucceed(tool.Name, nil)
+		})
+	}
+}
+
+// TestConversations plays each script in testdata/conversations against a
+// fresh server and fake API
+func TestConversations(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "conversations", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no conversations found")
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var steps []step
+			if err := json.Unmarshal(data, &steps); err != nil {
+				t.Fatalf("parse %s: %v", path, err)
+			}
+
+			newHarness(t).runConversation(name, steps)
+		})
+	}
+}
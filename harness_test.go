@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+	"github.com/andy-wilson/m2a-mcp/internal/fake"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// testClock is a settable clock shared by the fake API
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// harness runs the MCP server in-process against a fake M2A API and talks to
+// it through a real MCP client
+type harness struct {
+	t      *testing.T
+	fake   *fake.Server
+	clock  *testClock
+	client *mcpclient.Client
+}
+
+// newHarness starts a seeded fake API and an initialised MCP session. modify
+// can adjust the configuration before the server is built.
+func newHarness(t *testing.T, modify ...func(*config.Config)) *harness {
+	t.Helper()
+
+	clock := &testClock{now: time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)}
+	fakeServer, httpServer := fake.NewTestServer(fake.Options{
+		APIKey:     "test-key",
+		StartDelay: 5 * time.Second,
+		StopDelay:  3 * time.Second,
+		Seed:       true,
+		Now:        clock.Now,
+	})
+	t.Cleanup(httpServer.Close)
+
+	cfg := testConfig(httpServer.URL)
+	for _, m := range modify {
+		m(cfg)
+	}
+
+	svc, err := newServer(cfg, client.NewM2AClient(cfg), nil)
+	if err != nil {
+		t.Fatalf("newServer: %v", err)
+	}
+
+	c, err := mcpclient.NewInProcessClient(svc.mcpServer)
+	if err != nil {
+		t.Fatalf("NewInProcessClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "harness", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	return &harness{t: t, fake: fakeServer, clock: clock, client: c}
+}
+
+// testConfig returns a configuration for talking to a fake API at baseURL
+func testConfig(baseURL string) *config.Config {
+	return &config.Config{
+		APIKey:              "test-key",
+		BaseURL:             baseURL,
+		AWSAccountID:        "000000000000",
+		ClientName:          "test",
+		ConfirmTTL:          5 * time.Minute,
+		RetryMaxAttempts:    3,
+		RetryBaseDelay:      time.Millisecond,
+		RetryMaxDelay:       10 * time.Millisecond,
+		MaxInFlight:         8,
+		MaxQueueWait:        time.Second,
+		ToolTimeout:         5 * time.Second,
+		ChannelPollInterval: 10 * time.Millisecond,
+		JobPollInterval:     10 * time.Millisecond,
+	}
+}
+
+// tools lists the registered tools, sorted by name
+func (h *harness) tools() []mcp.Tool {
+	h.t.Helper()
+
+	result, err := h.client.ListTools(context.Background(), mcp.ListToolsRequest{})
+	if err != nil {
+		h.t.Fatalf("ListTools: %v", err)
+	}
+	sort.Slice(result.Tools, func(i, j int) bool { return result.Tools[i].Name < result.Tools[j].Name })
+	return result.Tools
+}
+
+// call invokes a tool and decodes its text result as JSON. isError reports
+// whether the tool returned an error result.
+func (h *harness) call(name string, args map[string]interface{}) (output interface{}, isError bool) {
+	h.t.Helper()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+	result, err := h.client.CallTool(context.Background(), request)
+	if err != nil {
+		h.t.Fatalf("%s: protocol error: %v", name, err)
+	}
+	if len(result.Content) != 1 {
+		h.t.Fatalf("%s: want 1 content item, got %d", name, len(result.Content))
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		h.t.Fatalf("%s: want text content, got %T", name, result.Content[0])
+	}
+	if err := json.Unmarshal([]byte(text.Text), &output); err != nil {
+		h.t.Fatalf("%s: result is not JSON: %v\n%s", name, err, text.Text)
+	}
+	return output, result.IsError
+}
+
+// mustSucceed calls a tool and fails the test if it returns an error
+func (h *harness) mustSucceed(name string, args map[string]interface{}) map[string]interface{} {
+	h.t.Helper()
+
+	output, isError := h.call(name, args)
+	if isError {
+		h.t.Fatalf("%s: unexpected error result: %v", name, output)
+	}
+	object, _ := output.(map[string]interface{})
+	return object
+}
+
+// mustFail calls a tool, checks it returns an error of the given kind and
+// returns the error payload
+func (h *harness) mustFail(name string, args map[string]interface{}, kind string) map[string]interface{} {
+	h.t.Helper()
+
+	output, isError := h.call(name, args)
+	if !isError {
+		h.t.Fatalf("%s: want %s error, got success: %v", name, kind, output)
+	}
+	payload, _ := output.(map[string]interface{})
+	toolErr, ok := payload["error"].(map[string]interface{})
+	if !ok {
+		h.t.Fatalf("%s: error result has no error object: %v", name, output)
+	}
+	for _, field := range []string{"kind", "message", "retryable"} {
+		if _, ok := toolErr[field]; !ok {
+			h.t.Errorf("%s: error object missing %q: %v", name, field, toolErr)
+		}
+	}
+	if toolErr["kind"] != kind {
+		h.t.Fatalf("%s: want error kind %q, got %q: %v", name, kind, toolErr["kind"], toolErr["message"])
+	}
+	return toolErr
+}
+
+// volatileFields vary between runs and are masked in golden files
+var volatileFields = map[string]bool{"confirm_token": true, "expires_at": true, "series_id": true}
+
+// maskVolatile replaces run-specific values so output can be compared
+func maskVolatile(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if volatileFields[k] {
+				out[k] = "<" + k + ">"
+			} else {
+				out[k] = maskVolatile(item)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = maskVolatile(item)
+		}
+		return out
+	case string:
+		return loopbackURL.ReplaceAllString(v, "http://<fake>")
+	}
+	return v
+}
+
+var loopbackURL = regexp.MustCompile(`http://127\.0\.0\.1:\d+`)
+
+// assertGolden compares v, as indented JSON, with testdata/golden/<name>.json.
+// Run with -update to rewrite the file.
+func assertGolden(t *testing.T, name string, v interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(maskVolatile(roundTrip(t, v))); err != nil {
+		t.Fatalf("marshal %s: %v", name, err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run go test -update to create it): %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s does not match golden file %s (run go test -update to accept)\n--- got ---\n%s", name, path, got)
+	}
+}
+
+// roundTrip converts v to its generic JSON form
+func roundTrip(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// step is one tool call in a scripted conversation
+type step struct {
+	// Tool and Args are the call to make. String arguments may refer to
+	// saved values as ${name}.
+	Tool string                 `json:"tool"`
+	Args map[string]interface{} `json:"args"`
+	// Advance moves the fake API's clock forward before the call
+	Advance string `json:"advance,omitempty"`
+	// Fault is injected into the fake API before the call
+	Fault *fake.Fault `json:"fault,omitempty"`
+	// Error is the expected error kind; empty expects success
+	Error string `json:"error,omitempty"`
+	// Expect lists values the result must contain, by dotted path
+	Expect map[string]interface{} `json:"expect,omitempty"`
+	// Save stores result values, by dotted path, for later steps
+	Save map[string]string `json:"save,omitempty"`
+	// Golden compares the full result with a golden file
+	Golden bool `json:"golden,omitempty"`
+}
+
+var reference = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// runConversation plays a script of tool calls, checking each result
+func (h *harness) runConversation(name string, steps []step) {
+	h.t.Helper()
+	saved := map[string]interface{}{}
+
+	for i, s := range steps {
+		if s.Advance != "" {
+			d, err := time.ParseDuration(s.Advance)
+			if err != nil {
+				h.t.Fatalf("step %d: bad advance %q: %v", i+1, s.Advance, err)
+			}
+			h.clock.Advance(d)
+		}
+		if s.Fault != nil {
+			h.fake.InjectFault(*s.Fault)
+		}
+
+		args := map[string]interface{}{}
+		if s.Args != nil {
+			args = substitute(s.Args, saved).(map[string]interface{})
+		}
+		var output interface{}
+		if s.Error != "" {
+			output = h.mustFail(s.Tool, args, s.Error)
+		} else {
+			output = h.mustSucceed(s.Tool, args)
+		}
+
+		for path, want := range s.Expect {
+			got, ok := lookupPath(output, path)
+			if want = substitute(want, saved); !ok || !reflect.DeepEqual(got, want) {
+				h.t.Errorf("step %d (%s): %s = %v, want %v", i+1, s.Tool, path, got, want)
+			}
+		}
+		for as, path := range s.Save {
+			value, ok := lookupPath(output, path)
+			if !ok {
+				h.t.Fatalf("step %d (%s): nothing at %s to save: %v", i+1, s.Tool, path, output)
+			}
+			saved[as] = value
+		}
+		if s.Golden {
+			assertGolden(h.t, filepath.Join(name, fmt.Sprintf("%02d-%s", i+1, s.Tool)), output)
+		}
+	}
+}
+
+// substitute replaces ${name} references in strings with saved values
+func substitute(v interface{}, saved map[string]interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = substitute(item, saved)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = substitute(item, saved)
+		}
+		return out
+	case string:
+		// A lone reference keeps the saved value's type
+		if m := reference.FindStringSubmatch(v); m != nil && m[0] == v {
+			if value, ok := saved[m[1]]; ok {
+				return value
+			}
+		}
+		return reference.ReplaceAllStringFunc(v, func(ref string) string {
+			if value, ok := saved[reference.FindStringSubmatch(ref)[1]]; ok {
+				if s, ok := value.(string); ok {
+					return s
+				}
+				data, _ := json.Marshal(value)
+				return string(data)
+			}
+			return ref
+		})
+	}
+	return v
+}
+
+// lookupPath finds a value by dotted path, e.g. "items.0.id"
+func lookupPath(v interface{}, path string) (interface{}, bool) {
+	for _, part := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = node[part]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i := 0
+			for _, c := range part {
+				if c < '0' || c > '9' {
+					return nil, false
+				}
+				i = i*10 + int(c-'0')
+			}
+			if part == "" || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,412 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/andy-wilson/m2a-mcp/internal/auth"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+// Event types
+const (
+	EventToolCall = "tool_call"
+	EventAPICall  = "api_call"
+)
+
+// Outcomes
+const (
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+)
+
+// redacted replaces secret values in the log
+const redacted = "[REDACTED]"
+
+// sensitiveKey matches argument names whose values must never be logged
+var sensitiveKey = regexp.MustCompile(`(?i)(token|secret|password|passwd|api_?key|authorization|credential)`)
+
+// Event is one line of the audit log
+type Event struct {
+	Time time.Time `json:"ts"`
+	Type string    `json:"type"`
+	// CallID ties the API calls made by a tool call to that tool call
+	CallID    string                 `json:"call_id,omitempty"`
+	Client    string                 `json:"client,omitempty"`
+	Transport string                 `json:"transport,omitempty"`
+	Tool      string                 `json:"tool,omitempty"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Method    string                 `json:"method,omitempty"`
+	Endpoint  string                 `json:"endpoint,omitempty"`
+	Status    int                    `json:"status,omitempty"`
+	Attempt   int                    `json:"attempt,omitempty"`
+	LatencyMS int64                  `json:"latency_ms"`
+	Outcome   string                 `json:"outcome"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// Logger writes audit events as JSON Lines. A nil *Logger discards events.
+type Logger struct {
+	mu      sync.Mutex
+	w       io.Writer
+	closer  io.Closer
+	secrets []string
+	// failing is set while writes fail, so the failure is reported once
+	failing bool
+}
+
+// New creates a Logger from the configuration. cfg.AuditLog is "stderr", a
+// file path, or "off" to disable auditing.
+func New(cfg *config.Config) (*Logger, error) {
+	secrets := cfg.Secrets()
+
+	switch cfg.AuditLog {
+	case "off", "none":
+		return nil, nil
+	case "", "stderr":
+		return &Logger{w: os.Stderr, secrets: secrets}, nil
+	}
+
+	f, err := newRotatingFile(cfg.AuditLog, cfg.AuditMaxSize, cfg.AuditMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{w: f, closer: f, secrets: secrets}, nil
+}
+
+// Close flushes and closes the underlying file, if any
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// Log writes one event. A failure to write is reported on the standard
+// logger when it starts and when writing recovers.
+func (l *Logger) Log(e Event) {
+	if l == nil {
+		return
+	}
+
+	// Secrets are removed before encoding: once JSON escapes a secret
+	// containing characters such as " or <, it no longer matches
+	e.Tool = l.scrub(e.Tool)
+	e.Endpoint = l.scrub(e.Endpoint)
+	e.Error = l.scrub(e.Error)
+	if e.Arguments != nil {
+		e.Arguments = l.scrubValue(e.Arguments).(map[string]interface{})
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(line, '\n'))
+	switch {
+	case err != nil && !l.failing:
+		log.Printf("Failed to write audit log, events are being lost: %v", err)
+		l.failing = true
+	case err == nil && l.failing:
+		log.Printf("Writing audit log again")
+		l.failing = false
+	}
+}
+
+// scrubValue removes configured secret values from every string in a
+// decoded JSON value
+func (l *Logger) scrubValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return l.scrub(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[l.scrub(key)] = l.scrubValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = l.scrubValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// scrub removes configured secret values from s
+func (l *Logger) scrub(s string) string {
+	for _, secret := range l.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
+
+type callKey struct{}
+
+type call struct {
+	id   string
+	tool string
+}
+
+// ToolMiddleware records every tool call, and tags its context so the API
+// calls it makes can be attributed to it
+func (l *Logger) ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if l == nil {
+				return next(ctx, request)
+			}
+
+			c := call{id: newCallID(), tool: request.Params.Name}
+			ctx = context.WithValue(ctx, callKey{}, c)
+
+			started := time.Now()
+			result, err := next(ctx, request)
+
+			e := l.newEvent(ctx, EventToolCall)
+			e.Arguments = redactArguments(request.GetArguments())
+			e.LatencyMS = time.Since(started).Milliseconds()
+			switch {
+			case err != nil:
+				e.Outcome = OutcomeError
+				e.Error = err.Error()
+			case result != nil && result.IsError:
+				e.Outcome = OutcomeError
+				e.Error = resultText(result)
+			default:
+				e.Outcome = OutcomeOK
+			}
+			l.Log(e)
+
+			return result, err
+		}
+	}
+}
+
+// ObserveRequest records an upstream API request attempt. It matches
+// client.Observer.
+func (l *Logger) ObserveRequest(ctx context.Context, info client.RequestInfo) {
+	if l == nil {
+		return
+	}
+
+	e := l.newEvent(ctx, EventAPICall)
+	e.Method = info.Method
+	e.Endpoint = redactQuery(info.Endpoint)
+	e.Status = info.StatusCode
+	e.Attempt = info.Attempt
+	e.LatencyMS = info.Latency.Milliseconds()
+	e.Outcome = OutcomeOK
+	if info.Err != nil {
+		e.Outcome = OutcomeError
+		e.Error = info.Err.Error()
+	}
+	l.Log(e)
+}
+
+// newEvent fills in the fields common to every event from the context
+func (l *Logger) newEvent(ctx context.Context, eventType string) Event {
+	e := Event{Time: time.Now().UTC(), Type: eventType}
+	if id, ok := auth.FromContext(ctx); ok {
+		e.Client = id.Name
+		e.Transport = id.Transport
+	}
+	if c, ok := ctx.Value(callKey{}).(call); ok {
+		e.CallID = c.id
+		e.Tool = c.tool
+	}
+	return e
+}
+
+// redactArguments copies tool arguments, replacing sensitive values,
+// including those in nested objects and arrays
+func redactArguments(arguments map[string]interface{}) map[string]interface{} {
+	if len(arguments) == 0 {
+		return nil
+	}
+
+	out := make(map[string]interface{}, len(arguments))
+	for key, value := range arguments {
+		if sensitiveKey.MatchString(key) {
+			out[key] = redacted
+			continue
+		}
+		out[key] = redactValue(value)
+	}
+	return out
+}
+
+// redactValue copies an argument value, redacting sensitive keys in any
+// objects within it
+func redactValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 {
+			return value
+		}
+		return redactArguments(value)
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = redactValue(item)
+		}
+		return out
+	}
+	return value
+}
+
+// redactQuery replaces sensitive query parameter values in an endpoint
+func redactQuery(endpoint string) string {
+	path, query, ok := strings.Cut(endpoint, "?")
+	if !ok {
+		return endpoint
+	}
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		if key, _, ok := strings.Cut(param, "="); ok && sensitiveKey.MatchString(key) {
+			params[i] = key + "=" + redacted
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
+// resultText returns the text of an error result for the log
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// newCallID returns a random ID for a tool call
+func newCallID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// rotatingFile is an append-only file that is rotated to path.1, path.2, ...
+// once it would grow past maxSize bytes
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	r.f = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write implements io.Writer
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A file that couldn't be reopened after a failed rotation is retried
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	// If rotation fails the event still goes to the current file, and the
+	// rotation is tried again on the next write
+	var rotateErr error
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if rotateErr = r.rotate(); rotateErr != nil && r.f == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// rotate shifts path.N to path.N+1, dropping the oldest, and starts a new
+// file. If that fails it reopens whatever is at path, so writing can go on;
+// r.f is nil only if that fails too.
+func (r *rotatingFile) rotate() error {
+	r.f.Close()
+	r.f = nil
+
+	if err := r.shift(); err != nil {
+		return errors.Join(err, r.open())
+	}
+	return r.open()
+}
+
+// shift renames path.N to path.N+1 and path to path.1, dropping the oldest,
+// or with no backups removes path
+func (r *rotatingFile) shift() error {
+	if r.maxBackups > 0 {
+		for i := r.maxBackups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		}
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return nil
+}
+
+// Close implements io.Closer
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+func TestRedactArguments(t *testing.T) {
+	arguments := map[string]interface{}{
+		"name":        "Main Feed",
+		"api_key":     "k-123",
+		"srtPassword": "hunter2",
+		"settings": map[string]interface{}{
+			"ingest": map[string]interface{}{"auth_token": "t-1", "url": "srt://ingest"},
+		},
+		"overrides": []interface{}{
+			map[string]interface{}{"password": "p-1", "bitrate": 6000.0},
+			[]interface{}{map[string]interface{}{"client_secret": "s-1"}},
+			"plain",
+		},
+		"empty": map[string]interface{}{},
+	}
+	want := map[string]interface{}{
+		"name":        "Main Feed",
+		"api_key":     redacted,
+		"srtPassword": redacted,
+		"settings": map[string]interface{}{
+			"ingest": map[string]interface{}{"auth_token": redacted, "url": "srt://ingest"},
+		},
+		"overrides": []interface{}{
+			map[string]interface{}{"password": redacted, "bitrate": 6000.0},
+			[]interface{}{map[string]interface{}{"client_secret": redacted}},
+			"plain",
+		},
+		"empty": map[string]interface{}{},
+	}
+	if got := redactArguments(arguments); !reflect.DeepEqual(got, want) {
+		t.Errorf("redactArguments =\n%v\nwant\n%v", got, want)
+	}
+	if arguments["api_key"] != "k-123" {
+		t.Error("redactArguments changed its input")
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"/api/v2/connect/sources":                "/api/v2/connect/sources",
+		"/api/v2/connect/sources?status=active":  "/api/v2/connect/sources?status=active",
+		"/api/x?token=abc&limit=5&apiKey=def":    "/api/x?token=[REDACTED]&limit=5&apiKey=[REDACTED]",
+		"/api/x?Authorization=Bearer%20abc&flag": "/api/x?Authorization=[REDACTED]&flag",
+	} {
+		if got := redactQuery(endpoint); got != want {
+			t.Errorf("redactQuery(%s) = %s, want %s", endpoint, got, want)
+		}
+	}
+}
+
+// Secrets are removed wherever they appear, including ones that JSON
+// escapes
+func TestLogScrubsSecrets(t *testing.T) {
+	for _, secret := range []string{"plain-secret-key", `k"e<y>&\x`} {
+		var buf bytes.Buffer
+		l := &Logger{w: &buf, secrets: []string{secret}}
+		l.Log(Event{
+			Type:     EventAPICall,
+			Endpoint: "/api/v2/connect/sources?q=" + secret,
+			Error:    "upstream said: bad key " + secret,
+			Arguments: map[string]interface{}{
+				"note":  "key is " + secret,
+				"items": []interface{}{map[string]interface{}{"label": secret}},
+			},
+			Outcome: OutcomeError,
+		})
+
+		escaped, _ := json.Marshal(secret)
+		line := buf.String()
+		if strings.Contains(line, secret) || strings.Contains(line, strings.Trim(string(escaped), `"`)) {
+			t.Errorf("secret %q leaked: %s", secret, line)
+		}
+		if strings.Count(line, redacted) != 4 {
+			t.Errorf("want 4 redactions of %q: %s", secret, line)
+		}
+		var decoded Event
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Errorf("line isn't JSON: %v", err)
+		}
+	}
+}
+
+// The configured extra secrets are scrubbed along with the API key
+func TestNewScrubsConfiguredSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := New(&config.Config{
+		APIKey:          "api-key-123",
+		CassetteSecrets: []string{"partner@example.com"},
+		AuditLog:        path,
+		AuditMaxSize:    1 << 20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Log(Event{
+		Type:     EventAPICall,
+		Endpoint: "/api/v2/connect/sources?owner=partner@example.com",
+		Error:    "bad key api-key-123",
+		Outcome:  OutcomeError,
+	})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(data)
+	if strings.Contains(line, "api-key-123") || strings.Contains(line, "partner@example.com") {
+		t.Errorf("secret leaked: %s", line)
+	}
+	if strings.Count(line, redacted) != 2 {
+		t.Errorf("want 2 redactions: %s", line)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := newRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	line := []byte(strings.Repeat("x", 39) + "\n")
+	for i := 0; i < 10; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if info.Size() > 100 {
+			t.Errorf("%s is %d bytes, over the 100 byte limit", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("more than maxBackups backups kept: %v", err)
+	}
+}
+
+// A failed rotation doesn't stop later writes
+func TestRotationFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := newRotatingFile(path, 50, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// A directory in the way of the backup makes the rename fail
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocker"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	line := []byte(strings.Repeat("x", 29) + "\n")
+	if _, err := f.Write(line); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(line); err == nil {
+		t.Error("failed rotation wasn't reported")
+	}
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(line); err != nil {
+		t.Errorf("write after the obstacle was removed: %v", err)
+	}
+
+	current, _ := os.ReadFile(path)
+	backup, _ := os.ReadFile(path + ".1")
+	if got := len(current) + len(backup); got != 3*len(line) {
+		t.Errorf("%d bytes written across the log and its backup, want %d", got, 3*len(line))
+	}
+}
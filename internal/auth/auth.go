@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Identity is the named client on whose behalf a tool call runs
+type Identity struct {
+	Name string
+	// Transport is how the client connected ("stdio" or "http")
+	Transport string
+}
+
+// Anonymous names HTTP clients when authentication is disabled
+const Anonymous = "anonymous"
+
+type contextKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the given identity
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity carried by ctx, if any
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
+
+// TokenStore maps static bearer tokens onto client identities. Tokens are
+// held as SHA-256 digests so lookups don't leak timing about the secret.
+type TokenStore struct {
+	clients map[[sha256.Size]byte]string
+}
+
+// LoadTokens reads a token file with one "<client-name> <token>" pair per
+// line. Blank lines and lines starting with # are ignored. Each client name
+// and each token may appear only once.
+func LoadTokens(path string) (*TokenStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open token file: %w", err)
+	}
+	defer f.Close()
+
+	store := &TokenStore{clients: make(map[[sha256.Size]byte]string)}
+	names := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("token file line %d: expected \"<client-name> <token>\"", lineNo)
+		}
+
+		name, token := fields[0], fields[1]
+		digest := sha256.Sum256([]byte(token))
+		if existing, ok := store.clients[digest]; ok {
+			return nil, fmt.Errorf("token file line %d: token for %q is already assigned to %q", lineNo, name, existing)
+		}
+		if previous, ok := names[name]; ok {
+			return nil, fmt.Errorf("token file line %d: client %q already has a token on line %d", lineNo, name, previous)
+		}
+		store.clients[digest] = name
+		names[name] = lineNo
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	if len(store.clients) == 0 {
+		return nil, fmt.Errorf("token file %s defines no tokens", path)
+	}
+	return store, nil
+}
+
+// Lookup returns the client name for a token
+func (s *TokenStore) Lookup(token string) (string, bool) {
+	name, ok := s.clients[sha256.Sum256([]byte(token))]
+	return name, ok
+}
+
+// Middleware rejects requests without a valid token in the Authorization
+// (Bearer) or X-API-Key header, and attaches the caller's identity to the
+// request context for everything downstream.
+func Middleware(store *TokenStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			unauthorized(w, "missing bearer token")
+			return
+		}
+
+		name, ok := store.Lookup(token)
+		if !ok {
+			unauthorized(w, "invalid token")
+			return
+		}
+
+		ctx := WithIdentity(r.Context(), Identity{Name: name, Transport: "http"})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// bearerToken extracts the presented token from the request headers
+func bearerToken(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// unauthorized writes a 401 response
+func unauthorized(w http.ResponseWriter, reason string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="m2a-mcp"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	fmt.Fprintf(w, `{"error":%q}`, reason)
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTokens writes a token file and returns its path
+func writeTokens(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTokens(t *testing.T) {
+	store, err := LoadTokens(writeTokens(t, `
+# <client-name> <token>
+
+alice   token-a
+	ci-bot token-b   
+# bob token-c
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for token, want := range map[string]string{"token-a": "alice", "token-b": "ci-bot"} {
+		if name, ok := store.Lookup(token); !ok || name != want {
+			t.Errorf("Lookup(%s) = %q, %v; want %q", token, name, ok, want)
+		}
+	}
+	for _, token := range []string{"token-c", "", "alice", "TOKEN-A"} {
+		if name, ok := store.Lookup(token); ok {
+			t.Errorf("Lookup(%q) = %q, want no client", token, name)
+		}
+	}
+}
+
+func TestLoadTokensErrors(t *testing.T) {
+	for content, want := range map[string]string{
+		"alice token-a\nbob token-a\n":   "line 2: token for \"bob\" is already assigned to \"alice\"",
+		"alice token-a\nalice token-b\n": "line 2: client \"alice\" already has a token on line 1",
+		"alice\n":                        "line 1: expected",
+		"# ok\nalice token-a extra\n":    "line 2: expected",
+		"# only comments\n\n":            "defines no tokens",
+	} {
+		_, err := LoadTokens(writeTokens(t, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadTokens(%q) = %v, want an error containing %q", content, err, want)
+		}
+	}
+
+	if _, err := LoadTokens(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadTokens of a missing file succeeded")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	store, err := LoadTokens(writeTokens(t, "alice token-a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dispatched []Identity
+	handler := Middleware(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := FromContext(r.Context())
+		if !ok {
+			t.Error("no identity in the request context")
+		}
+		dispatched = append(dispatched, id)
+	}))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		reason  string
+	}{
+		{"no header", nil, http.StatusUnauthorized, "missing bearer token"},
+		{"empty bearer", map[string]string{"Authorization": "Bearer "}, http.StatusUnauthorized, "missing bearer token"},
+		{"wrong scheme", map[string]string{"Authorization": "Basic token-a"}, http.StatusUnauthorized, "missing bearer token"},
+		{"bad token", map[string]string{"Authorization": "Bearer token-b"}, http.StatusUnauthorized, "invalid token"},
+		{"bad API key", map[string]string{"X-API-Key": "nope"}, http.StatusUnauthorized, "invalid token"},
+		{"bearer", map[string]string{"Authorization": "Bearer token-a"}, http.StatusOK, ""},
+		{"lower-case scheme", map[string]string{"Authorization": "bearer token-a"}, http.StatusOK, ""},
+		{"API key", map[string]string{"X-API-Key": "token-a"}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		dispatched = nil
+		r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if tt.status != http.StatusOK {
+			if len(dispatched) != 0 {
+				t.Errorf("%s: request reached the handler", tt.name)
+			}
+			if w.Header().Get("WWW-Authenticate") == "" || !strings.Contains(w.Body.String(), tt.reason) {
+				t.Errorf("%s: response %q, want a challenge and %q", tt.name, w.Body.String(), tt.reason)
+			}
+			continue
+		}
+		if len(dispatched) != 1 || dispatched[0] != (Identity{Name: "alice", Transport: "http"}) {
+			t.Errorf("%s: handler saw %v, want alice over http", tt.name, dispatched)
+		}
+	}
+}
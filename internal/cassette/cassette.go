@@ -0,0 +1,369 @@
+// Package cassette records the M2A API's HTTP responses to a file and
+// replays them later without a network, for reproducing bug reports and
+// backing regression tests. Recordings never contain the Authorization
+// header or any configured secret.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+// Modes, as set by M2A_CASSETTE_MODE
+const (
+	ModeOff    = "off"
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// redacted replaces scrubbed values
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are never written to a cassette
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Proxy-Authorization"}
+
+// ErrNoInteraction is returned in replay mode for a request the cassette has
+// no unused recording of
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Cassette is a sequence of recorded request/response pairs
+type Cassette struct {
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request. URL is the path and query only, so a
+// cassette replays against any base URL.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body
+}
+
+// Response is a recorded response
+type Response struct {
+	Status     int         `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+	DurationMS int64       `json:"duration_ms"`
+	Body
+}
+
+// Body holds a message body: JSON bodies are kept as JSON so cassettes are
+// easy to read and edit, anything else as text. Replayed JSON is compacted,
+// so it matches the recording byte for byte only up to whitespace.
+type Body struct {
+	JSON json.RawMessage `json:"json,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+func newBody(data []byte) Body {
+	if len(bytes.TrimSpace(data)) > 0 && json.Valid(data) {
+		return Body{JSON: json.RawMessage(data)}
+	}
+	return Body{Text: string(data)}
+}
+
+// Bytes returns the body to send. JSON bodies are compacted, since the
+// cassette file stores them indented.
+func (b Body) Bytes() []byte {
+	if len(b.JSON) > 0 {
+		var compact bytes.Buffer
+		if json.Compact(&compact, b.JSON) == nil {
+			return compact.Bytes()
+		}
+		return b.JSON
+	}
+	return []byte(b.Text)
+}
+
+// Load reads a cassette file
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes a cassette file, readable only by its owner
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// FromConfig returns the transport for cfg's cassette mode, wrapping next,
+// or nil when cassettes are off
+func FromConfig(cfg *config.Config, next http.RoundTripper) (http.RoundTripper, error) {
+	secrets := cfg.Secrets()
+	switch cfg.CassetteMode {
+	case ModeRecord:
+		return NewRecorder(cfg.CassettePath, next, secrets...), nil
+	case ModeReplay:
+		c, err := Load(cfg.CassettePath)
+		if err != nil {
+			return nil, err
+		}
+		return NewPlayer(c, secrets...), nil
+	case ModeOff, "":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown cassette mode %q", cfg.CassetteMode)
+}
+
+// scrubber replaces secrets wherever they appear: verbatim, or escaped as
+// they would be inside a JSON string or a URL
+type scrubber []string
+
+func newScrubber(secrets []string) scrubber {
+	var forms scrubber
+	add := func(form string) {
+		if form != "" && !slices.Contains(forms, form) {
+			forms = append(forms, form)
+		}
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		add(secret)
+		add(jsonEscape(secret, true))
+		add(jsonEscape(secret, false))
+		add(url.QueryEscape(secret))
+		add(url.PathEscape(secret))
+	}
+	// Longer forms first, so no form is left half replaced by a shorter one
+	sort.SliceStable(forms, func(i, j int) bool { return len(forms[i]) > len(forms[j]) })
+	return forms
+}
+
+// jsonEscape returns s as it appears between the quotes of a JSON string.
+// Encoders differ on whether they escape <, > and &, so both forms are needed.
+func jsonEscape(s string, escapeHTML bool) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if enc.Encode(s) != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(buf.String()), `"`), `"`)
+}
+
+// scrub replaces every form of every secret in text
+func (s scrubber) scrub(text string) string {
+	for _, form := range s {
+		text = strings.ReplaceAll(text, form, redacted)
+	}
+	return text
+}
+
+// scrubHeaders copies h, dropping sensitive headers' values and scrubbing
+// secrets from the rest
+func (s scrubber) scrubHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		for _, v := range values {
+			out.Add(name, s.scrub(v))
+		}
+	}
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// Recorder is an http.RoundTripper that passes requests on and appends each
+// scrubbed exchange to a cassette file, saving after every one so nothing is
+// lost if the process dies. Recording never changes the outcome of a
+// request: a failure to save is logged and the response returned anyway.
+type Recorder struct {
+	path     string
+	next     http.RoundTripper
+	scrubber scrubber
+
+	mu       sync.Mutex
+	cassette Cassette
+	// failing is set while saves fail, so the failure is reported once
+	failing bool
+}
+
+// NewRecorder creates a Recorder writing to path. secrets are replaced with
+// [REDACTED] wherever they appear, including JSON- and URL-escaped.
+func NewRecorder(path string, next http.RoundTripper, secrets ...string) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{
+		path:     path,
+		next:     next,
+		scrubber: newScrubber(secrets),
+		cassette: Cassette{RecordedAt: time.Now().UTC()},
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		reqBody, _ = io.ReadAll(body)
+		body.Close()
+	}
+
+	started := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Since(started)
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     r.scrubber.scrub(req.URL.RequestURI()),
+			Headers: r.scrubber.scrubHeaders(req.Header),
+			Body:    newBody([]byte(r.scrubber.scrub(string(reqBody)))),
+		},
+		Response: Response{
+			Status:     resp.StatusCode,
+			Headers:    r.scrubber.scrubHeaders(resp.Header),
+			DurationMS: duration.Milliseconds(),
+			Body:       newBody([]byte(r.scrubber.scrub(string(respBody)))),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	// Every save writes the whole cassette, so nothing recorded while
+	// saving fails is lost once it works again
+	err = r.cassette.Save(r.path)
+	switch {
+	case err != nil && !r.failing:
+		log.Printf("Failed to save cassette %s, recording continues in memory: %v", r.path, err)
+		r.failing = true
+	case err == nil && r.failing:
+		log.Printf("Saving cassette %s again", r.path)
+		r.failing = false
+	}
+	return resp, nil
+}
+
+// Player is an http.RoundTripper that answers requests from a cassette
+// without touching the network. Each request gets the first unused
+// interaction with the same method, path, query and body, so repeated calls
+// (including retries) replay in the order they were recorded. Requests are
+// scrubbed as the Recorder scrubbed them before they're compared.
+type Player struct {
+	scrubber scrubber
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewPlayer creates a Player for c. secrets should be those c was recorded
+// with.
+func NewPlayer(c *Cassette, secrets ...string) *Player {
+	return &Player{
+		scrubber: newScrubber(secrets),
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	requestURI := p.scrubber.scrub(req.URL.RequestURI())
+	body := newBody([]byte(p.scrubber.scrub(string(reqBody))))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, interaction := range p.cassette.Interactions {
+		recorded := interaction.Request
+		if p.used[i] || recorded.Method != req.Method || recorded.URL != requestURI {
+			continue
+		}
+		if !bytes.Equal(recorded.Bytes(), body.Bytes()) {
+			continue
+		}
+
+		p.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Headers.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(interaction.Response.Bytes())),
+			ContentLength: int64(len(interaction.Response.Bytes())),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, req.Method, requestURI)
+}
+
+// Unused returns the number of recorded interactions not yet replayed
+func (p *Player) Unused() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := 0
+	for _, used := range p.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
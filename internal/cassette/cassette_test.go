@@ -0,0 +1,218 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+	"github.com/andy-wilson/m2a-mcp/internal/fake"
+)
+
+const apiKey = "super-secret-key"
+
+func testConfig(baseURL string) *config.Config {
+	return &config.Config{
+		APIKey:           apiKey,
+		BaseURL:          baseURL,
+		RetryMaxAttempts: 3,
+		RetryBaseDelay:   time.Millisecond,
+		RetryMaxDelay:    10 * time.Millisecond,
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	fakeServer, httpServer := fake.NewTestServer(fake.Options{APIKey: apiKey, Seed: true})
+	defer httpServer.Close()
+	fakeServer.InjectFault(fake.Fault{Path: "/api/v3/live", Status: http.StatusServiceUnavailable, Times: 1})
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	ctx := context.Background()
+
+	// Record a session, including a retried 503, against the fake API
+	cfg := testConfig(httpServer.URL)
+	cfg.CassetteMode, cfg.CassettePath = ModeRecord, path
+	cfg.CassetteSecrets = []string{"partner@example.com"}
+	recording := client.NewM2AClient(cfg)
+	transport, err := FromConfig(cfg, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording.SetHTTPClient(&http.Client{Transport: transport})
+
+	var recorded []string
+	for _, call := range []func() ([]byte, error){
+		func() ([]byte, error) { return recording.Get(ctx, "/api/v3/live/channels") },
+		func() ([]byte, error) { return recording.Get(ctx, "/api/v2/connect/subscribers") },
+		func() ([]byte, error) {
+			return recording.Post(ctx, "/api/v2/connect/sources", map[string]string{"name": "Cam", "type": "rtmp", "url": "rtmp://x"})
+		},
+	} {
+		data, err := call()
+		if err != nil {
+			t.Fatalf("recording: %v", err)
+		}
+		recorded = append(recorded, string(newBody(data).Bytes()))
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{apiKey, "partner@example.com"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("cassette contains secret %q", secret)
+		}
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 4 {
+		t.Fatalf("want 4 interactions (one retried), got %d", len(c.Interactions))
+	}
+	if got := c.Interactions[0].Request.Headers.Get("Authorization"); got != redacted {
+		t.Errorf("Authorization recorded as %q", got)
+	}
+
+	// Replay with the API gone: the same calls get the same answers
+	httpServer.Close()
+	cfg = testConfig("http://127.0.0.1:1")
+	cfg.CassetteMode, cfg.CassettePath = ModeReplay, path
+	replaying := client.NewM2AClient(cfg)
+	transport, err = FromConfig(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaying.SetHTTPClient(&http.Client{Transport: transport})
+
+	data, err := replaying.Get(ctx, "/api/v3/live/channels")
+	if err != nil || string(data) != recorded[0] {
+		t.Errorf("replayed channels = %s, %v; want %s", data, err, recorded[0])
+	}
+	data, err = replaying.Get(ctx, "/api/v2/connect/subscribers")
+	if err != nil || !strings.Contains(string(data), redacted) {
+		t.Errorf("replayed subscribers = %s, %v; want scrubbed email", data, err)
+	}
+	data, err = replaying.Post(ctx, "/api/v2/connect/sources", map[string]string{"name": "Cam", "type": "rtmp", "url": "rtmp://x"})
+	if err != nil || string(data) != recorded[2] {
+		t.Errorf("replayed create = %s, %v; want %s", data, err, recorded[2])
+	}
+	if n := transport.(*Player).Unused(); n != 0 {
+		t.Errorf("%d interactions not replayed", n)
+	}
+
+	if _, err := replaying.Get(ctx, "/api/v1/vod/assets"); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("unrecorded request: want ErrNoInteraction, got %v", err)
+	}
+}
+
+// Secrets in requests are scrubbed before matching, so a replay sending the
+// same secrets matches its recording, and escaped secrets are scrubbed too
+func TestReplayScrubbedRequests(t *testing.T) {
+	_, httpServer := fake.NewTestServer(fake.Options{APIKey: apiKey, Seed: true})
+	defer httpServer.Close()
+
+	const secret = `k"e<y>&\x`
+	path := filepath.Join(t.TempDir(), "session.json")
+	ctx := context.Background()
+	source := map[string]string{"name": "Cam", "type": "srt", "url": "srt://ingest?passphrase=" + secret}
+	query := "/api/v2/connect/sources?name=" + url.QueryEscape(secret)
+
+	cfg := testConfig(httpServer.URL)
+	cfg.CassetteMode, cfg.CassettePath = ModeRecord, path
+	cfg.CassetteSecrets = []string{secret}
+	recording := client.NewM2AClient(cfg)
+	transport, err := FromConfig(cfg, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording.SetHTTPClient(&http.Client{Transport: transport})
+	if _, err := recording.Post(ctx, "/api/v2/connect/sources", source); err != nil {
+		t.Fatalf("recording create: %v", err)
+	}
+	if _, err := recording.Get(ctx, query); err != nil {
+		t.Fatalf("recording list: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	escaped, _ := json.Marshal(secret)
+	for _, form := range []string{secret, strings.Trim(string(escaped), `"`), url.QueryEscape(secret), `k\"e`} {
+		if strings.Contains(string(raw), form) {
+			t.Errorf("cassette contains the secret as %q: %s", form, raw)
+		}
+	}
+
+	cfg = testConfig("http://127.0.0.1:1")
+	cfg.CassetteMode, cfg.CassettePath = ModeReplay, path
+	cfg.CassetteSecrets = []string{secret}
+	replaying := client.NewM2AClient(cfg)
+	transport, err = FromConfig(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaying.SetHTTPClient(&http.Client{Transport: transport})
+	if _, err := replaying.Post(ctx, "/api/v2/connect/sources", source); err != nil {
+		t.Errorf("replayed create: %v", err)
+	}
+	if _, err := replaying.Get(ctx, query); err != nil {
+		t.Errorf("replayed list: %v", err)
+	}
+	if n := transport.(*Player).Unused(); n != 0 {
+		t.Errorf("%d interactions not replayed", n)
+	}
+
+	// A different secret is still a different request
+	source["url"] = "srt://ingest?passphrase=other"
+	_, err = replaying.Post(ctx, "/api/v2/connect/sources", source)
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("create with another secret: want ErrNoInteraction, got %v", err)
+	}
+}
+
+// A cassette that can't be saved doesn't fail the request, and is saved in
+// full once saving works again
+func TestRecordSaveFailure(t *testing.T) {
+	_, httpServer := fake.NewTestServer(fake.Options{APIKey: apiKey, Seed: true})
+	defer httpServer.Close()
+
+	dir := filepath.Join(t.TempDir(), "missing")
+	path := filepath.Join(dir, "session.json")
+	ctx := context.Background()
+
+	cfg := testConfig(httpServer.URL)
+	recording := client.NewM2AClient(cfg)
+	recording.SetHTTPClient(&http.Client{Transport: NewRecorder(path, http.DefaultTransport, apiKey)})
+
+	if _, err := recording.Post(ctx, "/api/v2/connect/sources", map[string]string{"name": "Cam", "type": "rtmp", "url": "rtmp://x"}); err != nil {
+		t.Fatalf("POST failed because the cassette couldn't be saved: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("cassette exists in a missing directory: %v", err)
+	}
+
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recording.Get(ctx, "/api/v2/connect/sources"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 2 || c.Interactions[0].Request.Method != http.MethodPost {
+		t.Errorf("cassette has %d interactions, want the POST and the GET", len(c.Interactions))
+	}
+}
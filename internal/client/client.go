@@ -2,7 +2,9 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,33 +15,67 @@ import (
 
 // M2AClient is the HTTP client for M2A Media API
 type M2AClient struct {
-	config     *config.Config
-	httpClient *http.Client
+	config      *config.Config
+	httpClient  *http.Client
+	retryPolicy RetryPolicy
+	limiter     *Limiter
+	observer    Observer
 }
 
+// RequestInfo describes a single attempt at an API request
+type RequestInfo struct {
+	Method   string
+	Endpoint string
+	// StatusCode is zero if no response was received
+	StatusCode int
+	Latency    time.Duration
+	Attempt    int
+	Err        error
+}
+
+// Observer is notified after every attempt at an API request, e.g. to write
+// an audit trail. It is called with the request's context.
+type Observer func(ctx context.Context, info RequestInfo)
+
 // NewM2AClient creates a new M2A API client
 func NewM2AClient(cfg *config.Config) *M2AClient {
 	return &M2AClient{
 		config: cfg,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		// Deadlines come from the caller's context rather than a fixed client timeout
+		httpClient:  &http.Client{},
+		retryPolicy: NewRetryPolicy(cfg),
+		limiter:     NewLimiter(cfg),
 	}
 }
 
+// SetHTTPClient replaces the underlying HTTP client, e.g. to point at a test server
+func (c *M2AClient) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetObserver registers a function to be told about every API request attempt
+func (c *M2AClient) SetObserver(observer Observer) {
+	c.observer = observer
+}
+
+// SetRetryPolicy overrides the retry policy derived from the configuration
+func (c *M2AClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
 // Get performs a GET request
-func (c *M2AClient) Get(endpoint string) ([]byte, error) {
+func (c *M2AClient) Get(ctx context.Context, endpoint string, opts ...RequestOption) ([]byte, error) {
 	url := c.config.BaseURL + endpoint
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return c.doRequest(req)
+	return c.doRequest(req, opts...)
 }
 
 // Post performs a POST request with JSON body
-func (c *M2AClient) Post(endpoint string, body interface{}) ([]byte, error) {
+func (c *M2AClient) Post(ctx context.Context, endpoint string, body interface{}, opts ...RequestOption) ([]byte, error) {
 	url := c.config.BaseURL + endpoint
 
 	jsonData, err := json.Marshal(body)
@@ -47,17 +83,17 @@ func (c *M2AClient) Post(endpoint string, body interface{}) ([]byte, error) {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	return c.doRequest(req)
+	return c.doRequest(req, opts...)
 }
 
 // Put performs a PUT request with JSON body
-func (c *M2AClient) Put(endpoint string, body interface{}) ([]byte, error) {
+func (c *M2AClient) Put(ctx context.Context, endpoint string, body interface{}, opts ...RequestOption) ([]byte, error) {
 	url := c.config.BaseURL + endpoint
 
 	jsonData, err := json.Marshal(body)
@@ -65,48 +101,154 @@ func (c *M2AClient) Put(endpoint string, body interface{}) ([]byte, error) {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	return c.doRequest(req)
+	return c.doRequest(req, opts...)
 }
 
 // Delete performs a DELETE request
-func (c *M2AClient) Delete(endpoint string) ([]byte, error) {
+func (c *M2AClient) Delete(ctx context.Context, endpoint string, opts ...RequestOption) ([]byte, error) {
 	url := c.config.BaseURL + endpoint
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return c.doRequest(req)
+	return c.doRequest(req, opts...)
 }
 
-// doRequest executes the HTTP request with authentication
-func (c *M2AClient) doRequest(req *http.Request) ([]byte, error) {
+// doRequest executes the HTTP request with authentication, retrying transient
+// failures according to the client's retry policy
+func (c *M2AClient) doRequest(req *http.Request, opts ...RequestOption) ([]byte, error) {
+	var options requestOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Defence in depth: read-only mode also hides mutating tools, but nothing
+	// that slips past that may change state upstream
+	if c.config.ReadOnly && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		err := fmt.Errorf("%s %s: %w", req.Method, req.URL.RequestURI(), ErrReadOnly)
+		c.observe(req, RequestInfo{Err: err})
+		return nil, err
+	}
+
 	// Add authentication header
 	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
 	req.Header.Set("Accept", "application/json")
 
+	maxAttempts := 1
+	if isIdempotent(req.Method) || options.retryable {
+		maxAttempts = max(c.retryPolicy.MaxAttempts, 1)
+		if options.maxAttempts > 0 {
+			maxAttempts = options.maxAttempts
+		}
+	}
+
+	var queued time.Duration
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		release, waited, err := c.limiter.Acquire(req.Context(), familyFor(req.URL.Path))
+		if err != nil {
+			c.observe(req, RequestInfo{Attempt: attempt, Err: err})
+			return nil, err
+		}
+		queued += waited
+
+		started := time.Now()
+		body, status, delay, err := c.attempt(req)
+		release()
+		c.observe(req, RequestInfo{
+			StatusCode: status,
+			Latency:    time.Since(started),
+			Attempt:    attempt,
+			Err:        err,
+		})
+		if err == nil {
+			return body, nil
+		}
+		if delay < 0 || attempt >= maxAttempts {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				apiErr.Attempts = attempt
+				apiErr.Queued = queued
+				return nil, apiErr
+			}
+			if attempt > 1 {
+				return nil, fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return nil, err
+		}
+		if delay == 0 {
+			delay = c.retryPolicy.backoff(attempt)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (gave up retrying after %d attempts: %w)", err, attempt, req.Context().Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// observe reports a request attempt, or a refusal to send one, to the observer
+func (c *M2AClient) observe(req *http.Request, info RequestInfo) {
+	if c.observer == nil {
+		return
+	}
+	info.Method = req.Method
+	info.Endpoint = req.URL.RequestURI()
+	c.observer(req.Context(), info)
+}
+
+// attempt performs a single round trip, returning the body and status code.
+// On failure it also returns how long to wait before retrying: zero means use
+// the backoff schedule, negative means the failure is not retryable.
+func (c *M2AClient) attempt(req *http.Request) ([]byte, int, time.Duration, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		// A cancelled or expired context is final, whatever the transport says
+		if req.Context().Err() == nil && isRetryableError(err) {
+			return nil, 0, 0, fmt.Errorf("request failed: %w", err)
+		}
+		return nil, 0, -1, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, resp.StatusCode, 0, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		apiErr := newAPIError(req, resp, body)
+		if !isRetryableStatus(resp.StatusCode) {
+			return nil, resp.StatusCode, -1, apiErr
+		}
+		if delay, ok := retryAfter(resp); ok {
+			// Don't block a tool call for longer than the policy allows
+			if c.retryPolicy.MaxDelay > 0 && delay > c.retryPolicy.MaxDelay {
+				return nil, resp.StatusCode, -1, apiErr
+			}
+			return nil, resp.StatusCode, max(delay, time.Millisecond), apiErr
+		}
+		return nil, resp.StatusCode, 0, apiErr
 	}
 
-	return body, nil
+	return body, resp.StatusCode, 0, nil
 }
 
 // GetConfig returns the client configuration
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+// In read-only mode only GET requests reach the API
+func TestReadOnly(t *testing.T) {
+	srv, calls := flakyServer(t, 0, http.StatusOK, nil)
+	c := NewM2AClient(&config.Config{BaseURL: srv.URL, APIKey: "test-key", ReadOnly: true})
+	ctx := context.Background()
+
+	for method, call := range map[string]func() ([]byte, error){
+		http.MethodPost:   func() ([]byte, error) { return c.Post(ctx, "/api/v3/live/channels/ch-1/start", nil, Retryable()) },
+		http.MethodPut:    func() ([]byte, error) { return c.Put(ctx, "/api/v2/connect/sources/src-1", map[string]string{"name": "x"}) },
+		http.MethodDelete: func() ([]byte, error) { return c.Delete(ctx, "/api/v2/connect/sources/src-1") },
+	} {
+		if _, err := call(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s in read-only mode = %v, want ErrReadOnly", method, err)
+		}
+	}
+	if got := calls.Load(); got != 0 {
+		t.Fatalf("%d refused requests reached the API", got)
+	}
+
+	if _, err := c.Get(ctx, "/api/v2/connect/sources"); err != nil {
+		t.Errorf("GET in read-only mode: %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests reached the API, want only the GET", got)
+	}
+}
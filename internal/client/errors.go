@@ -0,0 +1,171 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors for the API failure classes callers commonly branch on.
+// Use errors.Is to test an error returned by the client against them.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrValidation   = errors.New("validation failed")
+
+	// ErrReadOnly is returned, without contacting the API, for any mutating
+	// request made while the service is in read-only mode
+	ErrReadOnly = errors.New("refused: server is in read-only mode")
+)
+
+// APIError is returned for any non-2xx response from the M2A API
+type APIError struct {
+	StatusCode int
+	// Code and Message are parsed from the JSON error body when present
+	Code    string
+	Message string
+	// Body holds the raw response body when no message could be parsed
+	Body      string
+	RequestID string
+	Method    string
+	Endpoint  string
+	// Attempts is the number of attempts made, including retries
+	Attempts int
+	// Queued is the total time the request waited in the client-side limiter
+	Queued time.Duration
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "API error (status %d", e.StatusCode)
+	if e.Code != "" {
+		fmt.Fprintf(&b, ", code %s", e.Code)
+	}
+	b.WriteString(")")
+	if e.Method != "" {
+		fmt.Fprintf(&b, " from %s %s", e.Method, e.Endpoint)
+	}
+
+	switch {
+	case e.Message != "":
+		b.WriteString(": " + e.Message)
+	case e.Body != "":
+		b.WriteString(": " + e.Body)
+	}
+
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request_id %s]", e.RequestID)
+	}
+	if e.Attempts > 1 {
+		fmt.Fprintf(&b, " (after %d attempts)", e.Attempts)
+	}
+	if e.Queued >= time.Millisecond {
+		fmt.Fprintf(&b, " (queued %s by client rate limiter)", e.Queued.Round(time.Millisecond))
+	}
+	return b.String()
+}
+
+// Is maps the HTTP status onto the package's sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict || e.StatusCode == http.StatusPreconditionFailed
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	}
+	return false
+}
+
+// errorBody covers the error body shapes returned by the M2A APIs:
+// {"error": {"code": ..., "message": ...}}, {"code": ..., "message": ...},
+// {"error": "..."} and {"detail": "..."}
+type errorBody struct {
+	Error     json.RawMessage `json:"error"`
+	Code      json.RawMessage `json:"code"`
+	Message   string          `json:"message"`
+	Detail    string          `json:"detail"`
+	RequestID string          `json:"request_id"`
+}
+
+// newAPIError builds an APIError from a failed response and its body
+func newAPIError(req *http.Request, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     req.Method,
+		Endpoint:   req.URL.RequestURI(),
+		RequestID:  requestID(resp.Header),
+	}
+
+	var parsed errorBody
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		apiErr.Code = rawString(parsed.Code)
+		apiErr.Message = parsed.Message
+		if apiErr.Message == "" {
+			apiErr.Message = parsed.Detail
+		}
+
+		var nested struct {
+			Code    json.RawMessage `json:"code"`
+			Message string          `json:"message"`
+		}
+		if err := json.Unmarshal(parsed.Error, &nested); err == nil {
+			if code := rawString(nested.Code); code != "" {
+				apiErr.Code = code
+			}
+			if nested.Message != "" {
+				apiErr.Message = nested.Message
+			}
+		} else if msg := rawString(parsed.Error); msg != "" && apiErr.Message == "" {
+			apiErr.Message = msg
+		}
+
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = parsed.RequestID
+		}
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Body = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// requestID extracts the upstream request ID from the response headers
+func requestID(header http.Header) string {
+	for _, key := range []string{"X-Request-Id", "X-Amzn-Requestid", "X-Amz-Apigw-Id"} {
+		if id := header.Get(key); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// rawString renders a JSON string or number as a plain string
+func rawString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var sentinels = map[error]string{
+	ErrNotFound:     "ErrNotFound",
+	ErrUnauthorized: "ErrUnauthorized",
+	ErrConflict:     "ErrConflict",
+	ErrRateLimited:  "ErrRateLimited",
+	ErrValidation:   "ErrValidation",
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		headers   map[string]string
+		body      string
+		sentinel  error
+		code      string
+		message   string
+		rawBody   string
+		requestID string
+	}{
+		{"404 nested error", http.StatusNotFound, nil,
+			`{"error":{"code":"SOURCE_NOT_FOUND","message":"no such source"},"request_id":"req-1"}`,
+			ErrNotFound, "SOURCE_NOT_FOUND", "no such source", "", "req-1"},
+		{"410", http.StatusGone, nil, `{"message":"deleted"}`, ErrNotFound, "", "deleted", "", ""},
+		{"401 flat", http.StatusUnauthorized, map[string]string{"X-Request-Id": "hdr-1"},
+			`{"code":"UNAUTHORIZED","message":"bad key","request_id":"body-1"}`,
+			ErrUnauthorized, "UNAUTHORIZED", "bad key", "", "hdr-1"},
+		{"403", http.StatusForbidden, nil, `{"detail":"forbidden"}`, ErrUnauthorized, "", "forbidden", "", ""},
+		{"409 string error", http.StatusConflict, nil, `{"error":"channel is running"}`, ErrConflict, "", "channel is running", "", ""},
+		{"412", http.StatusPreconditionFailed, nil, `{"code":412,"message":"stale"}`, ErrConflict, "412", "stale", "", ""},
+		{"400", http.StatusBadRequest, map[string]string{"X-Amzn-Requestid": "amzn-1"},
+			`{"message":"name is required"}`, ErrValidation, "", "name is required", "", "amzn-1"},
+		{"422", http.StatusUnprocessableEntity, nil, `{"error":{"code":"INVALID","message":"bad bitrate"}}`,
+			ErrValidation, "INVALID", "bad bitrate", "", ""},
+		{"429", http.StatusTooManyRequests, map[string]string{"X-Amz-Apigw-Id": "gw-1"}, `{"message":"slow down"}`,
+			ErrRateLimited, "", "slow down", "", "gw-1"},
+		{"500 plain text", http.StatusInternalServerError, nil, "  upstream exploded\n", nil, "", "", "upstream exploded", ""},
+	}
+
+	var current int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tt := tests[current]
+		for k, v := range tt.headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(tt.status)
+		w.Write([]byte(tt.body))
+	}))
+	defer srv.Close()
+	c := testClient(srv, RetryPolicy{MaxAttempts: 1})
+
+	for i, tt := range tests {
+		current = i
+		_, err := c.Get(context.Background(), "/api/v2/connect/sources/src-1")
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: err = %v, want an *APIError", tt.name, err)
+			continue
+		}
+		for sentinel, name := range sentinels {
+			if got, want := errors.Is(err, sentinel), sentinel == tt.sentinel; got != want {
+				t.Errorf("%s: errors.Is(err, %s) = %v, want %v", tt.name, name, got, want)
+			}
+		}
+		if apiErr.StatusCode != tt.status || apiErr.Code != tt.code || apiErr.Message != tt.message ||
+			apiErr.Body != tt.rawBody || apiErr.RequestID != tt.requestID {
+			t.Errorf("%s: got status %d, code %q, message %q, body %q, request ID %q; want %d, %q, %q, %q, %q",
+				tt.name, apiErr.StatusCode, apiErr.Code, apiErr.Message, apiErr.Body, apiErr.RequestID,
+				tt.status, tt.code, tt.message, tt.rawBody, tt.requestID)
+		}
+		if apiErr.Method != http.MethodGet || apiErr.Endpoint != "/api/v2/connect/sources/src-1" {
+			t.Errorf("%s: request recorded as %s %s", tt.name, apiErr.Method, apiErr.Endpoint)
+		}
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	err := &APIError{
+		StatusCode: http.StatusNotFound,
+		Code:       "SOURCE_NOT_FOUND",
+		Message:    "no such source",
+		RequestID:  "req-1",
+		Method:     http.MethodGet,
+		Endpoint:   "/api/v2/connect/sources/src-1",
+		Attempts:   2,
+	}
+	want := "API error (status 404, code SOURCE_NOT_FOUND) from GET /api/v2/connect/sources/src-1: no such source [request_id req-1] (after 2 attempts)"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() =\n%s\nwant\n%s", got, want)
+	}
+
+	bare := &APIError{StatusCode: http.StatusBadGateway, Body: "<html>bad gateway</html>"}
+	if got := bare.Error(); !strings.HasSuffix(got, ": <html>bad gateway</html>") {
+		t.Errorf("Error() = %s, want the raw body", got)
+	}
+}
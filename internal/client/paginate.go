@@ -0,0 +1,247 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the page size requested when following offset pagination
+const DefaultPageSize = 100
+
+// PaginateOptions controls how a collection is walked
+type PaginateOptions struct {
+	// PageSize is the limit requested per page (DefaultPageSize if zero)
+	PageSize int
+	// MaxItems stops iteration once this many items have been returned (0 = no limit)
+	MaxItems int
+}
+
+// Page is a single page of a collection response
+type Page struct {
+	Items []json.RawMessage
+	// Total is the collection size reported by the API, or -1 if it didn't say
+	Total int
+	// Next is the cursor or URL of the next page, if the API uses cursor pagination
+	Next string
+}
+
+// Iterate walks every item of the collection at endpoint, following cursor
+// pagination when the API returns a cursor and limit/offset pagination
+// otherwise. Iteration stops at the end of the collection, at opts.MaxItems,
+// or at the first error, which is yielded with a zero item.
+func Iterate[T any](ctx context.Context, c *M2AClient, endpoint string, opts PaginateOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		pageSize := opts.PageSize
+		if pageSize <= 0 {
+			pageSize = DefaultPageSize
+		}
+
+		next := withQuery(endpoint, url.Values{"limit": {strconv.Itoa(pageSize)}})
+		seen := 0
+		var previousFirst json.RawMessage
+		var previousNext string
+
+		for {
+			data, err := c.Get(ctx, next)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			page, err := ParsePage(data)
+			if err != nil {
+				yield(zero, fmt.Errorf("failed to parse page from %s: %w", next, err))
+				return
+			}
+
+			// An empty page is the end, whatever cursor comes with it, and an
+			// API that ignores offset keeps returning the same page
+			if len(page.Items) == 0 || bytes.Equal(page.Items[0], previousFirst) {
+				return
+			}
+			previousFirst = page.Items[0]
+
+			for _, raw := range page.Items {
+				var item T
+				if err := json.Unmarshal(raw, &item); err != nil {
+					yield(zero, fmt.Errorf("failed to decode item from %s: %w", next, err))
+					return
+				}
+				if !yield(item, nil) {
+					return
+				}
+				seen++
+				if opts.MaxItems > 0 && seen >= opts.MaxItems {
+					return
+				}
+			}
+
+			switch {
+			case page.Next != "" && page.Next == previousNext:
+				// A cursor that doesn't move would return this page again
+				return
+			case page.Next != "":
+				previousNext = page.Next
+				if next, err = c.nextPageEndpoint(endpoint, page.Next, pageSize); err != nil {
+					yield(zero, err)
+					return
+				}
+			case len(page.Items) < pageSize, page.Total >= 0 && seen >= page.Total:
+				// A short page, or one that ignored our limit, is the last one
+				return
+			default:
+				next = withQuery(endpoint, url.Values{
+					"limit":  {strconv.Itoa(pageSize)},
+					"offset": {strconv.Itoa(seen)},
+				})
+			}
+		}
+	}
+}
+
+// ListAll collects every item of the collection at endpoint. See Iterate.
+func ListAll[T any](ctx context.Context, c *M2AClient, endpoint string, opts PaginateOptions) ([]T, error) {
+	var items []T
+	for item, err := range Iterate[T](ctx, c, endpoint, opts) {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ParsePage extracts the items and pagination metadata from a collection
+// response. It accepts a bare JSON array, or an object holding the items under
+// "items", "data", "results" or a single resource-named array field.
+func ParsePage(data []byte) (*Page, error) {
+	page := &Page{Total: -1}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &page.Items); err != nil {
+			return nil, err
+		}
+		return page, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, key := range []string{"items", "data", "results"} {
+		if raw, ok := fields[key]; ok && isArray(raw) {
+			if err := json.Unmarshal(raw, &page.Items); err != nil {
+				return nil, err
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		var arrays []string
+		for key, raw := range fields {
+			if isArray(raw) {
+				arrays = append(arrays, key)
+			}
+		}
+		if len(arrays) != 1 {
+			return nil, fmt.Errorf("could not find the item list in the response")
+		}
+		if err := json.Unmarshal(fields[arrays[0]], &page.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range []string{"total", "total_count", "totalCount", "count"} {
+		if raw, ok := fields[key]; ok {
+			var n int
+			if err := json.Unmarshal(raw, &n); err == nil {
+				page.Total = n
+				break
+			}
+		}
+	}
+
+	for _, key := range []string{"next_cursor", "nextCursor", "next_token", "nextToken", "cursor", "next"} {
+		if raw, ok := fields[key]; ok {
+			if next := rawString(raw); next != "" {
+				page.Next = next
+				break
+			}
+		}
+	}
+
+	return page, nil
+}
+
+// nextPageEndpoint turns a cursor into the endpoint for the next page. The
+// cursor may be an opaque token, a path or an absolute URL. A URL must be on
+// the API's host, though its scheme may differ; its path and query are used
+// relative to the base URL, so the client's credentials are never sent
+// anywhere else.
+func (c *M2AClient) nextPageEndpoint(endpoint, next string, pageSize int) (string, error) {
+	base, err := url.Parse(c.config.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	basePath := strings.TrimSuffix(base.Path, "/")
+
+	u, err := url.Parse(next)
+	switch {
+	case err == nil && u.Host != "":
+		if !strings.EqualFold(u.Host, base.Host) {
+			return "", fmt.Errorf("next page URL %s is not on the API host %s", next, base.Host)
+		}
+		path := trimBasePath(u.EscapedPath(), basePath)
+		if u.RawQuery != "" {
+			path += "?" + u.RawQuery
+		}
+		return path, nil
+	case strings.HasPrefix(next, "/"):
+		return trimBasePath(next, basePath), nil
+	}
+	return withQuery(endpoint, url.Values{
+		"limit":  {strconv.Itoa(pageSize)},
+		"cursor": {next},
+	}), nil
+}
+
+// trimBasePath removes the base URL's path, if any, from the start of a
+// server path, so that it can be appended to the base URL
+func trimBasePath(path, basePath string) string {
+	if basePath != "" && (path == basePath || strings.HasPrefix(path, basePath+"/")) {
+		return strings.TrimPrefix(path, basePath)
+	}
+	return path
+}
+
+// withQuery merges params into the query string of endpoint, replacing any
+// existing values for the same keys
+func withQuery(endpoint string, params url.Values) string {
+	path, rawQuery, _ := strings.Cut(endpoint, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		query = url.Values{}
+	}
+	for key, values := range params {
+		query[key] = values
+	}
+	return path + "?" + query.Encode()
+}
+
+// isArray reports whether raw holds a JSON array
+func isArray(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '['
+}
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		items int
+		total int
+		next  string
+	}{
+		{"bare array", `[{"id":"a"},{"id":"b"}]`, 2, -1, ""},
+		{"items", `{"items":[{"id":"a"}],"total":7}`, 1, 7, ""},
+		{"data", `{"data":[{"id":"a"},{"id":"b"}],"total_count":2,"next_cursor":"c2"}`, 2, 2, "c2"},
+		{"results", `{"results":[],"totalCount":0}`, 0, 0, ""},
+		{"named array", `{"sources":[{"id":"a"}],"count":1,"nextToken":"t"}`, 1, 1, "t"},
+		{"items preferred over other arrays", `{"items":[{"id":"a"}],"tags":["x","y"]}`, 1, -1, ""},
+		{"next URL", `{"items":[{"id":"a"}],"next":"https://api.example.com/x?cursor=2"}`, 1, -1, "https://api.example.com/x?cursor=2"},
+		{"null cursor", `{"items":[{"id":"a"}],"next_cursor":null,"cursor":"c"}`, 1, -1, "c"},
+	}
+	for _, tt := range tests {
+		page, err := ParsePage([]byte(tt.body))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(page.Items) != tt.items || page.Total != tt.total || page.Next != tt.next {
+			t.Errorf("%s: %d items, total %d, next %q; want %d, %d, %q",
+				tt.name, len(page.Items), page.Total, page.Next, tt.items, tt.total, tt.next)
+		}
+	}
+
+	for _, body := range []string{
+		`{"sources":[],"tags":[]}`,
+		`{"id":"a"}`,
+		`not json`,
+	} {
+		if _, err := ParsePage([]byte(body)); err == nil {
+			t.Errorf("ParsePage(%s) succeeded", body)
+		}
+	}
+}
+
+type item struct {
+	ID int `json:"id"`
+}
+
+// pagedServer serves a collection of n items through handler, which is given
+// the items and the request and writes a page
+func pagedServer(t *testing.T, n int, handler func(w http.ResponseWriter, r *http.Request, items []item)) (*M2AClient, *atomic.Int32) {
+	t.Helper()
+	items := make([]item, n)
+	for i := range items {
+		items[i] = item{ID: i}
+	}
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r, items)
+	}))
+	t.Cleanup(srv.Close)
+	return testClient(srv, quickRetries), &calls
+}
+
+// offsetPage writes the page of items selected by the limit and offset
+// parameters
+func offsetPage(w http.ResponseWriter, r *http.Request, items []item) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	end := min(offset+limit, len(items))
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": items[min(offset, end):end]})
+}
+
+// collect returns the IDs Iterate yields, stopping at the first error
+func collect(c *M2AClient, endpoint string, opts PaginateOptions) ([]int, error) {
+	var ids []int
+	for it, err := range Iterate[item](context.Background(), c, endpoint, opts) {
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, it.ID)
+	}
+	return ids, nil
+}
+
+func checkIDs(t *testing.T, ids []int, want int) {
+	t.Helper()
+	if len(ids) != want {
+		t.Fatalf("got %d items, want %d", len(ids), want)
+	}
+	for i, id := range ids {
+		if id != i {
+			t.Fatalf("item %d has ID %d", i, id)
+		}
+	}
+}
+
+func TestIterateOffset(t *testing.T) {
+	c, calls := pagedServer(t, 250, offsetPage)
+	ids, err := collect(c, "/api/v2/connect/sources", PaginateOptions{PageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIDs(t, ids, 250)
+	if got := calls.Load(); got != 3 {
+		t.Errorf("%d requests, want 3", got)
+	}
+
+	// A collection that fills its last page exactly ends on an empty page
+	c, calls = pagedServer(t, 200, offsetPage)
+	ids, err = collect(c, "/api/v2/connect/sources", PaginateOptions{PageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIDs(t, ids, 200)
+	if got := calls.Load(); got != 3 {
+		t.Errorf("%d requests, want 3", got)
+	}
+}
+
+func TestIterateCursor(t *testing.T) {
+	var base string
+	c, _ := pagedServer(t, 25, func(w http.ResponseWriter, r *http.Request, items []item) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		end := min(start+10, len(items))
+		page := map[string]interface{}{"data": items[start:end]}
+		switch {
+		case end == len(items):
+		case start == 0:
+			// An opaque token first, then a URL on the API's host
+			page["next_cursor"] = strconv.Itoa(end)
+		default:
+			page["next"] = base + "/api/v2/connect/sources?limit=10&cursor=" + strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+	base = c.config.BaseURL
+
+	ids, err := collect(c, "/api/v2/connect/sources", PaginateOptions{PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIDs(t, ids, 25)
+}
+
+func TestIterateMaxItems(t *testing.T) {
+	c, calls := pagedServer(t, 250, offsetPage)
+	ids, err := collect(c, "/api/v2/connect/sources", PaginateOptions{PageSize: 100, MaxItems: 150})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIDs(t, ids, 150)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+// An API that ignores offset returns the first page again, which ends
+// iteration rather than repeating the items forever
+func TestIterateIgnoresOffset(t *testing.T) {
+	c, calls := pagedServer(t, 250, func(w http.ResponseWriter, r *http.Request, items []item) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items[:100]})
+	})
+	ids, err := collect(c, "/api/v2/connect/sources", PaginateOptions{PageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIDs(t, ids, 100)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+// A cursor that doesn't move, with or without items, ends iteration
+func TestIterateStuckCursor(t *testing.T) {
+	c, calls := pagedServer(t, 0, func(w http.ResponseWriter, r *http.Request, items []item) {
+		w.Write([]byte(`{"items":[],"next_cursor":"same"}`))
+	})
+	if ids, err := collect(c, "/api/v2/connect/sources", PaginateOptions{}); err != nil || len(ids) != 0 {
+		t.Errorf("empty page: %v, %v", ids, err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("empty page: %d requests, want 1", got)
+	}
+
+	c, calls = pagedServer(t, 0, func(w http.ResponseWriter, r *http.Request, items []item) {
+		n := calls.Load() - 1
+		fmt.Fprintf(w, `{"items":[{"id":%d}],"next_cursor":"same"}`, n)
+	})
+	ids, err := collect(c, "/api/v2/connect/sources", PaginateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIDs(t, ids, 2)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("repeated cursor: %d requests, want 2", got)
+	}
+}
+
+// A next URL on another host is an error, not a request carrying our key
+func TestIterateForeignNextURL(t *testing.T) {
+	c, calls := pagedServer(t, 0, func(w http.ResponseWriter, r *http.Request, items []item) {
+		w.Write([]byte(`{"items":[{"id":0}],"next":"https://elsewhere.example.com/api/v2/connect/sources?cursor=2"}`))
+	})
+	ids, err := collect(c, "/api/v2/connect/sources", PaginateOptions{})
+	if err == nil || !strings.Contains(err.Error(), "not on the API host") {
+		t.Errorf("err = %v, want a host mismatch", err)
+	}
+	if len(ids) != 1 || calls.Load() != 1 {
+		t.Errorf("%d items from %d requests, want 1 from 1", len(ids), calls.Load())
+	}
+}
+
+func TestNextPageEndpoint(t *testing.T) {
+	c := NewM2AClient(&config.Config{BaseURL: "https://api.example.com/m2a/", APIKey: "test-key"})
+	for next, want := range map[string]string{
+		"abc123":                            "/api/x?cursor=abc123&limit=10",
+		"a b/c":                             "/api/x?cursor=a+b%2Fc&limit=10",
+		"/api/x?page=2":                     "/api/x?page=2",
+		"/m2a/api/x?page=2":                 "/api/x?page=2",
+		"https://api.example.com/m2a/api/x": "/api/x",
+		"http://API.example.com/api/x?p=2":  "/api/x?p=2",
+	} {
+		got, err := c.nextPageEndpoint("/api/x", next, 10)
+		if err != nil || got != want {
+			t.Errorf("nextPageEndpoint(%q) = %q, %v; want %q", next, got, err, want)
+		}
+	}
+
+	for _, next := range []string{
+		"https://api.example.com.evil.test/api/x",
+		"https://api.example.com:8443/api/x",
+		"//elsewhere.example.com/api/x",
+	} {
+		if got, err := c.nextPageEndpoint("/api/x", next, 10); err == nil {
+			t.Errorf("nextPageEndpoint(%q) = %q, want an error", next, got)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+// API families that can be throttled independently
+const (
+	FamilyConnect = "connect"
+	FamilyLive    = "live"
+	FamilyCapture = "capture"
+	FamilyVOD     = "vod"
+	FamilyOther   = "other"
+)
+
+// ErrThrottled is matched by errors.Is for requests the client-side limiter
+// refused to send
+var ErrThrottled = errors.New("throttled by client rate limiter")
+
+// ThrottleError is returned when a request could not get a rate-limit token
+// or an in-flight slot within its queueing budget
+type ThrottleError struct {
+	Family string
+	// Reason says which limit was hit
+	Reason string
+	// Wait is how long the request would have had to queue, if known
+	Wait time.Duration
+	// Waited is how long the request queued before it was rejected
+	Waited time.Duration
+}
+
+// Error implements the error interface
+func (e *ThrottleError) Error() string {
+	msg := fmt.Sprintf("request to %s API throttled: %s", e.Family, e.Reason)
+	if e.Wait > 0 {
+		msg += fmt.Sprintf(" (would have queued %s)", e.Wait.Round(time.Millisecond))
+	}
+	if e.Waited > 0 {
+		msg += fmt.Sprintf(" (queued %s)", e.Waited.Round(time.Millisecond))
+	}
+	return msg
+}
+
+// Is makes ThrottleError match ErrThrottled
+func (e *ThrottleError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// familyFor maps an endpoint onto the API family it belongs to
+func familyFor(endpoint string) string {
+	// Ignore any path prefix in the configured base URL
+	if i := strings.Index(endpoint, "/api/"); i > 0 {
+		endpoint = endpoint[i:]
+	}
+
+	switch {
+	case strings.HasPrefix(endpoint, "/api/v1/connect/capture"):
+		return FamilyCapture
+	case strings.HasPrefix(endpoint, "/api/v2/connect"):
+		return FamilyConnect
+	case strings.HasPrefix(endpoint, "/api/v3/live"), strings.HasPrefix(endpoint, "/api/v1/live"):
+		return FamilyLive
+	case strings.HasPrefix(endpoint, "/api/v1/vod"):
+		return FamilyVOD
+	}
+	return FamilyOther
+}
+
+// tokenBucket is a reservation-based token bucket. Tokens may go negative,
+// in which case the caller waits for the deficit to refill.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = max(1, int(math.Ceil(rate)))
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve that won't be used
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// Limiter throttles requests with an overall token bucket, optional per-family
+// buckets and a cap on the number of requests in flight
+type Limiter struct {
+	global   *tokenBucket
+	families map[string]*tokenBucket
+	inFlight chan struct{}
+	maxWait  time.Duration
+}
+
+// NewLimiter builds a Limiter from the service configuration. A zero rate or
+// in-flight cap disables that limit.
+func NewLimiter(cfg *config.Config) *Limiter {
+	l := &Limiter{
+		families: make(map[string]*tokenBucket),
+		maxWait:  cfg.MaxQueueWait,
+	}
+	if cfg.RateLimit > 0 {
+		l.global = newTokenBucket(cfg.RateLimit, cfg.RateBurst)
+	}
+	for family, rate := range cfg.FamilyRateLimits {
+		if rate > 0 {
+			l.families[family] = newTokenBucket(rate, 0)
+		}
+	}
+	if cfg.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return l
+}
+
+// Acquire waits for a rate-limit token and an in-flight slot for a request
+// to the given family. It returns a release func to call when the request
+// completes and how long the request queued.
+func (l *Limiter) Acquire(ctx context.Context, family string) (func(), time.Duration, error) {
+	start := time.Now()
+
+	var buckets []*tokenBucket
+	if l.global != nil {
+		buckets = append(buckets, l.global)
+	}
+	if b, ok := l.families[family]; ok {
+		buckets = append(buckets, b)
+	}
+
+	var wait time.Duration
+	for _, b := range buckets {
+		wait = max(wait, b.reserve(start))
+	}
+	// A request that isn't sent gives its tokens back
+	cancel := func() {
+		for _, b := range buckets {
+			b.cancel()
+		}
+	}
+
+	if wait > 0 {
+		reject := ""
+		if l.maxWait > 0 && wait > l.maxWait {
+			reject = fmt.Sprintf("rate limit exceeded, queueing longer than %s", l.maxWait)
+		} else if deadline, ok := ctx.Deadline(); ok && start.Add(wait).After(deadline) {
+			reject = "rate limit exceeded, queueing would pass the call's deadline"
+		}
+		if reject != "" {
+			cancel()
+			return nil, 0, &ThrottleError{Family: family, Reason: reject, Wait: wait}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			cancel()
+			return nil, 0, &ThrottleError{
+				Family: family,
+				Reason: fmt.Sprintf("call ended while waiting for a rate-limit token: %v", ctx.Err()),
+				Waited: time.Since(start),
+			}
+		case <-timer.C:
+		}
+	}
+
+	if l.inFlight == nil {
+		return func() {}, time.Since(start), nil
+	}
+
+	slotCtx := ctx
+	if l.maxWait > 0 {
+		var stop context.CancelFunc
+		slotCtx, stop = context.WithTimeout(ctx, l.maxWait)
+		defer stop()
+	}
+
+	select {
+	case l.inFlight <- struct{}{}:
+		return func() { <-l.inFlight }, time.Since(start), nil
+	case <-slotCtx.Done():
+		cancel()
+		reason := fmt.Sprintf("all %d in-flight request slots busy", cap(l.inFlight))
+		if ctx.Err() != nil {
+			reason += fmt.Sprintf(" until the call ended: %v", ctx.Err())
+		} else {
+			reason += fmt.Sprintf(" for longer than %s", l.maxWait)
+		}
+		return nil, 0, &ThrottleError{Family: family, Reason: reason, Waited: time.Since(start)}
+	}
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(10, 3)
+	now := b.last
+
+	// The burst is available at once, then tokens arrive every 100ms
+	for i := 0; i < 3; i++ {
+		if wait := b.reserve(now); wait != 0 {
+			t.Fatalf("reservation %d within the burst waits %s", i+1, wait)
+		}
+	}
+	if wait := b.reserve(now); wait != 100*time.Millisecond {
+		t.Errorf("reservation beyond the burst waits %s, want 100ms", wait)
+	}
+	if wait := b.reserve(now); wait != 200*time.Millisecond {
+		t.Errorf("second reservation beyond the burst waits %s, want 200ms", wait)
+	}
+
+	// Cancelled reservations are given back
+	b.cancel()
+	b.cancel()
+	if wait := b.reserve(now.Add(100 * time.Millisecond)); wait != 0 {
+		t.Errorf("reservation after a refill waits %s", wait)
+	}
+
+	// Refill stops at the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		b.reserve(now)
+	}
+	if wait := b.reserve(now); wait == 0 {
+		t.Error("an idle bucket refilled past its burst")
+	}
+}
+
+func TestTokenBucketDefaultBurst(t *testing.T) {
+	for rate, want := range map[float64]float64{0.5: 1, 2.5: 3, 10: 10} {
+		if got := newTokenBucket(rate, 0).burst; got != want {
+			t.Errorf("burst for rate %v = %v, want %v", rate, got, want)
+		}
+	}
+}
+
+func TestFamilyFor(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"/api/v2/connect/sources":          FamilyConnect,
+		"/api/v1/connect/capture/jobs":     FamilyCapture,
+		"/api/v3/live/channels/ch-1/start": FamilyLive,
+		"/api/v1/vod/assets":               FamilyVOD,
+		"/prefix/api/v1/vod/assets":        FamilyVOD,
+		"/api/v1/accounts":                 FamilyOther,
+	} {
+		if got := familyFor(endpoint); got != want {
+			t.Errorf("familyFor(%s) = %s, want %s", endpoint, got, want)
+		}
+	}
+}
+
+// acquired acquires and releases at once, returning how long it queued
+func acquired(t *testing.T, l *Limiter, ctx context.Context, family string) time.Duration {
+	t.Helper()
+	release, waited, err := l.Acquire(ctx, family)
+	if err != nil {
+		t.Fatalf("Acquire(%s): %v", family, err)
+	}
+	release()
+	return waited
+}
+
+// throttled checks that Acquire refuses with a reason containing want
+func throttled(t *testing.T, l *Limiter, ctx context.Context, family, want string) *ThrottleError {
+	t.Helper()
+	_, _, err := l.Acquire(ctx, family)
+	var throttle *ThrottleError
+	if !errors.As(err, &throttle) || !errors.Is(err, ErrThrottled) {
+		t.Fatalf("Acquire(%s) = %v, want a ThrottleError", family, err)
+	}
+	if throttle.Family != family || !strings.Contains(throttle.Reason, want) {
+		t.Errorf("Acquire(%s) = %v, want it throttled for %q", family, err, want)
+	}
+	return throttle
+}
+
+func TestLimiterQueues(t *testing.T) {
+	l := NewLimiter(&config.Config{RateLimit: 20, RateBurst: 1, MaxQueueWait: time.Second})
+	ctx := context.Background()
+
+	acquired(t, l, ctx, FamilyConnect)
+	if waited := acquired(t, l, ctx, FamilyConnect); waited < 40*time.Millisecond {
+		t.Errorf("second request queued %s, want about 50ms", waited)
+	}
+}
+
+func TestLimiterFamilies(t *testing.T) {
+	l := NewLimiter(&config.Config{
+		FamilyRateLimits: map[string]float64{FamilyLive: 1},
+		MaxQueueWait:     10 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	acquired(t, l, ctx, FamilyLive)
+	throttled(t, l, ctx, FamilyLive, "longer than 10ms")
+
+	// Other families have no bucket of their own and no overall limit
+	for i := 0; i < 5; i++ {
+		if waited := acquired(t, l, ctx, FamilyConnect); waited > 10*time.Millisecond {
+			t.Errorf("connect request queued %s behind the live limit", waited)
+		}
+	}
+}
+
+func TestLimiterMaxWait(t *testing.T) {
+	l := NewLimiter(&config.Config{RateLimit: 1, RateBurst: 1, MaxQueueWait: 100 * time.Millisecond})
+	ctx := context.Background()
+
+	acquired(t, l, ctx, FamilyVOD)
+	throttle := throttled(t, l, ctx, FamilyVOD, "queueing longer than 100ms")
+	if throttle.Wait < 900*time.Millisecond {
+		t.Errorf("Wait = %s, want about a second", throttle.Wait)
+	}
+
+	// The rejected request didn't keep its token, so the next one is
+	// refused for the same wait rather than a longer one
+	if again := throttled(t, l, ctx, FamilyVOD, "queueing"); again.Wait > throttle.Wait {
+		t.Errorf("Wait grew from %s to %s after a rejection", throttle.Wait, again.Wait)
+	}
+}
+
+func TestLimiterDeadline(t *testing.T) {
+	l := NewLimiter(&config.Config{RateLimit: 1, RateBurst: 1})
+	acquired(t, l, context.Background(), FamilyConnect)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	throttled(t, l, ctx, FamilyConnect, "deadline")
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("rejection took %s; it should be immediate", elapsed)
+	}
+}
+
+func TestLimiterCancelledWhileQueued(t *testing.T) {
+	l := NewLimiter(&config.Config{RateLimit: 1, RateBurst: 1})
+	acquired(t, l, context.Background(), FamilyConnect)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	throttle := throttled(t, l, ctx, FamilyConnect, "call ended")
+	if throttle.Waited < 20*time.Millisecond {
+		t.Errorf("Waited = %s, want at least 20ms", throttle.Waited)
+	}
+}
+
+func TestLimiterInFlight(t *testing.T) {
+	l := NewLimiter(&config.Config{RateLimit: 1, RateBurst: 3, MaxInFlight: 2, MaxQueueWait: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, _, err := l.Acquire(ctx, FamilyLive)
+		if err != nil {
+			t.Fatalf("Acquire %d: %v", i+1, err)
+		}
+		releases = append(releases, release)
+	}
+	tokens := l.global.tokens
+
+	throttle := throttled(t, l, ctx, FamilyLive, "all 2 in-flight request slots busy for longer than 50ms")
+	if throttle.Waited < 50*time.Millisecond {
+		t.Errorf("Waited = %s, want at least 50ms", throttle.Waited)
+	}
+	// The rejected request's token was given back
+	if l.global.tokens < tokens {
+		t.Errorf("tokens fell from %v to %v for a request that wasn't sent", tokens, l.global.tokens)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	throttled(t, l, cancelled, FamilyLive, "until the call ended")
+
+	// A released slot can be taken again
+	releases[0]()
+	acquired(t, l, ctx, FamilyLive)
+	releases[1]()
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the first
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles on each attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the computed backoff. A server that asks, with
+	// Retry-After, for a longer wait isn't retried: its response is returned
+	// as the final error rather than blocking the call.
+	MaxDelay time.Duration
+}
+
+// NewRetryPolicy builds a RetryPolicy from the service configuration
+func NewRetryPolicy(cfg *config.Config) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: cfg.RetryMaxAttempts,
+		BaseDelay:   cfg.RetryBaseDelay,
+		MaxDelay:    cfg.RetryMaxDelay,
+	}
+}
+
+// backoff returns the delay before the given retry (1 for the first retry),
+// using exponential backoff with full jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+
+	ceiling := p.BaseDelay << (retry - 1)
+	if ceiling <= 0 || (p.MaxDelay > 0 && ceiling > p.MaxDelay) {
+		ceiling = p.MaxDelay
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// RequestOption customises a single API call
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	retryable   bool
+	maxAttempts int
+}
+
+// Retryable marks a request as safe to retry. GET, PUT and DELETE are always
+// retried; POST is only retried when the caller knows it is idempotent.
+func Retryable() RequestOption {
+	return func(o *requestOptions) {
+		o.retryable = true
+	}
+}
+
+// WithMaxAttempts overrides the policy's attempt budget for a single request
+func WithMaxAttempts(n int) RequestOption {
+	return func(o *requestOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// isIdempotent reports whether requests with the given method are retried by default
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryableStatus reports whether a response status indicates a transient failure
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isRetryableError reports whether a transport error is worth retrying: a
+// dropped connection or a timeout. Failures that won't go away by
+// themselves, such as a refused connection or an unknown host, fail at once.
+func isRetryableError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryAfter parses a Retry-After header (delta-seconds or HTTP date) on 429
+// and 503 responses. It returns false if the header is absent or invalid.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if when, err := http.ParseTime(value); err == nil {
+		delay := time.Until(when)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+)
+
+// flakyServer fails the first failures requests with status, setting any
+// headers given, then succeeds. It counts every request it receives.
+func flakyServer(t *testing.T, failures int, status int, headers map[string]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code":"FLAKY","message":"try again"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// testClient returns a client for srv that retries quickly
+func testClient(srv *httptest.Server, policy RetryPolicy) *M2AClient {
+	c := NewM2AClient(&config.Config{BaseURL: srv.URL, APIKey: "test-key"})
+	c.SetRetryPolicy(policy)
+	return c
+}
+
+var quickRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetryIdempotentMethods(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+			srv, calls := flakyServer(t, 2, status, nil)
+			c := testClient(srv, quickRetries)
+
+			var err error
+			switch method {
+			case http.MethodGet:
+				_, err = c.Get(context.Background(), "/api/v2/connect/sources")
+			case http.MethodPut:
+				_, err = c.Put(context.Background(), "/api/v2/connect/sources/src-1", map[string]string{"name": "x"})
+			case http.MethodDelete:
+				_, err = c.Delete(context.Background(), "/api/v2/connect/sources/src-1")
+			}
+			if err != nil {
+				t.Errorf("%s after two %d responses: %v", method, status, err)
+			}
+			if got := calls.Load(); got != 3 {
+				t.Errorf("%s with %d: %d requests, want 3", method, status, got)
+			}
+		}
+	}
+}
+
+func TestRetryNotOnClientErrors(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusBadRequest, nil)
+	_, err := testClient(srv, quickRetries).Get(context.Background(), "/api/v2/connect/sources")
+	if err == nil || calls.Load() != 1 {
+		t.Errorf("400 was retried: err %v after %d requests", err, calls.Load())
+	}
+}
+
+func TestRetryPostOnlyWhenRetryable(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	c := testClient(srv, quickRetries)
+	if _, err := c.Post(context.Background(), "/api/v2/connect/sources", map[string]string{}); err == nil {
+		t.Error("POST succeeded after a 503 without retrying")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("POST was sent %d times, want 1", got)
+	}
+
+	srv, calls = flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	c = testClient(srv, quickRetries)
+	if _, err := c.Post(context.Background(), "/api/v2/connect/sources", map[string]string{}, Retryable()); err != nil {
+		t.Errorf("Retryable POST: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Retryable POST was sent %d times, want 2", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"seconds", "0"},
+		{"HTTP date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)},
+	}
+	for _, tt := range tests {
+		srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": tt.value})
+		policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour}
+		start := time.Now()
+		if _, err := testClient(srv, policy).Get(context.Background(), "/api/v2/connect/sources"); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		// Retry-After replaces the hour-long backoff
+		if elapsed := time.Since(start); elapsed > 5*time.Second || calls.Load() != 2 {
+			t.Errorf("%s: %d requests in %s", tt.name, calls.Load(), elapsed)
+		}
+	}
+}
+
+func TestRetryAfterParsing(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+		ok    bool
+	}{
+		{"2", 2 * time.Second, 2 * time.Second, true},
+		{time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat), 28 * time.Second, 30 * time.Second, true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0, true},
+		{"-1", 0, 0, false},
+		{"soon", 0, 0, false},
+	} {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {tt.value}}}
+		got, ok := retryAfter(resp)
+		if ok != tt.ok || got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %s, %v; want %s-%s, %v", tt.value, got, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+
+	resp := &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{"Retry-After": {"2"}}}
+	if _, ok := retryAfter(resp); ok {
+		t.Error("Retry-After was honoured on a 500")
+	}
+}
+
+// A Retry-After longer than MaxDelay ends retrying with the server's error
+func TestRetryAfterBeyondMaxDelay(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusServiceUnavailable, map[string]string{"Retry-After": "120"})
+	_, err := testClient(srv, quickRetries).Get(context.Background(), "/api/v2/connect/sources")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want the 503", err)
+	}
+	if calls.Load() != 1 || apiErr.Attempts != 1 {
+		t.Errorf("%d requests, Attempts %d; want 1", calls.Load(), apiErr.Attempts)
+	}
+}
+
+func TestRetryBudgetExhausted(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusBadGateway, nil)
+	_, err := testClient(srv, quickRetries).Get(context.Background(), "/api/v2/connect/sources")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want an *APIError", err)
+	}
+	if apiErr.Attempts != 3 || calls.Load() != 3 {
+		t.Errorf("Attempts = %d after %d requests, want 3", apiErr.Attempts, calls.Load())
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("error doesn't report the attempts: %v", err)
+	}
+
+	// WithMaxAttempts overrides the policy for one call
+	srv, calls = flakyServer(t, 10, http.StatusBadGateway, nil)
+	_, err = testClient(srv, quickRetries).Get(context.Background(), "/api/v2/connect/sources", WithMaxAttempts(5))
+	if !errors.As(err, &apiErr) || apiErr.Attempts != 5 || calls.Load() != 5 {
+		t.Errorf("WithMaxAttempts(5): %d requests, err %v", calls.Load(), err)
+	}
+}
+
+func TestRetryCancelledDuringBackoff(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusServiceUnavailable, nil)
+	c := testClient(srv, RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.Get(ctx, "/api/v2/connect/sources")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancellation took %s to end the backoff", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
+
+// A connection the server drops is retried
+func TestRetryConnectionReset(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// Closing with no linger sends a reset rather than a clean close
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	if _, err := testClient(srv, quickRetries).Get(context.Background(), "/api/v2/connect/sources"); err != nil {
+		t.Errorf("GET after a reset: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+// A refused connection, such as a wrong base URL, fails at once
+func TestRetryNotOnRefusedConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	c := NewM2AClient(&config.Config{BaseURL: "http://" + addr, APIKey: "test-key"})
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour})
+	start := time.Now()
+	_, err = c.Get(context.Background(), "/api/v2/connect/sources")
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Errorf("err = %v, want a refused connection", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("refused connection took %s to fail; it was retried", elapsed)
+	}
+}
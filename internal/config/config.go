@@ -1,8 +1,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the configuration for the M2A MCP service
@@ -10,6 +16,84 @@ type Config struct {
 	APIKey       string
 	BaseURL      string
 	AWSAccountID string
+
+	// MCP transport ("stdio" or "http") and, for http, the address to listen on
+	Transport  string
+	ListenAddr string
+
+	// Inbound authentication for the http transport. AuthTokensFile lists
+	// "<client-name> <token>" pairs; AuthDisabled must be set explicitly to
+	// serve http without it. ClientName identifies the stdio client.
+	AuthTokensFile string
+	AuthDisabled   bool
+	ClientName     string
+
+	// ReadOnly hides mutating tools and makes the client refuse non-GET requests
+	ReadOnly bool
+
+	// ConfirmTTL is how long a destructive-operation preview's token stays valid
+	ConfirmTTL time.Duration
+
+	// Audit log destination ("stderr", a file path or "off"), and for files the
+	// size in bytes at which it rotates and how many rotated files to keep
+	AuditLog        string
+	AuditMaxSize    int64
+	AuditMaxBackups int
+
+	// Retry policy for upstream API calls
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+
+	// Client-side throttling. RateLimit and FamilyRateLimits are in requests
+	// per second; zero disables a limit.
+	RateLimit        float64
+	RateBurst        int
+	FamilyRateLimits map[string]float64
+	MaxInFlight      int
+	MaxQueueWait     time.Duration
+
+	// Deadline applied to each tool call, with optional per-tool overrides
+	ToolTimeout  time.Duration
+	ToolTimeouts map[string]time.Duration
+
+	// HTTP cassettes: CassetteMode is "off", "record" or "replay" and
+	// CassettePath the file to write or read. CassetteSecrets are extra
+	// values scrubbed from recordings and the audit log along with the API
+	// key.
+	CassetteMode    string
+	CassettePath    string
+	CassetteSecrets []string
+
+	// ResourcePollInterval is how often subscribed resources are checked for
+	// changes; zero disables change notifications
+	ResourcePollInterval time.Duration
+
+	// ChannelPollInterval is how often start_channel and stop_channel check
+	// the channel's state when asked to wait
+	ChannelPollInterval time.Duration
+
+	// JobsFile is where tracked jobs are saved (empty keeps them in memory)
+	// and JobPollInterval how often running jobs are checked
+	JobsFile        string
+	JobPollInterval time.Duration
+
+	// PromptsDir holds extra prompt definitions, added to the built-in ones
+	PromptsDir string
+}
+
+// TimeoutFor returns the deadline for the named tool
+func (c *Config) TimeoutFor(tool string) time.Duration {
+	if d, ok := c.ToolTimeouts[tool]; ok {
+		return d
+	}
+	return c.ToolTimeout
+}
+
+// Secrets returns the values that must never be written out: the API key
+// and the configured CassetteSecrets
+func (c *Config) Secrets() []string {
+	return append([]string{c.APIKey}, c.CassetteSecrets...)
 }
 
 // Load reads configuration from environment variables
@@ -29,9 +113,293 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("M2A_AWS_ACCOUNT_ID environment variable is required")
 	}
 
+	transport := os.Getenv("M2A_TRANSPORT")
+	if transport == "" {
+		transport = "stdio"
+	}
+
+	listenAddr := os.Getenv("M2A_LISTEN_ADDR")
+	if listenAddr == "" {
+		listenAddr = ":8080"
+	}
+
+	authDisabled, err := getEnvBool("M2A_AUTH_DISABLED", false)
+	if err != nil {
+		return nil, err
+	}
+
+	readOnly, err := getEnvBool("M2A_READ_ONLY", false)
+	if err != nil {
+		return nil, err
+	}
+
+	clientName := os.Getenv("M2A_CLIENT_NAME")
+	if clientName == "" {
+		clientName = "local"
+	}
+
+	confirmTTL, err := getEnvDuration("M2A_CONFIRM_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog := os.Getenv("M2A_AUDIT_LOG")
+	if auditLog == "" {
+		auditLog = "stderr"
+	}
+
+	auditMaxSizeMB, err := getEnvInt("M2A_AUDIT_MAX_SIZE_MB", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	auditMaxBackups, err := getEnvInt("M2A_AUDIT_MAX_BACKUPS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	retryMaxAttempts, err := getEnvInt("M2A_RETRY_MAX_ATTEMPTS", 3)
+	if err != nil {
+		return nil, err
+	}
+	if retryMaxAttempts < 1 {
+		return nil, fmt.Errorf("M2A_RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+
+	retryBaseDelay, err := getEnvDuration("M2A_RETRY_BASE_DELAY", 500*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+
+	retryMaxDelay, err := getEnvDuration("M2A_RETRY_MAX_DELAY", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit, err := getEnvFloat("M2A_RATE_LIMIT", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	rateBurst, err := getEnvInt("M2A_RATE_BURST", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	familyRateLimits := make(map[string]float64)
+	for _, family := range []string{"connect", "live", "capture", "vod"} {
+		rate, err := getEnvFloat("M2A_RATE_LIMIT_"+strings.ToUpper(family), 0)
+		if err != nil {
+			return nil, err
+		}
+		if rate > 0 {
+			familyRateLimits[family] = rate
+		}
+	}
+
+	maxInFlight, err := getEnvInt("M2A_MAX_IN_FLIGHT", 8)
+	if err != nil {
+		return nil, err
+	}
+
+	maxQueueWait, err := getEnvDuration("M2A_MAX_QUEUE_WAIT", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	toolTimeout, err := getEnvDuration("M2A_TOOL_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	toolTimeouts, err := parseToolTimeouts(os.Getenv("M2A_TOOL_TIMEOUTS"))
+	if err != nil {
+		return nil, err
+	}
+
+	cassetteMode := os.Getenv("M2A_CASSETTE_MODE")
+	if cassetteMode == "" {
+		cassetteMode = "off"
+	}
+	if cassetteMode != "off" && cassetteMode != "record" && cassetteMode != "replay" {
+		return nil, fmt.Errorf("M2A_CASSETTE_MODE must be off, record or replay, got %q", cassetteMode)
+	}
+
+	cassettePath := os.Getenv("M2A_CASSETTE_PATH")
+	if cassetteMode != "off" && cassettePath == "" {
+		return nil, fmt.Errorf("M2A_CASSETTE_PATH is required when M2A_CASSETTE_MODE is %s", cassetteMode)
+	}
+
+	var cassetteSecrets []string
+	for _, secret := range strings.Split(os.Getenv("M2A_CASSETTE_SCRUB"), ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			cassetteSecrets = append(cassetteSecrets, secret)
+		}
+	}
+
+	resourcePollInterval, err := getEnvDuration("M2A_RESOURCE_POLL_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	channelPollInterval, err := getEnvDuration("M2A_CHANNEL_POLL_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if channelPollInterval == 0 {
+		return nil, fmt.Errorf("M2A_CHANNEL_POLL_INTERVAL must be positive")
+	}
+
+	jobsFile := os.Getenv("M2A_JOBS_FILE")
+	switch jobsFile {
+	case "off":
+		jobsFile = ""
+	case "":
+		if dir, err := os.UserCacheDir(); err == nil {
+			jobsFile = filepath.Join(dir, "m2a-mcp", defaultJobsFile(baseURL, awsAccountID))
+		}
+	}
+
+	jobPollInterval, err := getEnvDuration("M2A_JOB_POLL_INTERVAL", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if jobPollInterval == 0 {
+		return nil, fmt.Errorf("M2A_JOB_POLL_INTERVAL must be positive")
+	}
+
 	return &Config{
-		APIKey:       apiKey,
-		BaseURL:      baseURL,
-		AWSAccountID: awsAccountID,
+		APIKey:               apiKey,
+		BaseURL:              baseURL,
+		AWSAccountID:         awsAccountID,
+		Transport:            transport,
+		ListenAddr:           listenAddr,
+		AuthTokensFile:       os.Getenv("M2A_AUTH_TOKENS_FILE"),
+		AuthDisabled:         authDisabled,
+		ClientName:           clientName,
+		ReadOnly:             readOnly,
+		ConfirmTTL:           confirmTTL,
+		AuditLog:             auditLog,
+		AuditMaxSize:         int64(auditMaxSizeMB) << 20,
+		AuditMaxBackups:      auditMaxBackups,
+		RetryMaxAttempts:     retryMaxAttempts,
+		RetryBaseDelay:       retryBaseDelay,
+		RetryMaxDelay:        retryMaxDelay,
+		RateLimit:            rateLimit,
+		RateBurst:            rateBurst,
+		FamilyRateLimits:     familyRateLimits,
+		MaxInFlight:          maxInFlight,
+		MaxQueueWait:         maxQueueWait,
+		ToolTimeout:          toolTimeout,
+		ToolTimeouts:         toolTimeouts,
+		CassetteMode:         cassetteMode,
+		CassettePath:         cassettePath,
+		CassetteSecrets:      cassetteSecrets,
+		ResourcePollInterval: resourcePollInterval,
+		ChannelPollInterval:  channelPollInterval,
+		JobsFile:             jobsFile,
+		JobPollInterval:      jobPollInterval,
+		PromptsDir:           os.Getenv("M2A_PROMPTS_DIR"),
 	}, nil
 }
+
+// parseToolTimeouts parses per-tool overrides of the form
+// "start_channel=2m,list_sources=10s"
+func parseToolTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	if value == "" {
+		return timeouts, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		tool, raw, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(tool) == "" {
+			return nil, fmt.Errorf("M2A_TOOL_TIMEOUTS entry %q must be of the form tool=duration", entry)
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("M2A_TOOL_TIMEOUTS entry %q has an invalid duration: %w", entry, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("M2A_TOOL_TIMEOUTS entry %q must be positive", entry)
+		}
+		timeouts[strings.TrimSpace(tool)] = d
+	}
+	return timeouts, nil
+}
+
+// getEnvInt reads an integer environment variable, falling back to def when unset
+func getEnvInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvBool reads a boolean environment variable, falling back to def when unset
+func getEnvBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false: %w", key, err)
+	}
+	return b, nil
+}
+
+// getEnvFloat reads a non-negative number from the environment, falling back to def when unset
+func getEnvFloat(key string, def float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", key, err)
+	}
+	if f < 0 {
+		return 0, fmt.Errorf("%s must not be negative", key)
+	}
+	return f, nil
+}
+
+// getEnvDuration reads a duration environment variable (e.g. "500ms", "2s"),
+// falling back to def when unset
+func getEnvDuration(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 500ms or 2s: %w", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative", key)
+	}
+	return d, nil
+}
+
+// defaultJobsFile names the jobs file for one API and account, so servers
+// for different accounts on the same machine never share tracked jobs
+func defaultJobsFile(baseURL, awsAccountID string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSuffix(baseURL, "/") + "\n" + awsAccountID))
+	return "jobs-" + hex.EncodeToString(sum[:6]) + ".json"
+}
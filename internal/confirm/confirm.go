@@ -0,0 +1,91 @@
+package confirm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Errors returned by Redeem
+var (
+	ErrUnknownToken  = errors.New("unknown or already used confirmation token")
+	ErrExpired       = errors.New("confirmation token has expired")
+	ErrScopeMismatch = errors.New("confirmation token was issued for a different operation")
+)
+
+// Scope is the exact operation a confirmation token authorises
+type Scope struct {
+	// Action is the tool name, e.g. "delete_channel"
+	Action     string
+	ResourceID string
+	// Client is the identity the preview was issued to
+	Client string
+}
+
+type pending struct {
+	scope   Scope
+	expires time.Time
+}
+
+// Store issues and redeems short-lived, single-use confirmation tokens for
+// destructive operations
+type Store struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	tokens map[string]pending
+	now    func() time.Time
+}
+
+// NewStore creates a Store whose tokens expire after ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:    ttl,
+		tokens: make(map[string]pending),
+		now:    time.Now,
+	}
+}
+
+// Issue creates a token authorising exactly the given scope
+func (s *Store) Issue(scope Scope) (string, time.Time) {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := "cfm_" + hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for t, p := range s.tokens {
+		if now.After(p.expires) {
+			delete(s.tokens, t)
+		}
+	}
+
+	expires := now.Add(s.ttl)
+	s.tokens[token] = pending{scope: scope, expires: expires}
+	return token, expires
+}
+
+// Redeem consumes a token if it was issued for scope and hasn't expired. A
+// token presented for the wrong scope is not consumed.
+func (s *Store) Redeem(token string, scope Scope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.tokens[token]
+	if !ok {
+		return ErrUnknownToken
+	}
+	if s.now().After(p.expires) {
+		delete(s.tokens, token)
+		return ErrExpired
+	}
+	if p.scope != scope {
+		return ErrScopeMismatch
+	}
+
+	delete(s.tokens, token)
+	return nil
+}
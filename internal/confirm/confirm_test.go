@@ -0,0 +1,105 @@
+package confirm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a Store on a clock the test moves with advance
+func newTestStore(ttl time.Duration) (*Store, func(time.Duration)) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore(ttl)
+	s.now = func() time.Time { return now }
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+var scope = Scope{Action: "delete_channel", ResourceID: "ch-0001", Client: "alice"}
+
+func TestIssue(t *testing.T) {
+	s, _ := newTestStore(time.Minute)
+	token, expires := s.Issue(scope)
+	other, _ := s.Issue(scope)
+
+	if !strings.HasPrefix(token, "cfm_") || len(token) != len("cfm_")+32 {
+		t.Errorf("token = %q, want cfm_ and 32 hex digits", token)
+	}
+	if token == other {
+		t.Error("two tokens for the same scope are equal")
+	}
+	if want := s.now().Add(time.Minute); !expires.Equal(want) {
+		t.Errorf("expires = %s, want %s", expires, want)
+	}
+}
+
+// A token can be redeemed once
+func TestRedeemSingleUse(t *testing.T) {
+	s, _ := newTestStore(time.Minute)
+	token, _ := s.Issue(scope)
+
+	if err := s.Redeem(token, scope); err != nil {
+		t.Fatalf("Redeem: %v", err)
+	}
+	if err := s.Redeem(token, scope); !errors.Is(err, ErrUnknownToken) {
+		t.Errorf("second Redeem = %v, want ErrUnknownToken", err)
+	}
+	if err := s.Redeem("cfm_made_up", scope); !errors.Is(err, ErrUnknownToken) {
+		t.Errorf("Redeem of a made-up token = %v, want ErrUnknownToken", err)
+	}
+}
+
+func TestRedeemExpired(t *testing.T) {
+	s, advance := newTestStore(time.Minute)
+	token, _ := s.Issue(scope)
+	later, _ := s.Issue(scope)
+
+	advance(time.Minute)
+	if err := s.Redeem(later, scope); err != nil {
+		t.Errorf("Redeem at the expiry time: %v", err)
+	}
+
+	advance(time.Second)
+	if err := s.Redeem(token, scope); !errors.Is(err, ErrExpired) {
+		t.Errorf("Redeem after expiry = %v, want ErrExpired", err)
+	}
+	// An expired token is gone
+	if err := s.Redeem(token, scope); !errors.Is(err, ErrUnknownToken) {
+		t.Errorf("Redeem of an expired token again = %v, want ErrUnknownToken", err)
+	}
+}
+
+// Issuing a token drops expired ones
+func TestIssuePrunesExpired(t *testing.T) {
+	s, advance := newTestStore(time.Minute)
+	s.Issue(scope)
+	advance(2 * time.Minute)
+	s.Issue(scope)
+
+	if len(s.tokens) != 1 {
+		t.Errorf("%d tokens held, want only the unexpired one", len(s.tokens))
+	}
+}
+
+// A token only authorises the exact scope it was issued for, and presenting
+// it for another doesn't use it up
+func TestRedeemScopeMismatch(t *testing.T) {
+	s, _ := newTestStore(time.Minute)
+	token, _ := s.Issue(scope)
+
+	for name, other := range map[string]Scope{
+		"action":      {Action: "stop_channel", ResourceID: scope.ResourceID, Client: scope.Client},
+		"resource":    {Action: scope.Action, ResourceID: "ch-0002", Client: scope.Client},
+		"client":      {Action: scope.Action, ResourceID: scope.ResourceID, Client: "bob"},
+		"no client":   {Action: scope.Action, ResourceID: scope.ResourceID},
+		"empty scope": {},
+	} {
+		if err := s.Redeem(token, other); !errors.Is(err, ErrScopeMismatch) {
+			t.Errorf("Redeem with a different %s = %v, want ErrScopeMismatch", name, err)
+		}
+	}
+
+	if err := s.Redeem(token, scope); err != nil {
+		t.Errorf("Redeem for the real scope after mismatches: %v", err)
+	}
+}
@@ -0,0 +1,418 @@
+// Package fake is an in-memory stand-in for the M2A Media APIs. It serves
+// every endpoint the MCP tools call, keeps state between requests, moves
+// channels and captures through their lifecycles over time and can be told to
+// fail, so the tools can be exercised without touching the real cloud.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures a fake server
+type Options struct {
+	// APIKey, if set, must be presented as a bearer token on every request
+	APIKey string
+	// StartDelay is how long a channel spends STARTING before it is RUNNING
+	StartDelay time.Duration
+	// StopDelay is how long a channel spends STOPPING before it is IDLE
+	StopDelay time.Duration
+	// Seed loads a small set of sample resources
+	Seed bool
+	// Now is the server's clock; defaults to time.Now
+	Now func() time.Time
+}
+
+// record is a stored resource, serialised as-is
+type record map[string]interface{}
+
+// collection is an ordered set of resources of one kind
+type collection struct {
+	prefix string
+	next   int
+	order  []string
+	items  map[string]record
+}
+
+// transition is a pending channel state change
+type transition struct {
+	state string
+	at    time.Time
+}
+
+// Server is a fake M2A API. It is safe for concurrent use.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+
+	mu          sync.Mutex
+	collections map[string]*collection
+	transitions map[string]transition
+	faults      []*Fault
+}
+
+// Collection names, which are also the last path segment of their endpoints
+const (
+	sources        = "sources"
+	subscribers    = "subscribers"
+	subscriptions  = "subscriptions"
+	schedules      = "schedules"
+	channels       = "channels"
+	encoderConfigs = "encoder-configs"
+	workflows      = "workflows"
+	captures       = "captures"
+	exports        = "exports"
+	clips          = "clips"
+	assets         = "assets"
+)
+
+// New creates a fake server
+func New(opts Options) *Server {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	s.Reset()
+	s.routes()
+	return s
+}
+
+// NewTestServer starts a fake on a local port. Callers must Close it.
+func NewTestServer(opts Options) (*Server, *httptest.Server) {
+	s := New(opts)
+	return s, httptest.NewServer(s)
+}
+
+// Reset discards all state and faults, reloading the seed data if enabled
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.collections = map[string]*collection{
+		sources:        {prefix: "src"},
+		subscribers:    {prefix: "sub"},
+		subscriptions:  {prefix: "pkg"},
+		schedules:      {prefix: "sch"},
+		channels:       {prefix: "ch"},
+		encoderConfigs: {prefix: "enc"},
+		workflows:      {prefix: "wf"},
+		captures:       {prefix: "cap"},
+		exports:        {prefix: "exp"},
+		clips:          {prefix: "clip"},
+		assets:         {prefix: "asset"},
+	}
+	for _, c := range s.collections {
+		c.items = map[string]record{}
+	}
+	s.transitions = map[string]transition{}
+	s.faults = nil
+
+	if s.opts.Seed {
+		s.seed()
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/_fake/") {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+
+	if s.opts.APIKey != "" && r.Header.Get("Authorization") != "Bearer "+s.opts.APIKey {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid API key")
+		return
+	}
+
+	if fault := s.matchFault(r); fault != nil {
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.Status != 0 {
+			if fault.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Round(time.Second)/time.Second)))
+			}
+			writeError(w, fault.Status, fault.code(), fault.message())
+			return
+		}
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// routes registers every endpoint
+func (s *Server) routes() {
+	s.crud("/api/v2/connect/sources", sources, s.validateSource)
+	s.crud("/api/v2/connect/subscribers", subscribers, s.validateSubscriber)
+	s.crud("/api/v2/connect/subscriptions", subscriptions, s.validateSubscription)
+	s.crud("/api/v2/connect/schedules", schedules, s.validateSchedule)
+
+	s.handle("GET /api/v1/connect/capture", s.list(captures))
+	s.handle("POST /api/v1/connect/capture", s.create(captures, s.validateCapture))
+	s.handle("GET /api/v1/connect/capture/{id}", s.get(captures))
+	s.handle("POST /api/v1/connect/capture/{id}/cancel", s.cancelCapture)
+	s.handle("GET /api/v1/connect/capture/exports", s.list(exports))
+	s.handle("GET /api/v1/connect/capture/exports/{id}", s.get(exports))
+	s.handle("GET /api/v1/connect/capture/clips", s.list(clips))
+	s.handle("POST /api/v1/connect/capture/clips", s.create(clips, s.validateClip))
+	s.handle("GET /api/v1/connect/capture/clips/{id}", s.get(clips))
+
+	s.handle("GET /api/v3/live/channels", s.list(channels))
+	s.handle("POST /api/v3/live/channels", s.create(channels, s.validateChannel))
+	s.handle("GET /api/v3/live/channels/{id}", s.get(channels))
+	s.handle("PUT /api/v3/live/channels/{id}", s.update(channels))
+	s.handle("DELETE /api/v3/live/channels/{id}", s.deleteChannel)
+	s.handle("POST /api/v3/live/channels/{id}/start", s.startChannel)
+	s.handle("POST /api/v3/live/channels/{id}/stop", s.stopChannel)
+	s.crud("/api/v1/live/encoder-configs", encoderConfigs, s.validateNamed)
+	s.crud("/api/v1/live/workflows", workflows, s.validateNamed)
+
+	s.handle("GET /api/v1/vod/assets", s.list(assets))
+	s.handle("GET /api/v1/vod/assets/{id}", s.get(assets))
+	s.handle("PUT /api/v1/vod/assets/{id}", s.update(assets))
+	s.handle("DELETE /api/v1/vod/assets/{id}", s.delete(assets))
+	s.handle("GET /api/v1/vod/assets/{id}/playback", s.playback)
+
+	s.mux.HandleFunc("POST /_fake/faults", s.handleAddFault)
+	s.mux.HandleFunc("DELETE /_fake/faults", s.handleClearFaults)
+	s.mux.HandleFunc("POST /_fake/reset", func(w http.ResponseWriter, r *http.Request) {
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	})
+}
+
+// crud registers list, create, get, update and delete for a collection
+func (s *Server) crud(path, name string, validate func(record) error) {
+	s.handle("GET "+path, s.list(name))
+	s.handle("POST "+path, s.create(name, validate))
+	s.handle("GET "+path+"/{id}", s.get(name))
+	s.handle("PUT "+path+"/{id}", s.update(name))
+	s.handle("DELETE "+path+"/{id}", s.delete(name))
+}
+
+// handle registers a handler that runs with the state locked and lifecycles
+// brought up to date
+func (s *Server) handle(pattern string, h http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.advance()
+		h(w, r)
+	})
+}
+
+// list serves a collection, filtered by any query parameter that names a
+// field and paginated with limit and offset
+func (s *Server) list(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		c := s.collections[name]
+
+		items := []record{}
+		for _, id := range c.order {
+			if item := c.items[id]; matches(item, query) {
+				items = append(items, item)
+			}
+		}
+		total := len(items)
+
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		items = items[min(max(offset, 0), total):]
+		if limit > 0 && limit < len(items) {
+			items = items[:limit]
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": items, "total": total})
+	}
+}
+
+// get serves one resource
+func (s *Server) get(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if item := s.lookup(w, name, r.PathValue("id")); item != nil {
+			writeJSON(w, http.StatusOK, item)
+		}
+	}
+}
+
+// create stores a new resource from the request body
+func (s *Server) create(name string, validate func(record) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		if err := validate(body); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, s.insert(name, body))
+	}
+}
+
+// update merges the request body into a resource. Identity and lifecycle
+// fields can't be changed.
+func (s *Server) update(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		item := s.lookup(w, name, r.PathValue("id"))
+		if item == nil {
+			return
+		}
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		if len(body) == 0 {
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "no fields to update")
+			return
+		}
+
+		for k, v := range body {
+			switch k {
+			case "id", "created_at", "updated_at", "state", "status":
+				continue
+			}
+			item[k] = v
+		}
+		for _, k := range []string{"source_ids", "tags"} {
+			if v, ok := item[k]; ok {
+				item[k] = idList(v)
+			}
+		}
+		item["updated_at"] = s.timestamp()
+
+		writeJSON(w, http.StatusOK, item)
+	}
+}
+
+// delete removes a resource
+func (s *Server) delete(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if s.lookup(w, name, id) == nil {
+			return
+		}
+		s.remove(name, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// lookup finds a resource, writing a 404 if it doesn't exist
+func (s *Server) lookup(w http.ResponseWriter, name, id string) record {
+	item, ok := s.collections[name].items[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("%s %s not found", strings.TrimSuffix(name, "s"), id))
+		return nil
+	}
+	return item
+}
+
+// insert assigns an ID and timestamps to item and stores it
+func (s *Server) insert(name string, item record) record {
+	c := s.collections[name]
+	c.next++
+	id := fmt.Sprintf("%s-%04d", c.prefix, c.next)
+
+	item["id"] = id
+	item["created_at"] = s.timestamp()
+	c.items[id] = item
+	c.order = append(c.order, id)
+	return item
+}
+
+// remove deletes a resource
+func (s *Server) remove(name, id string) {
+	c := s.collections[name]
+	delete(c.items, id)
+	for i, v := range c.order {
+		if v == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	delete(s.transitions, id)
+}
+
+// timestamp formats the current fake time
+func (s *Server) timestamp() string {
+	return s.opts.Now().UTC().Format(time.RFC3339)
+}
+
+// matches reports whether item satisfies the list filters in query. Unknown
+// parameters and "all" are ignored; start_date and end_date bound a window.
+func matches(item record, query map[string][]string) bool {
+	for key, values := range query {
+		if len(values) == 0 || values[0] == "" || values[0] == "all" {
+			continue
+		}
+		want := values[0]
+
+		switch key {
+		case "limit", "offset":
+			continue
+		case "start_date":
+			if end, _ := item["end_time"].(string); end != "" && end < want {
+				return false
+			}
+			continue
+		case "end_date":
+			if start, _ := item["start_time"].(string); start != "" && start > want {
+				return false
+			}
+			continue
+		}
+
+		if v, ok := item[key]; ok && !strings.EqualFold(fmt.Sprint(v), want) {
+			return false
+		}
+	}
+	return true
+}
+
+// readBody decodes a JSON object request body, writing a 400 if it is malformed
+func readBody(w http.ResponseWriter, r *http.Request) (record, bool) {
+	body := record{}
+	if r.ContentLength == 0 {
+		return body, true
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON", "request body must be a JSON object")
+		return nil, false
+	}
+	if body == nil {
+		body = record{}
+	}
+	return body, true
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the API's {"error":{code,message}} shape
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
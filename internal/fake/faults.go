@@ -0,0 +1,107 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Fault makes matching requests fail or slow down
+type Fault struct {
+	// Method restricts the fault to one HTTP method; empty matches all
+	Method string `json:"method,omitempty"`
+	// Path is a path prefix the fault applies to; empty matches all
+	Path string `json:"path,omitempty"`
+	// Status is the error status to return; 0 lets the request through
+	// after Delay
+	Status int `json:"status,omitempty"`
+	// Code and Message override the error body
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	// RetryAfter is sent as a Retry-After header with the error
+	RetryAfter time.Duration `json:"-"`
+	// Delay holds the request before responding
+	Delay time.Duration `json:"-"`
+	// Times limits how many requests fail; 0 fails until cleared
+	Times int `json:"times,omitempty"`
+}
+
+// InjectFault adds a fault. Faults are checked in the order they were added.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every fault
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// matchFault returns the first fault that applies to r, using up one of its
+// Times
+func (s *Server) matchFault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (f *Fault) code() string {
+	if f.Code != "" {
+		return f.Code
+	}
+	return strings.ToUpper(strings.ReplaceAll(http.StatusText(f.Status), " ", "_"))
+}
+
+func (f *Fault) message() string {
+	if f.Message != "" {
+		return f.Message
+	}
+	return "injected fault: " + http.StatusText(f.Status)
+}
+
+// handleAddFault adds a fault from a JSON body, taking delay_ms and
+// retry_after_seconds alongside the Fault fields
+func (s *Server) handleAddFault(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Fault
+		DelayMS           int `json:"delay_ms"`
+		RetryAfterSeconds int `json:"retry_after_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON", "request body must be a JSON fault")
+		return
+	}
+
+	f := body.Fault
+	f.Delay = time.Duration(body.DelayMS) * time.Millisecond
+	f.RetryAfter = time.Duration(body.RetryAfterSeconds) * time.Second
+	s.InjectFault(f)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleClearFaults removes every fault
+func (s *Server) handleClearFaults(w http.ResponseWriter, r *http.Request) {
+	s.ClearFaults()
+	w.WriteHeader(http.StatusNoContent)
+}
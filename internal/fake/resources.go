@@ -0,0 +1,401 @@
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Lifecycle states, matching the real API
+const (
+	stateIdle     = "IDLE"
+	stateStarting = "STARTING"
+	stateRunning  = "RUNNING"
+	stateStopping = "STOPPING"
+
+	statusPending    = "PENDING"
+	statusInProgress = "IN_PROGRESS"
+	statusCompleted  = "COMPLETED"
+	statusCancelled  = "CANCELLED"
+)
+
+// captureFrameRate is the frame rate reported for every fake capture
+const captureFrameRate = "25"
+
+var timecodePattern = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}[:;]\d{2}$`)
+
+// required checks that each named field is a non-empty string
+func required(item record, fields ...string) error {
+	for _, field := range fields {
+		if v, _ := item[field].(string); strings.TrimSpace(v) == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+	}
+	return nil
+}
+
+// oneOf checks that a field, if present, holds one of allowed
+func oneOf(item record, field string, allowed ...string) error {
+	v, ok := item[field].(string)
+	if !ok {
+		return nil
+	}
+	for _, a := range allowed {
+		if v == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s", field, strings.Join(allowed, ", "))
+}
+
+// parseWindow parses start_time and end_time, requiring end after start
+func parseWindow(item record) (time.Time, time.Time, error) {
+	start, err := time.Parse(time.RFC3339, fmt.Sprint(item["start_time"]))
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("start_time must be an ISO 8601 timestamp")
+	}
+	end, err := time.Parse(time.RFC3339, fmt.Sprint(item["end_time"]))
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("end_time must be an ISO 8601 timestamp")
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.New("end_time must be after start_time")
+	}
+	return start, end, nil
+}
+
+// idList normalises a list of IDs given as an array or comma-separated string
+func idList(v interface{}) []interface{} {
+	ids := []interface{}{}
+	switch v := v.(type) {
+	case string:
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	case []interface{}:
+		for _, id := range v {
+			if s, ok := id.(string); ok && s != "" {
+				ids = append(ids, s)
+			}
+		}
+	}
+	return ids
+}
+
+func (s *Server) validateNamed(item record) error {
+	return required(item, "name")
+}
+
+func (s *Server) validateSource(item record) error {
+	if err := required(item, "name", "type", "url"); err != nil {
+		return err
+	}
+	if err := oneOf(item, "type", "rtmp", "srt", "udp", "rtp"); err != nil {
+		return err
+	}
+	item["status"] = "active"
+	return nil
+}
+
+func (s *Server) validateSubscriber(item record) error {
+	if err := required(item, "name", "email"); err != nil {
+		return err
+	}
+	if email, _ := item["email"].(string); !strings.Contains(email, "@") {
+		return errors.New("email must be a valid address")
+	}
+	item["status"] = "active"
+	return nil
+}
+
+func (s *Server) validateSubscription(item record) error {
+	if err := required(item, "name", "subscriber_id"); err != nil {
+		return err
+	}
+	if _, ok := s.collections[subscribers].items[fmt.Sprint(item["subscriber_id"])]; !ok {
+		return fmt.Errorf("subscriber %v does not exist", item["subscriber_id"])
+	}
+
+	ids := idList(item["source_ids"])
+	if len(ids) == 0 {
+		return errors.New("source_ids is required")
+	}
+	for _, id := range ids {
+		if _, ok := s.collections[sources].items[id.(string)]; !ok {
+			return fmt.Errorf("source %s does not exist", id)
+		}
+	}
+	item["source_ids"] = ids
+	item["status"] = "active"
+	return nil
+}
+
+func (s *Server) validateSchedule(item record) error {
+	if err := required(item, "name", "source_id", "start_time", "end_time"); err != nil {
+		return err
+	}
+	if _, ok := s.collections[sources].items[fmt.Sprint(item["source_id"])]; !ok {
+		return fmt.Errorf("source %v does not exist", item["source_id"])
+	}
+	if _, _, err := parseWindow(item); err != nil {
+		return err
+	}
+	item["status"] = "scheduled"
+	return nil
+}
+
+func (s *Server) validateChannel(item record) error {
+	if err := required(item, "name", "input_type"); err != nil {
+		return err
+	}
+	if err := oneOf(item, "input_type", "RTMP_PUSH", "RTP_PUSH", "UDP_PUSH", "MEDIACONNECT"); err != nil {
+		return err
+	}
+	if id, ok := item["encoder_config_id"].(string); ok && id != "" {
+		if _, ok := s.collections[encoderConfigs].items[id]; !ok {
+			return fmt.Errorf("encoder config %s does not exist", id)
+		}
+	}
+	item["state"] = stateIdle
+	return nil
+}
+
+func (s *Server) validateCapture(item record) error {
+	if err := required(item, "name", "channel_id", "start_time", "end_time"); err != nil {
+		return err
+	}
+	if _, ok := s.collections[channels].items[fmt.Sprint(item["channel_id"])]; !ok {
+		return fmt.Errorf("channel %v does not exist", item["channel_id"])
+	}
+	start, end, err := parseWindow(item)
+	if err != nil {
+		return err
+	}
+	item["status"] = statusPending
+	item["frame_rate"] = captureFrameRate
+	item["duration_seconds"] = end.Sub(start).Seconds()
+	return nil
+}
+
+func (s *Server) validateClip(item record) error {
+	if err := required(item, "capture_id", "start_timecode", "end_timecode", "name"); err != nil {
+		return err
+	}
+	capture, ok := s.collections[captures].items[fmt.Sprint(item["capture_id"])]
+	if !ok {
+		return fmt.Errorf("capture %v does not exist", item["capture_id"])
+	}
+	if status := capture["status"]; status != statusInProgress && status != statusCompleted {
+		return fmt.Errorf("capture %v is %v; clips can only be cut from in-progress or completed captures", item["capture_id"], status)
+	}
+
+	start, _ := item["start_timecode"].(string)
+	end, _ := item["end_timecode"].(string)
+	if !timecodePattern.MatchString(start) || !timecodePattern.MatchString(end) {
+		return errors.New("timecodes must be HH:MM:SS:FF")
+	}
+	if end <= start {
+		return errors.New("end_timecode must be after start_timecode")
+	}
+
+	asset := s.insert(assets, record{
+		"title":      item["name"],
+		"source":     "clip",
+		"capture_id": item["capture_id"],
+		"tags":       []interface{}{},
+		"status":     "READY",
+	})
+	item["asset_id"] = asset["id"]
+	item["status"] = statusCompleted
+	return nil
+}
+
+// advance applies every lifecycle change that is due at the current time
+func (s *Server) advance() {
+	now := s.opts.Now()
+
+	for id, t := range s.transitions {
+		if !now.Before(t.at) {
+			if channel, ok := s.collections[channels].items[id]; ok {
+				channel["state"] = t.state
+			}
+			delete(s.transitions, id)
+		}
+	}
+
+	for _, id := range s.collections[captures].order {
+		capture := s.collections[captures].items[id]
+		status := capture["status"]
+		if status != statusPending && status != statusInProgress {
+			continue
+		}
+
+		start, end, err := parseWindow(capture)
+		switch {
+		case err != nil:
+		case !now.Before(end):
+			capture["status"] = statusCompleted
+			s.exportCapture(capture)
+		case !now.Before(start) && status == statusPending:
+			capture["status"] = statusInProgress
+		}
+	}
+}
+
+// exportCapture creates the VOD asset and export for a completed capture
+func (s *Server) exportCapture(capture record) {
+	asset := s.insert(assets, record{
+		"title":            capture["name"],
+		"source":           "capture",
+		"capture_id":       capture["id"],
+		"duration_seconds": capture["duration_seconds"],
+		"tags":             []interface{}{},
+		"status":           "READY",
+	})
+	s.insert(exports, record{
+		"capture_id": capture["id"],
+		"asset_id":   asset["id"],
+		"status":     statusCompleted,
+	})
+	capture["asset_id"] = asset["id"]
+}
+
+// startChannel moves an idle channel to STARTING, then RUNNING after StartDelay
+func (s *Server) startChannel(w http.ResponseWriter, r *http.Request) {
+	s.changeChannelState(w, r, []string{stateIdle}, stateStarting, stateRunning, s.opts.StartDelay)
+}
+
+// stopChannel moves a running or starting channel to STOPPING, then IDLE
+// after StopDelay
+func (s *Server) stopChannel(w http.ResponseWriter, r *http.Request) {
+	s.changeChannelState(w, r, []string{stateRunning, stateStarting}, stateStopping, stateIdle, s.opts.StopDelay)
+}
+
+// changeChannelState moves a channel in one of from to via, scheduling the
+// move to final after delay
+func (s *Server) changeChannelState(w http.ResponseWriter, r *http.Request, from []string, via, final string, delay time.Duration) {
+	id := r.PathValue("id")
+	channel := s.lookup(w, channels, id)
+	if channel == nil {
+		return
+	}
+
+	state, _ := channel["state"].(string)
+	allowed := false
+	for _, f := range from {
+		allowed = allowed || state == f
+	}
+	if !allowed {
+		writeError(w, http.StatusConflict, "INVALID_STATE", fmt.Sprintf("channel %s is %s", id, state))
+		return
+	}
+
+	channel["state"] = via
+	s.transitions[id] = transition{state: final, at: s.opts.Now().Add(delay)}
+	writeJSON(w, http.StatusOK, channel)
+}
+
+// deleteChannel deletes a channel that isn't running
+func (s *Server) deleteChannel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	channel := s.lookup(w, channels, id)
+	if channel == nil {
+		return
+	}
+	if state := channel["state"]; state != stateIdle {
+		writeError(w, http.StatusConflict, "INVALID_STATE", fmt.Sprintf("channel %s is %s; stop it before deleting", id, state))
+		return
+	}
+
+	s.remove(channels, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cancelCapture cancels a pending or in-progress capture
+func (s *Server) cancelCapture(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	capture := s.lookup(w, captures, id)
+	if capture == nil {
+		return
+	}
+	if status := capture["status"]; status != statusPending && status != statusInProgress {
+		writeError(w, http.StatusConflict, "INVALID_STATE", fmt.Sprintf("capture %s is %s", id, status))
+		return
+	}
+
+	capture["status"] = statusCancelled
+	writeJSON(w, http.StatusOK, capture)
+}
+
+// playback serves a playback URL for a VOD asset
+func (s *Server) playback(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if s.lookup(w, assets, id) == nil {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	manifests := map[string]string{"hls": "index.m3u8", "dash": "manifest.mpd", "mp4": id + ".mp4"}
+	if format == "" {
+		format = "hls"
+	}
+	manifest, ok := manifests[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "format must be one of hls, dash, mp4")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record{
+		"url":        fmt.Sprintf("https://vod.fake.m2amedia.tv/%s/%s", id, manifest),
+		"format":     format,
+		"expires_at": s.opts.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+}
+
+// seed loads sample resources: two sources in a subscription, a scheduled
+// event, an idle and a running channel, and a completed capture with its
+// export and VOD asset
+func (s *Server) seed() {
+	now := s.opts.Now().UTC().Truncate(time.Second)
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+
+	studio := s.insert(sources, record{"name": "Studio A Camera 1", "type": "rtmp", "url": "rtmp://ingest.example.com/live/studio-a", "status": "active"})
+	stadium := s.insert(sources, record{"name": "Stadium Feed", "type": "srt", "url": "srt://ingest.example.com:9000", "status": "active"})
+	partner := s.insert(subscribers, record{"name": "Broadcast Partner", "email": "partner@example.com", "organization": "Example Sports", "status": "active"})
+	s.insert(subscriptions, record{"name": "Premium Package", "subscriber_id": partner["id"], "source_ids": []interface{}{studio["id"], stadium["id"]}, "status": "active"})
+	s.insert(schedules, record{"name": "Evening Match", "source_id": stadium["id"], "start_time": at(2 * time.Hour), "end_time": at(4 * time.Hour), "status": "scheduled"})
+
+	encoder := s.insert(encoderConfigs, record{
+		"name":        "1080p50 H.264",
+		"description": "1080p50 AVC ladder for sport",
+		"video": map[string]interface{}{
+			"codec":     "H.264",
+			"framerate": 50,
+			"ladder": []interface{}{
+				map[string]interface{}{"height": 1080, "bitrate_kbps": 6000},
+				map[string]interface{}{"height": 720, "bitrate_kbps": 3500},
+				map[string]interface{}{"height": 540, "bitrate_kbps": 2000},
+			},
+		},
+		"audio": map[string]interface{}{"codec": "AAC", "bitrate_kbps": 128, "channels": 2},
+	})
+	s.insert(workflows, record{"name": "Sports Live", "description": "Contribution to OTT delivery", "status": "active"})
+	s.insert(channels, record{"name": "News Channel", "input_type": "RTMP_PUSH", "encoder_config_id": encoder["id"], "state": stateIdle})
+	sports := s.insert(channels, record{"name": "Sports Channel", "input_type": "MEDIACONNECT", "encoder_config_id": encoder["id"], "state": stateRunning})
+
+	s.insert(captures, record{
+		"name":             "Morning Bulletin",
+		"channel_id":       sports["id"],
+		"start_time":       at(-2 * time.Hour),
+		"end_time":         at(-time.Hour),
+		"status":           statusPending,
+		"frame_rate":       captureFrameRate,
+		"duration_seconds": time.Hour.Seconds(),
+	})
+	s.advance()
+}
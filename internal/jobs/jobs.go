@@ -0,0 +1,510 @@
+// Package jobs tracks the asynchronous work the server starts on the
+// platform (captures, the exports they produce, and clips) and polls it in
+// the background until it finishes. Jobs are saved to a file so they
+// survive restarts.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/auth"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
+)
+
+// Kinds of job
+const (
+	KindCapture = "capture"
+	KindExport  = "export"
+	KindClip    = "clip"
+)
+
+// Job states, independent of the platform's own status values
+const (
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// maxFinished bounds how many finished jobs are kept
+const maxFinished = 500
+
+// ErrUnknownJob is returned for a job ID the tracker doesn't know
+var ErrUnknownJob = errors.New("unknown job")
+
+// Job is one piece of asynchronous work on the platform
+type Job struct {
+	// ID is "<kind>:<resource ID>", e.g. "capture:cap-0003"
+	ID         string `json:"id"`
+	Kind       string `json:"kind"`
+	ResourceID string `json:"resource_id"`
+	Name       string `json:"name,omitempty"`
+	// Parent is the job that produced this one, e.g. an export's capture
+	Parent string `json:"parent,omitempty"`
+	// Status is the platform's status, e.g. IN_PROGRESS
+	Status  string `json:"status"`
+	State   string `json:"state"`
+	AssetID string `json:"asset_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// StartedBy is the client that started the job
+	StartedBy  string     `json:"started_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished reports whether the job has stopped, successfully or not
+func (j Job) Finished() bool {
+	return j.State != StateRunning
+}
+
+// jobID returns the ID of the job for a resource
+func jobID(kind, resourceID string) string {
+	return kind + ":" + resourceID
+}
+
+// Tracker records jobs and polls the running ones
+type Tracker struct {
+	capture  *m2a.CaptureService
+	path     string
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	onFinish func(Job)
+}
+
+// New creates a Tracker that polls running jobs every interval and saves
+// them to path. An empty path keeps jobs in memory only.
+func New(c *client.M2AClient, path string, interval time.Duration) (*Tracker, error) {
+	t := &Tracker{
+		capture:  m2a.NewCaptureService(c),
+		path:     path,
+		interval: interval,
+		now:      time.Now,
+		jobs:     make(map[string]*Job),
+	}
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// OnFinish registers a function to be called when a job finishes
+func (t *Tracker) OnFinish(fn func(Job)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onFinish = fn
+}
+
+// Track starts tracking a job the caller has just created on the platform
+func (t *Tracker) Track(ctx context.Context, kind, resourceID, name, status string) Job {
+	startedBy := ""
+	if identity, ok := auth.FromContext(ctx); ok {
+		startedBy = identity.Name
+	}
+
+	t.mu.Lock()
+	job, added := t.add(&Job{
+		ID:         jobID(kind, resourceID),
+		Kind:       kind,
+		ResourceID: resourceID,
+		Name:       name,
+		Status:     status,
+		StartedBy:  startedBy,
+	})
+	t.mu.Unlock()
+
+	// The platform may report the work finished as soon as it is created
+	if added && job.Finished() {
+		if err := t.finish(ctx, job); err != nil {
+			log.Printf("Failed to track the exports of job %s: %v", job.ID, err)
+		}
+	}
+	return job
+}
+
+// add records a new job, or returns the existing one with the same ID,
+// reporting whether it was added. The caller must hold t.mu.
+func (t *Tracker) add(job *Job) (Job, bool) {
+	if existing, ok := t.jobs[job.ID]; ok {
+		return *existing, false
+	}
+
+	now := t.now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	job.State = stateFor(job.Status)
+	if job.Finished() {
+		job.FinishedAt = &now
+	}
+	t.jobs[job.ID] = job
+	t.commit()
+	return *job, true
+}
+
+// Get returns a job by ID. A job another client started is reported as
+// unknown; see List.
+func (t *Tracker) Get(ctx context.Context, id string) (Job, error) {
+	job, err := t.get(id)
+	if err == nil && !visible(ctx, job) {
+		return Job{}, fmt.Errorf("%w %q", ErrUnknownJob, id)
+	}
+	return job, err
+}
+
+// get returns a job by ID, whoever started it
+func (t *Tracker) get(id string) (Job, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	job, ok := t.jobs[id]
+	if !ok {
+		return Job{}, fmt.Errorf("%w %q", ErrUnknownJob, id)
+	}
+	return *job, nil
+}
+
+// List returns the jobs matching kind and state, newest first. Empty
+// filters match everything. A client authenticated over HTTP sees only the
+// jobs it started; local and anonymous clients see them all.
+func (t *Tracker) List(ctx context.Context, kind, state string) []Job {
+	jobs := []Job{}
+	for _, job := range t.list(kind, state) {
+		if visible(ctx, job) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// list returns the jobs matching kind and state, whoever started them
+func (t *Tracker) list(kind, state string) []Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	jobs := []Job{}
+	for _, job := range t.jobs {
+		if (kind == "" || job.Kind == kind) && (state == "" || job.State == state) {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs
+}
+
+// visible reports whether the client calling with ctx may see job
+func visible(ctx context.Context, job Job) bool {
+	identity, ok := auth.FromContext(ctx)
+	if !ok || identity.Transport != "http" || identity.Name == auth.Anonymous {
+		return true
+	}
+	return job.StartedBy == identity.Name
+}
+
+// Wait blocks until a job finishes or ctx ends, polling it every interval.
+// Like Get, it only finds jobs the calling client may see.
+// onPoll, if set, is called with the job after each poll. When ctx ends
+// first, the job as last seen is returned with an error wrapping ctx.Err().
+func (t *Tracker) Wait(ctx context.Context, id string, onPoll func(Job)) (Job, error) {
+	for {
+		job, err := t.Get(ctx, id)
+		if err != nil {
+			return job, err
+		}
+		if !job.Finished() {
+			if err := t.refresh(ctx, id); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to poll job %s: %v", id, err)
+			}
+			if job, err = t.get(id); err != nil {
+				return job, err
+			}
+		}
+		if onPoll != nil {
+			onPoll(job)
+		}
+		if job.Finished() {
+			return job, nil
+		}
+
+		timer := time.NewTimer(t.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return job, fmt.Errorf("job %s is still %s: %w", id, job.Status, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Run polls running jobs until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	if t.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.poll(ctx)
+		}
+	}
+}
+
+// poll refreshes every running job once
+func (t *Tracker) poll(ctx context.Context) {
+	for _, job := range t.list("", StateRunning) {
+		if err := t.refresh(ctx, job.ID); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to poll job %s: %v", job.ID, err)
+		}
+	}
+}
+
+// refresh fetches a job's current status from the platform
+func (t *Tracker) refresh(ctx context.Context, id string) error {
+	job, err := t.get(id)
+	if err != nil || job.Finished() {
+		return err
+	}
+
+	var status, assetID string
+	switch job.Kind {
+	case KindCapture:
+		capture, err := t.capture.GetCapture(ctx, job.ResourceID)
+		if err != nil {
+			return t.failIfGone(ctx, job, err)
+		}
+		status, assetID = capture.Status, capture.AssetID
+	case KindExport:
+		export, err := t.capture.GetExport(ctx, job.ResourceID)
+		if err != nil {
+			return t.failIfGone(ctx, job, err)
+		}
+		status, assetID = export.Status, export.AssetID
+	case KindClip:
+		clip, err := t.capture.GetClip(ctx, job.ResourceID)
+		if err != nil {
+			return t.failIfGone(ctx, job, err)
+		}
+		status, assetID = clip.Status, clip.AssetID
+	default:
+		return fmt.Errorf("job %s has unknown kind %q", id, job.Kind)
+	}
+
+	if finished := t.update(id, status, assetID, ""); finished != nil {
+		return t.finish(ctx, *finished)
+	}
+	return nil
+}
+
+// finish reports a job that has just finished and, if it is a capture that
+// succeeded, starts tracking its exports
+func (t *Tracker) finish(ctx context.Context, job Job) error {
+	t.mu.Lock()
+	onFinish := t.onFinish
+	t.mu.Unlock()
+
+	if onFinish != nil {
+		onFinish(job)
+	}
+	if job.Kind == KindCapture && job.State == StateSucceeded {
+		return t.trackExports(ctx, job)
+	}
+	return nil
+}
+
+// trackExports starts tracking the exports a completed capture produced
+func (t *Tracker) trackExports(ctx context.Context, capture Job) error {
+	exports, err := t.capture.ExportsForCapture(ctx, capture.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	var finished []Job
+	for _, export := range exports {
+		if _, known := t.jobs[jobID(KindExport, export.ID)]; known {
+			continue
+		}
+		job, _ := t.add(&Job{
+			ID:         jobID(KindExport, export.ID),
+			Kind:       KindExport,
+			ResourceID: export.ID,
+			Name:       capture.Name,
+			Parent:     capture.ID,
+			Status:     export.Status,
+			AssetID:    export.AssetID,
+			StartedBy:  capture.StartedBy,
+		})
+		if job.Finished() {
+			finished = append(finished, job)
+		}
+	}
+	onFinish := t.onFinish
+	t.mu.Unlock()
+
+	// Exports are often complete by the time the capture is
+	if onFinish != nil {
+		for _, job := range finished {
+			onFinish(job)
+		}
+	}
+	return nil
+}
+
+// failIfGone marks a job failed if its resource no longer exists
+func (t *Tracker) failIfGone(ctx context.Context, job Job, err error) error {
+	if !errors.Is(err, client.ErrNotFound) {
+		return err
+	}
+	if finished := t.update(job.ID, job.Status, "", fmt.Sprintf("%s %s no longer exists", job.Kind, job.ResourceID)); finished != nil {
+		return t.finish(ctx, *finished)
+	}
+	return nil
+}
+
+// update records a job's latest status, returning the job if this update
+// finished it, for the caller to pass to finish. A non-empty failure marks
+// the job failed.
+func (t *Tracker) update(id, status, assetID, failure string) *Job {
+	t.mu.Lock()
+	job, ok := t.jobs[id]
+	if !ok || job.Finished() || (job.Status == status && assetID == job.AssetID && failure == "") {
+		t.mu.Unlock()
+		return nil
+	}
+
+	now := t.now()
+	job.Status = status
+	job.UpdatedAt = now
+	if assetID != "" {
+		job.AssetID = assetID
+	}
+	job.State = stateFor(status)
+	if failure != "" {
+		job.State = StateFailed
+		job.Error = failure
+	}
+
+	var finished *Job
+	if job.Finished() {
+		job.FinishedAt = &now
+		copied := *job
+		finished = &copied
+	}
+	t.commit()
+	t.mu.Unlock()
+	return finished
+}
+
+// stateFor maps a platform status onto a job state
+func stateFor(status string) string {
+	switch status {
+	case m2a.CaptureCompleted, "READY":
+		return StateSucceeded
+	case m2a.CaptureFailed, "ERROR":
+		return StateFailed
+	case m2a.CaptureCancelled:
+		return StateCancelled
+	}
+	return StateRunning
+}
+
+// commit prunes old jobs and saves the rest. The caller must hold t.mu.
+func (t *Tracker) commit() {
+	t.prune()
+	if err := t.save(); err != nil {
+		log.Printf("Failed to save jobs: %v", err)
+	}
+}
+
+// prune drops the oldest finished jobs beyond maxFinished. The caller must
+// hold t.mu.
+func (t *Tracker) prune() {
+	var finished []*Job
+	for _, job := range t.jobs {
+		if job.Finished() {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= maxFinished {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool { return finished[i].FinishedAt.Before(*finished[j].FinishedAt) })
+	for _, job := range finished[:len(finished)-maxFinished] {
+		delete(t.jobs, job.ID)
+	}
+}
+
+// load reads saved jobs, if there are any
+func (t *Tracker) load() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read jobs file: %w", err)
+	}
+
+	var saved []*Job
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to parse jobs file %s: %w", t.path, err)
+	}
+	for _, job := range saved {
+		t.jobs[job.ID] = job
+	}
+	return nil
+}
+
+// save writes every job to the jobs file, replacing it atomically. The
+// caller must hold t.mu.
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+
+	jobs := make([]*Job, 0, len(t.jobs))
+	for _, job := range t.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/auth"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+	"github.com/andy-wilson/m2a-mcp/internal/fake"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
+)
+
+// Jobs started before a restart are reloaded and polled to completion
+func TestTrackerSurvivesRestart(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	_, httpServer := fake.NewTestServer(fake.Options{APIKey: "test-key", Seed: true, Now: clock})
+	defer httpServer.Close()
+
+	c := client.NewM2AClient(&config.Config{APIKey: "test-key", BaseURL: httpServer.URL, RetryMaxAttempts: 1})
+	ctx := context.Background()
+	capture, err := m2a.NewCaptureService(c).CreateCapture(ctx, m2a.CreateCaptureRequest{
+		Name:      "Lunchtime News",
+		ChannelID: "ch-0002",
+		StartTime: "2025-10-01T12:30:00Z",
+		EndTime:   "2025-10-01T13:00:00Z",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	first, err := New(c, path, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := first.Track(ctx, KindCapture, capture.ID, capture.Name, capture.Status)
+	if job.State != StateRunning {
+		t.Fatalf("state = %s, want %s", job.State, StateRunning)
+	}
+
+	// A new tracker picks the job up from the file
+	second, err := New(c, path, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished := make(chan Job, 10)
+	second.OnFinish(func(job Job) { finished <- job })
+
+	mu.Lock()
+	now = now.Add(time.Hour)
+	mu.Unlock()
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go second.Run(runCtx)
+
+	select {
+	case job := <-finished:
+		if job.ID != "capture:"+capture.ID || job.State != StateSucceeded || job.AssetID == "" {
+			t.Errorf("finished job = %+v, want a succeeded capture with an asset", job)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("job never finished")
+	}
+
+	// Completing the capture started tracking its export
+	select {
+	case job := <-finished:
+		if job.Kind != KindExport || job.Parent != "capture:"+capture.ID {
+			t.Errorf("finished job = %+v, want the capture's export", job)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("export job never finished")
+	}
+	cancel()
+
+	third, err := New(c, path, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobs := third.List(ctx, "", StateSucceeded); len(jobs) != 2 {
+		t.Errorf("reloaded %d finished jobs, want 2", len(jobs))
+	}
+}
+
+// A client authenticated over HTTP sees only the jobs it started
+func TestTrackerVisibility(t *testing.T) {
+	tracker, err := New(client.NewM2AClient(&config.Config{APIKey: "test-key"}), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := auth.WithIdentity(context.Background(), auth.Identity{Name: "alice", Transport: "http"})
+	bob := auth.WithIdentity(context.Background(), auth.Identity{Name: "bob", Transport: "http"})
+	tracker.Track(alice, KindCapture, "cap-0001", "Alice's", m2a.CaptureInProgress)
+	tracker.Track(bob, KindClip, "clip-0001", "Bob's", m2a.CaptureInProgress)
+
+	for name, tt := range map[string]struct {
+		ctx  context.Context
+		want []string
+	}{
+		"alice":       {alice, []string{"capture:cap-0001"}},
+		"bob":         {bob, []string{"clip:clip-0001"}},
+		"stdio":       {auth.WithIdentity(context.Background(), auth.Identity{Name: "local", Transport: "stdio"}), []string{"capture:cap-0001", "clip:clip-0001"}},
+		"anonymous":   {auth.WithIdentity(context.Background(), auth.Identity{Name: auth.Anonymous, Transport: "http"}), []string{"capture:cap-0001", "clip:clip-0001"}},
+		"no identity": {context.Background(), []string{"capture:cap-0001", "clip:clip-0001"}},
+	} {
+		var ids []string
+		for _, job := range tracker.List(tt.ctx, "", "") {
+			ids = append(ids, job.ID)
+		}
+		sort.Strings(ids)
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%s lists %v, want %v", name, ids, tt.want)
+		}
+	}
+
+	if _, err := tracker.Get(alice, "capture:cap-0001"); err != nil {
+		t.Errorf("Get of alice's own job: %v", err)
+	}
+	if _, err := tracker.Get(bob, "capture:cap-0001"); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("Get of alice's job by bob = %v, want ErrUnknownJob", err)
+	}
+	if _, err := tracker.Wait(bob, "capture:cap-0001", nil); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("Wait on alice's job by bob = %v, want ErrUnknownJob", err)
+	}
+}
+
+// A capture the platform already reports complete when it is tracked is
+// reported finished at once, and its exports are tracked
+func TestTrackFinishedCapture(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	_, httpServer := fake.NewTestServer(fake.Options{APIKey: "test-key", Seed: true, Now: clock})
+	defer httpServer.Close()
+
+	c := client.NewM2AClient(&config.Config{APIKey: "test-key", BaseURL: httpServer.URL, RetryMaxAttempts: 1})
+	ctx := context.Background()
+	captures := m2a.NewCaptureService(c)
+	capture, err := captures.CreateCapture(ctx, m2a.CreateCaptureRequest{
+		Name:      "Lunchtime News",
+		ChannelID: "ch-0002",
+		StartTime: "2025-10-01T12:30:00Z",
+		EndTime:   "2025-10-01T13:00:00Z",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	now = now.Add(time.Hour)
+	mu.Unlock()
+	if capture, err = captures.GetCapture(ctx, capture.ID); err != nil || capture.Status != m2a.CaptureCompleted {
+		t.Fatalf("capture is %s, %v; want it complete", capture.Status, err)
+	}
+
+	// No polling, so only Track can report the jobs
+	tracker, err := New(c, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var finished []Job
+	tracker.OnFinish(func(job Job) { finished = append(finished, job) })
+
+	job := tracker.Track(ctx, KindCapture, capture.ID, capture.Name, capture.Status)
+	if job.State != StateSucceeded || job.FinishedAt == nil {
+		t.Errorf("tracked job = %+v, want it succeeded", job)
+	}
+	if len(finished) != 2 || finished[0].ID != job.ID || finished[1].Kind != KindExport || finished[1].Parent != job.ID {
+		t.Fatalf("finished jobs = %+v, want the capture then its export", finished)
+	}
+
+	// Tracking it again reports nothing new
+	tracker.Track(ctx, KindCapture, capture.ID, capture.Name, capture.Status)
+	if len(finished) != 2 {
+		t.Errorf("%d jobs reported finished after tracking the capture twice, want 2", len(finished))
+	}
+}
@@ -0,0 +1,145 @@
+package m2a
+
+import (
+	"context"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/timecode"
+)
+
+// Capture statuses reported by the Capture API
+const (
+	CapturePending    = "PENDING"
+	CaptureInProgress = "IN_PROGRESS"
+	CaptureCompleted  = "COMPLETED"
+	CaptureFailed     = "FAILED"
+	CaptureCancelled  = "CANCELLED"
+)
+
+// Rate returns the capture's frame rate, or false if the API didn't report
+// one this package understands
+func (c *Capture) Rate() (timecode.Rate, bool) {
+	r, err := timecode.ParseRate(c.FrameRate)
+	return r, err == nil
+}
+
+// Duration returns the length of the recording, falling back to the
+// scheduled window when the API doesn't report it, or 0 if neither is known
+func (c *Capture) Duration() time.Duration {
+	if c.DurationSeconds > 0 {
+		return time.Duration(c.DurationSeconds * float64(time.Second))
+	}
+	start, err := time.Parse(time.RFC3339, c.StartTime)
+	if err != nil {
+		return 0
+	}
+	end, err := time.Parse(time.RFC3339, c.EndTime)
+	if err != nil || !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// Resolve finds the timecode of a position in the capture, given as a
+// timecode, an ISO 8601 time or an offset from its start or end (see
+// timecode.Resolve). It needs the capture's frame rate.
+func (c *Capture) Resolve(position string) (timecode.Timecode, error) {
+	rate, ok := c.Rate()
+	if !ok {
+		return timecode.Timecode{}, invalid("capture %s doesn't report a supported frame rate (%q), so %q can't be converted to a timecode; use HH:MM:SS:FF", c.ID, c.FrameRate, position)
+	}
+	start, _ := time.Parse(time.RFC3339, c.StartTime)
+	tc, err := timecode.Resolve(position, start, c.Duration(), rate)
+	if err != nil {
+		return timecode.Timecode{}, invalid("%v", err)
+	}
+	return tc, nil
+}
+
+// CaptureService covers M2A Capture jobs, exports and clips
+type CaptureService struct {
+	client *client.M2AClient
+}
+
+// NewCaptureService creates a CaptureService
+func NewCaptureService(c *client.M2AClient) *CaptureService {
+	return &CaptureService{client: c}
+}
+
+// ListCaptures lists capture jobs
+func (s *CaptureService) ListCaptures(ctx context.Context, opts ListOptions) ([]Capture, error) {
+	return list[Capture](ctx, s.client, "/api/v1/connect/capture", opts)
+}
+
+// GetCapture gets a capture job
+func (s *CaptureService) GetCapture(ctx context.Context, id string) (*Capture, error) {
+	return get[Capture](ctx, s.client, "/api/v1/connect/capture/"+pathID(id))
+}
+
+// CreateCapture creates a live-to-VOD capture job
+func (s *CaptureService) CreateCapture(ctx context.Context, req CreateCaptureRequest) (*Capture, error) {
+	return post[Capture](ctx, s.client, "/api/v1/connect/capture", req)
+}
+
+// CancelCapture cancels an in-progress capture job
+func (s *CaptureService) CancelCapture(ctx context.Context, id string) (*Capture, error) {
+	return post[Capture](ctx, s.client, "/api/v1/connect/capture/"+pathID(id)+"/cancel", nil, client.Retryable())
+}
+
+// ListExports lists completed VOD exports from captures
+func (s *CaptureService) ListExports(ctx context.Context, opts ListOptions) ([]Export, error) {
+	return list[Export](ctx, s.client, "/api/v1/connect/capture/exports", opts)
+}
+
+// GetExport gets a capture export
+func (s *CaptureService) GetExport(ctx context.Context, id string) (*Export, error) {
+	return get[Export](ctx, s.client, "/api/v1/connect/capture/exports/"+pathID(id))
+}
+
+// CreateClip creates a frame-accurate clip from a capture
+func (s *CaptureService) CreateClip(ctx context.Context, req CreateClipRequest) (*Clip, error) {
+	return post[Clip](ctx, s.client, "/api/v1/connect/capture/clips", req)
+}
+
+// GetClip gets a clip
+func (s *CaptureService) GetClip(ctx context.Context, id string) (*Clip, error) {
+	return get[Clip](ctx, s.client, "/api/v1/connect/capture/clips/"+pathID(id))
+}
+
+// ExportsForCapture lists the exports produced by a capture
+func (s *CaptureService) ExportsForCapture(ctx context.Context, captureID string) ([]Export, error) {
+	exports, err := s.ListExports(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []Export{}
+	for _, export := range exports {
+		if export.CaptureID == captureID {
+			matching = append(matching, export)
+		}
+	}
+	return matching, nil
+}
+
+// CapturesForChannel lists the captures recording from a channel. With
+// activeOnly, only pending and in-progress captures are returned.
+func (s *CaptureService) CapturesForChannel(ctx context.Context, channelID string, activeOnly bool) ([]Capture, error) {
+	captures, err := s.ListCaptures(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []Capture{}
+	for _, capture := range captures {
+		if capture.ChannelID != channelID {
+			continue
+		}
+		if activeOnly && capture.Status != CapturePending && capture.Status != CaptureInProgress {
+			continue
+		}
+		matching = append(matching, capture)
+	}
+	return matching, nil
+}
@@ -0,0 +1,165 @@
+package m2a
+
+import (
+	"context"
+
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+)
+
+// ConnectService covers M2A Connect sources, subscribers, subscriptions and schedules
+type ConnectService struct {
+	client *client.M2AClient
+}
+
+// NewConnectService creates a ConnectService
+func NewConnectService(c *client.M2AClient) *ConnectService {
+	return &ConnectService{client: c}
+}
+
+// ListSources lists video sources
+func (s *ConnectService) ListSources(ctx context.Context, opts ListOptions) ([]Source, error) {
+	return list[Source](ctx, s.client, "/api/v2/connect/sources", opts)
+}
+
+// GetSource gets a video source
+func (s *ConnectService) GetSource(ctx context.Context, id string) (*Source, error) {
+	return get[Source](ctx, s.client, "/api/v2/connect/sources/"+pathID(id))
+}
+
+// CreateSource creates a video source
+func (s *ConnectService) CreateSource(ctx context.Context, req CreateSourceRequest) (*Source, error) {
+	return post[Source](ctx, s.client, "/api/v2/connect/sources", req)
+}
+
+// UpdateSource applies a partial update to a video source
+func (s *ConnectService) UpdateSource(ctx context.Context, id string, req UpdateSourceRequest) (*Source, error) {
+	return put[Source](ctx, s.client, "/api/v2/connect/sources/"+pathID(id), req)
+}
+
+// DeleteSource deletes a video source
+func (s *ConnectService) DeleteSource(ctx context.Context, id string) error {
+	return del(ctx, s.client, "/api/v2/connect/sources/"+pathID(id))
+}
+
+// ListSubscribers lists subscribers
+func (s *ConnectService) ListSubscribers(ctx context.Context, opts ListOptions) ([]Subscriber, error) {
+	return list[Subscriber](ctx, s.client, "/api/v2/connect/subscribers", opts)
+}
+
+// GetSubscriber gets a subscriber
+func (s *ConnectService) GetSubscriber(ctx context.Context, id string) (*Subscriber, error) {
+	return get[Subscriber](ctx, s.client, "/api/v2/connect/subscribers/"+pathID(id))
+}
+
+// CreateSubscriber creates a subscriber
+func (s *ConnectService) CreateSubscriber(ctx context.Context, req CreateSubscriberRequest) (*Subscriber, error) {
+	return post[Subscriber](ctx, s.client, "/api/v2/connect/subscribers", req)
+}
+
+// UpdateSubscriber applies a partial update to a subscriber
+func (s *ConnectService) UpdateSubscriber(ctx context.Context, id string, req UpdateSubscriberRequest) (*Subscriber, error) {
+	return put[Subscriber](ctx, s.client, "/api/v2/connect/subscribers/"+pathID(id), req)
+}
+
+// DeleteSubscriber deletes a subscriber
+func (s *ConnectService) DeleteSubscriber(ctx context.Context, id string) error {
+	return del(ctx, s.client, "/api/v2/connect/subscribers/"+pathID(id))
+}
+
+// ListSubscriptions lists subscription packages
+func (s *ConnectService) ListSubscriptions(ctx context.Context, opts ListOptions) ([]Subscription, error) {
+	return list[Subscription](ctx, s.client, "/api/v2/connect/subscriptions", opts)
+}
+
+// GetSubscription gets a subscription package
+func (s *ConnectService) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+	return get[Subscription](ctx, s.client, "/api/v2/connect/subscriptions/"+pathID(id))
+}
+
+// CreateSubscription creates a subscription package
+func (s *ConnectService) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*Subscription, error) {
+	return post[Subscription](ctx, s.client, "/api/v2/connect/subscriptions", req)
+}
+
+// UpdateSubscription applies a partial update to a subscription package
+func (s *ConnectService) UpdateSubscription(ctx context.Context, id string, req UpdateSubscriptionRequest) (*Subscription, error) {
+	return put[Subscription](ctx, s.client, "/api/v2/connect/subscriptions/"+pathID(id), req)
+}
+
+// DeleteSubscription deletes a subscription package
+func (s *ConnectService) DeleteSubscription(ctx context.Context, id string) error {
+	return del(ctx, s.client, "/api/v2/connect/subscriptions/"+pathID(id))
+}
+
+// ListSchedules lists scheduled events
+func (s *ConnectService) ListSchedules(ctx context.Context, opts ListOptions) ([]Schedule, error) {
+	return list[Schedule](ctx, s.client, "/api/v2/connect/schedules", opts)
+}
+
+// GetSchedule gets a scheduled event
+func (s *ConnectService) GetSchedule(ctx context.Context, id string) (*Schedule, error) {
+	return get[Schedule](ctx, s.client, "/api/v2/connect/schedules/"+pathID(id))
+}
+
+// CreateSchedule creates a scheduled event
+func (s *ConnectService) CreateSchedule(ctx context.Context, req CreateScheduleRequest) (*Schedule, error) {
+	return post[Schedule](ctx, s.client, "/api/v2/connect/schedules", req)
+}
+
+// UpdateSchedule applies a partial update to a scheduled event
+func (s *ConnectService) UpdateSchedule(ctx context.Context, id string, req UpdateScheduleRequest) (*Schedule, error) {
+	return put[Schedule](ctx, s.client, "/api/v2/connect/schedules/"+pathID(id), req)
+}
+
+// DeleteSchedule deletes a scheduled event
+func (s *ConnectService) DeleteSchedule(ctx context.Context, id string) error {
+	return del(ctx, s.client, "/api/v2/connect/schedules/"+pathID(id))
+}
+
+// SubscriptionsForSource lists the subscriptions that include a source
+func (s *ConnectService) SubscriptionsForSource(ctx context.Context, sourceID string) ([]Subscription, error) {
+	subscriptions, err := s.ListSubscriptions(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []Subscription{}
+	for _, subscription := range subscriptions {
+		if subscription.SourceIDs.Contains(sourceID) {
+			matching = append(matching, subscription)
+		}
+	}
+	return matching, nil
+}
+
+// SubscriptionsForSubscriber lists a subscriber's subscription packages
+func (s *ConnectService) SubscriptionsForSubscriber(ctx context.Context, subscriberID string) ([]Subscription, error) {
+	subscriptions, err := s.ListSubscriptions(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []Subscription{}
+	for _, subscription := range subscriptions {
+		if subscription.SubscriberID == subscriberID {
+			matching = append(matching, subscription)
+		}
+	}
+	return matching, nil
+}
+
+// SchedulesForSource lists the scheduled events for a source
+func (s *ConnectService) SchedulesForSource(ctx context.Context, sourceID string) ([]Schedule, error) {
+	schedules, err := s.ListSchedules(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []Schedule{}
+	for _, schedule := range schedules {
+		if schedule.SourceID == sourceID {
+			matching = append(matching, schedule)
+		}
+	}
+	return matching, nil
+}
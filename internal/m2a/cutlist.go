@@ -0,0 +1,353 @@
+package m2a
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andy-wilson/m2a-mcp/internal/timecode"
+)
+
+// Cut list formats accepted by ParseCutList
+const (
+	CutListEDL  = "edl"
+	CutListCSV  = "csv"
+	CutListJSON = "json"
+)
+
+// CutListFormats are the formats ParseCutList accepts
+var CutListFormats = []string{CutListEDL, CutListCSV, CutListJSON}
+
+// CutListEvent is one clip in a cut list. Start and End are anything
+// Capture.Resolve accepts: timecodes, ISO 8601 times or offsets.
+type CutListEvent struct {
+	// Row identifies the event in the source: the event number in an EDL,
+	// the line in a CSV file or the 1-based index in JSON
+	Row   int    `json:"row"`
+	Name  string `json:"name,omitempty"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ParseCutList parses a CMX3600 EDL, CSV or JSON cut list. An empty format
+// guesses it from the content.
+//
+// EDL events are clipped from their source in and out points, named from a
+// "* FROM CLIP NAME:" comment if there is one. Those points are used as
+// timecodes relative to the start of the capture, not time of day. CSV needs a header row with
+// start and end columns (or in/out, start_timecode/end_timecode) and
+// optionally name. JSON is an array of {"name", "start", "end"} objects, or
+// an object with such an array under "clips".
+func ParseCutList(data []byte, format string) ([]CutListEvent, error) {
+	if format == "" {
+		format = detectCutList(data)
+	}
+
+	var events []CutListEvent
+	var err error
+	switch strings.ToLower(format) {
+	case CutListEDL:
+		events, err = parseEDL(data)
+	case CutListCSV:
+		events, err = parseCutListCSV(data)
+	case CutListJSON:
+		events, err = parseCutListJSON(data)
+	default:
+		return nil, invalid("format must be one of %s, got %q", strings.Join(CutListFormats, ", "), format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, invalid("cut list has no events")
+	}
+	return events, nil
+}
+
+// edlEvent matches a CMX3600 event line: event number, reel, track,
+// transition with an optional duration, then source in/out and record
+// in/out
+var edlEvent = regexp.MustCompile(`^(\d+)\s+(\S+)\s+(\S+)\s+(\S+)(?:\s+\d+)?\s+(\d{2}:\d{2}:\d{2}[:;]\d{2})\s+(\d{2}:\d{2}:\d{2}[:;]\d{2})\s+\d{2}:\d{2}:\d{2}[:;]\d{2}\s+\d{2}:\d{2}:\d{2}[:;]\d{2}\s*$`)
+
+// detectCutList guesses the format of a cut list
+func detectCutList(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return CutListJSON
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "TITLE:") || edlEvent.MatchString(line) {
+			return CutListEDL
+		}
+	}
+	return CutListCSV
+}
+
+// parseEDL reads the events of a CMX3600 EDL. Black (BL) events are
+// skipped, and when an event number repeats, as it does for a dissolve,
+// the last line wins since it describes the incoming clip.
+func parseEDL(data []byte) ([]CutListEvent, error) {
+	var events []CutListEvent
+	index := map[int]int{}
+	last := -1
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "", strings.HasPrefix(text, "TITLE:"), strings.HasPrefix(text, "FCM:"):
+			continue
+		case strings.HasPrefix(text, "*"):
+			comment := strings.TrimSpace(strings.TrimPrefix(text, "*"))
+			if name, ok := strings.CutPrefix(comment, "FROM CLIP NAME:"); ok && last >= 0 {
+				events[last].Name = strings.TrimSpace(name)
+			}
+			continue
+		}
+
+		m := edlEvent.FindStringSubmatch(text)
+		if m == nil {
+			// Other notes (M2 speed changes, SPLIT, audio notes) don't
+			// affect where the clips are cut
+			if _, err := strconv.Atoi(strings.Fields(text)[0]); err == nil {
+				return nil, invalid("EDL line %d: can't read event %q", line, text)
+			}
+			continue
+		}
+		number, _ := strconv.Atoi(m[1])
+		if strings.EqualFold(m[2], "BL") {
+			last = -1
+			continue
+		}
+
+		event := CutListEvent{Row: number, Start: m[5], End: m[6]}
+		if i, ok := index[number]; ok {
+			events[i] = event
+			last = i
+			continue
+		}
+		index[number] = len(events)
+		last = len(events)
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, invalid("EDL: %v", err)
+	}
+	return events, nil
+}
+
+// Accepted CSV header names for each column
+var (
+	csvNameColumns  = []string{"name", "clip", "clip_name", "title"}
+	csvStartColumns = []string{"start", "start_timecode", "start_time", "in", "source_in"}
+	csvEndColumns   = []string{"end", "end_timecode", "end_time", "out", "source_out"}
+)
+
+// parseCutListCSV reads a CSV cut list with a header row
+func parseCutListCSV(data []byte) ([]CutListEvent, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, invalid("CSV header: %v", err)
+	}
+	column := func(names []string) int {
+		for i, h := range header {
+			for _, name := range names {
+				if strings.EqualFold(strings.TrimSpace(h), name) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	name, start, end := column(csvNameColumns), column(csvStartColumns), column(csvEndColumns)
+	if start < 0 || end < 0 {
+		return nil, invalid("CSV header must have start and end columns (or in and out), got %q", strings.Join(header, ","))
+	}
+
+	var events []CutListEvent
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, invalid("CSV: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		events = append(events, CutListEvent{Row: line, Name: field(name), Start: field(start), End: field(end)})
+	}
+	return events, nil
+}
+
+// parseCutListJSON reads a JSON cut list
+func parseCutListJSON(data []byte) ([]CutListEvent, error) {
+	type entry struct {
+		Name          string `json:"name"`
+		Start         string `json:"start"`
+		End           string `json:"end"`
+		StartTimecode string `json:"start_timecode"`
+		EndTimecode   string `json:"end_timecode"`
+	}
+	var entries []entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		var wrapped struct {
+			Clips []entry `json:"clips"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, invalid("JSON cut list must be an array of clips or {\"clips\": [...]}: %v", err)
+		}
+		entries = wrapped.Clips
+	}
+
+	events := make([]CutListEvent, 0, len(entries))
+	for i, e := range entries {
+		event := CutListEvent{Row: i + 1, Name: e.Name, Start: e.Start, End: e.End}
+		if event.Start == "" {
+			event.Start = e.StartTimecode
+		}
+		if event.End == "" {
+			event.End = e.EndTimecode
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Outcomes of each event in CreateClips
+const (
+	ClipValid   = "valid"
+	ClipInvalid = "invalid"
+	ClipCreated = "created"
+	ClipFailed  = "failed"
+)
+
+// ClipBatchOptions controls CreateClips
+type ClipBatchOptions struct {
+	// Concurrency is how many clips are submitted at once (default 4)
+	Concurrency int
+	// DryRun validates every event without creating anything
+	DryRun bool
+}
+
+// ClipResult is the outcome of one cut list event
+type ClipResult struct {
+	Row           int    `json:"row"`
+	Name          string `json:"name"`
+	StartTimecode string `json:"start_timecode,omitempty"`
+	EndTimecode   string `json:"end_timecode,omitempty"`
+	// Status is ClipValid (dry run), ClipInvalid, ClipCreated or ClipFailed
+	Status string `json:"status"`
+	ClipID string `json:"clip_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Clip is the created clip and Err why the event wasn't created
+	Clip *Clip `json:"-"`
+	Err  error `json:"-"`
+}
+
+// CreateClips validates every event against the capture, then creates a
+// clip for each valid one, a few at a time. Invalid events are reported
+// and skipped rather than stopping the batch. Events without a name are
+// named after the capture and their row.
+func (s *CaptureService) CreateClips(ctx context.Context, capture *Capture, events []CutListEvent, opts ClipBatchOptions) []ClipResult {
+	results := make([]ClipResult, len(events))
+	requests := make([]CreateClipRequest, len(events))
+	for i, event := range events {
+		requests[i], results[i] = clipFromEvent(capture, event)
+	}
+	if opts.DryRun {
+		return results
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].Status != ClipValid {
+			continue
+		}
+		wg.Add(1)
+		go func(result *ClipResult, req CreateClipRequest) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				result.Status, result.Err, result.Error = ClipFailed, ctx.Err(), ctx.Err().Error()
+				return
+			}
+
+			clip, err := s.CreateClip(ctx, req)
+			if err != nil {
+				result.Status, result.Err, result.Error = ClipFailed, err, err.Error()
+				return
+			}
+			result.Status, result.Clip, result.ClipID = ClipCreated, clip, clip.ID
+		}(&results[i], requests[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// clipFromEvent resolves and validates one event against the capture
+func clipFromEvent(capture *Capture, event CutListEvent) (CreateClipRequest, ClipResult) {
+	req := CreateClipRequest{CaptureID: capture.ID, Name: event.Name}
+	if req.Name == "" {
+		req.Name = fmt.Sprintf("%s #%d", cmp.Or(capture.Name, capture.ID), event.Row)
+	}
+	result := ClipResult{Row: event.Row, Name: req.Name}
+
+	fail := func(err error) (CreateClipRequest, ClipResult) {
+		result.Status, result.Err, result.Error = ClipInvalid, err, strings.TrimPrefix(err.Error(), ErrInvalidRequest.Error()+": ")
+		return req, result
+	}
+	for _, field := range []struct {
+		name     string
+		position string
+		tc       *string
+	}{{"start", event.Start, &req.StartTimecode}, {"end", event.End, &req.EndTimecode}} {
+		if strings.TrimSpace(field.position) == "" {
+			return fail(invalid("%s is required", field.name))
+		}
+		if timecode.IsTimecode(field.position) {
+			*field.tc = field.position
+			continue
+		}
+		tc, err := capture.Resolve(field.position)
+		if err != nil {
+			return fail(err)
+		}
+		*field.tc = tc.String()
+	}
+
+	result.StartTimecode, result.EndTimecode = req.StartTimecode, req.EndTimecode
+	if err := req.ValidateFor(capture); err != nil {
+		return fail(err)
+	}
+	result.Status = ClipValid
+	return req, result
+}
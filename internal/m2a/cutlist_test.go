@@ -0,0 +1,200 @@
+package m2a
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEDL(t *testing.T) {
+	tests := []struct {
+		name string
+		edl  string
+		want []CutListEvent
+	}{
+		{
+			name: "cuts with clip names",
+			edl: `TITLE: Match Highlights
+FCM: NON-DROP FRAME
+
+001  AX       V     C        00:01:00:00 00:01:30:00 01:00:00:00 01:00:30:00
+* FROM CLIP NAME: Opening goal
+002  AX       V     C        00:05:10:12 00:05:20:00 01:00:30:00 01:00:39:13
+`,
+			want: []CutListEvent{
+				{Row: 1, Name: "Opening goal", Start: "00:01:00:00", End: "00:01:30:00"},
+				{Row: 2, Start: "00:05:10:12", End: "00:05:20:00"},
+			},
+		},
+		{
+			name: "black is skipped and its comment ignored",
+			edl: `001  BL       V     C        00:00:00:00 00:00:02:00 01:00:00:00 01:00:02:00
+* FROM CLIP NAME: Slate
+002  AX       V     C        00:02:00:00 00:02:10:00 01:00:02:00 01:00:12:00
+`,
+			want: []CutListEvent{{Row: 2, Start: "00:02:00:00", End: "00:02:10:00"}},
+		},
+		{
+			name: "dissolve keeps the incoming clip",
+			edl: `003  AX       V     C        00:10:00:00 00:10:00:00 01:00:00:00 01:00:00:00
+003  AX       V     D    025 00:12:00:00 00:12:15:00 01:00:00:00 01:00:15:00
+* FROM CLIP NAME: Penalty
+* EFFECT NAME: CROSS DISSOLVE
+`,
+			want: []CutListEvent{{Row: 3, Name: "Penalty", Start: "00:12:00:00", End: "00:12:15:00"}},
+		},
+		{
+			name: "drop-frame timecodes and other notes",
+			edl: `FCM: DROP FRAME
+001  AX       V     C        00:01:00;02 00:01:10;00 00:00:00;00 00:00:09;28
+M2   AX       050.0                      00:01:00;02
+`,
+			want: []CutListEvent{{Row: 1, Start: "00:01:00;02", End: "00:01:10;00"}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseCutList([]byte(tt.edl), "")
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+
+	for edl, want := range map[string]string{
+		"001  AX  V  C  00:01:00:00 00:01:30:00 01:00:00:00 01:00:30:00\n002  AX  V  C  00:01:00:00\n": "EDL line 2: can't read event",
+		"TITLE: Empty\n": "no events",
+	} {
+		_, err := ParseCutList([]byte(edl), CutListEDL)
+		if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseCutList(%q) = %v, want an error mentioning %q", edl, err, want)
+		}
+	}
+}
+
+func TestParseCutListCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want []CutListEvent
+	}{
+		{
+			name: "start and end",
+			csv:  "name,start,end\nGoal,00:01:00:00,00:01:30:00\nSave,+90s,+2m\n",
+			want: []CutListEvent{
+				{Row: 2, Name: "Goal", Start: "00:01:00:00", End: "00:01:30:00"},
+				{Row: 3, Name: "Save", Start: "+90s", End: "+2m"},
+			},
+		},
+		{
+			name: "in and out in any case and order, without names",
+			csv:  "Out, In\n00:00:20:00, 00:00:10:00\n",
+			want: []CutListEvent{{Row: 2, Start: "00:00:10:00", End: "00:00:20:00"}},
+		},
+		{
+			name: "timecode columns and a title",
+			csv:  "title,start_timecode,end_timecode,notes\nKick-off,00:00:00:00,00:00:05:00,first half\n",
+			want: []CutListEvent{{Row: 2, Name: "Kick-off", Start: "00:00:00:00", End: "00:00:05:00"}},
+		},
+		{
+			// Short rows are kept, with their line, for CreateClips to report
+			name: "short row",
+			csv:  "start,end,name\n00:00:10:00\n\n00:00:20:00,00:00:30:00\n",
+			want: []CutListEvent{
+				{Row: 2, Start: "00:00:10:00"},
+				{Row: 4, Start: "00:00:20:00", End: "00:00:30:00"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseCutList([]byte(tt.csv), CutListCSV)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+
+	for csv, want := range map[string]string{
+		"name,from,to\nGoal,1,2\n":                      "must have start and end columns",
+		"start,end\n00:00:10:00,00:00:20:00\n\"bad,x\n": "line 3",
+		"start,end\n": "no events",
+	} {
+		_, err := ParseCutList([]byte(csv), CutListCSV)
+		if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseCutList(%q) = %v, want an error mentioning %q", csv, err, want)
+		}
+	}
+}
+
+func TestParseCutListJSON(t *testing.T) {
+	want := []CutListEvent{
+		{Row: 1, Name: "Goal", Start: "00:01:00:00", End: "00:01:30:00"},
+		{Row: 2, Start: "2025-10-01T15:42:10Z", End: "-00:01:00"},
+	}
+	for _, in := range []string{
+		`[{"name":"Goal","start":"00:01:00:00","end":"00:01:30:00"},{"start":"2025-10-01T15:42:10Z","end":"-00:01:00"}]`,
+		`{"clips":[{"name":"Goal","start_timecode":"00:01:00:00","end_timecode":"00:01:30:00"},{"start":"2025-10-01T15:42:10Z","end":"-00:01:00"}]}`,
+	} {
+		got, err := ParseCutList([]byte(in), "")
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", in, got, want)
+		}
+	}
+
+	for in, want := range map[string]string{
+		`[{"name":"Goal","start":1}]`: "must be an array of clips",
+		`{"clips":[]}`:                "no events",
+		`[]`:                          "no events",
+	} {
+		_, err := ParseCutList([]byte(in), CutListJSON)
+		if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseCutList(%s) = %v, want an error mentioning %q", in, err, want)
+		}
+	}
+}
+
+func TestParseCutListFormat(t *testing.T) {
+	if _, err := ParseCutList([]byte("start,end\n1,2\n"), "xml"); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("unknown format: %v", err)
+	}
+	// Formats are case-insensitive
+	if _, err := ParseCutList([]byte("start,end\n1,2\n"), "CSV"); err != nil {
+		t.Errorf("format CSV: %v", err)
+	}
+}
+
+func TestClipFromEvent(t *testing.T) {
+	capture := &Capture{ID: "cap-1", Name: "Match", FrameRate: "25", DurationSeconds: 600}
+	event := CutListEvent{Row: 3, Start: "00:01:00:00", End: "00:02:00:00"}
+
+	req, result := clipFromEvent(capture, event)
+	if result.Status != ClipValid || req.Name != "Match #3" || req.StartTimecode != "00:01:00:00" {
+		t.Errorf("clipFromEvent = %+v, %+v; want a valid clip named Match #3", req, result)
+	}
+
+	// An unnamed event of an unnamed capture is named after the capture ID
+	capture.Name = ""
+	if req, _ := clipFromEvent(capture, event); req.Name != "cap-1 #3" {
+		t.Errorf("name = %q, want cap-1 #3", req.Name)
+	}
+
+	event.Name = "Goal"
+	if req, _ := clipFromEvent(capture, event); req.Name != "Goal" {
+		t.Errorf("name = %q, want the event's own name", req.Name)
+	}
+
+	event.End = ""
+	if _, result := clipFromEvent(capture, event); result.Status != ClipInvalid || result.Error != "end is required" {
+		t.Errorf("result = %+v, want end reported missing", result)
+	}
+}
@@ -0,0 +1,196 @@
+package m2a
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Kinds of FieldChange
+const (
+	FieldAdded   = "added"
+	FieldRemoved = "removed"
+	FieldChanged = "changed"
+)
+
+// FieldChange is one difference between two encoder configurations. Path
+// names the field, e.g. "video.ladder[1].bitrate_kbps".
+type FieldChange struct {
+	Path   string      `json:"path"`
+	Change string      `json:"change"`
+	A      interface{} `json:"a,omitempty"`
+	B      interface{} `json:"b,omitempty"`
+}
+
+// managedFields are set by the API rather than being part of a
+// configuration, so they are neither copied nor compared
+var managedFields = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// Settings returns the configuration's fields other than its identity, name
+// and description, decoded from Extra
+func (c *EncoderConfig) Settings() (map[string]interface{}, error) {
+	settings := map[string]interface{}{}
+	for k, raw := range c.Extra {
+		if managedFields[k] {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("decode encoder config field %s: %w", k, err)
+		}
+		settings[k] = v
+	}
+	return settings, nil
+}
+
+// Clone returns a request that creates a copy of the configuration called
+// name. overrides are applied as a JSON merge patch (RFC 7386): objects are
+// merged field by field, other values replace what was there, and null
+// removes a field. They may change the description, to a string or null,
+// but not the name, which is given separately, or fields the API manages.
+func (c *EncoderConfig) Clone(name string, overrides map[string]interface{}) (CreateEncoderConfigRequest, error) {
+	if err := checkSettings("overrides", overrides, "description"); err != nil {
+		return CreateEncoderConfigRequest{}, err
+	}
+	switch overrides["description"].(type) {
+	case string, nil:
+	default:
+		return CreateEncoderConfigRequest{}, invalid("overrides: description must be a string")
+	}
+	settings, err := c.Settings()
+	if err != nil {
+		return CreateEncoderConfigRequest{}, err
+	}
+	settings["description"] = c.Description
+	settings = mergePatch(settings, overrides)
+
+	req := CreateEncoderConfigRequest{Name: name, Settings: settings}
+	req.Description, _ = settings["description"].(string)
+	delete(settings, "description")
+	return req, nil
+}
+
+// DiffEncoderConfigs lists the fields that differ between a and b, sorted
+// by path. Nested objects and arrays are compared element by element.
+func DiffEncoderConfigs(a, b *EncoderConfig) ([]FieldChange, error) {
+	fields := func(c *EncoderConfig) (map[string]interface{}, error) {
+		settings, err := c.Settings()
+		if err != nil {
+			return nil, err
+		}
+		settings["name"] = c.Name
+		if c.Description != "" {
+			settings["description"] = c.Description
+		}
+		return settings, nil
+	}
+	fieldsA, err := fields(a)
+	if err != nil {
+		return nil, err
+	}
+	fieldsB, err := fields(b)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []FieldChange{}
+	diffValues("", fieldsA, fieldsB, &changes)
+	return changes, nil
+}
+
+// diffValues appends the differences between a and b at path
+func diffValues(path string, a, b interface{}, changes *[]FieldChange) {
+	mapA, aIsMap := a.(map[string]interface{})
+	mapB, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		keys := make([]string, 0, len(mapA)+len(mapB))
+		for k := range mapA {
+			keys = append(keys, k)
+		}
+		for k := range mapB {
+			if _, ok := mapA[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			field := k
+			if path != "" {
+				field = path + "." + k
+			}
+			valueA, inA := mapA[k]
+			valueB, inB := mapB[k]
+			switch {
+			case !inA:
+				*changes = append(*changes, FieldChange{Path: field, Change: FieldAdded, B: valueB})
+			case !inB:
+				*changes = append(*changes, FieldChange{Path: field, Change: FieldRemoved, A: valueA})
+			default:
+				diffValues(field, valueA, valueB, changes)
+			}
+		}
+		return
+	}
+
+	listA, aIsList := a.([]interface{})
+	listB, bIsList := b.([]interface{})
+	if aIsList && bIsList {
+		for i := 0; i < max(len(listA), len(listB)); i++ {
+			element := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(listA):
+				*changes = append(*changes, FieldChange{Path: element, Change: FieldAdded, B: listB[i]})
+			case i >= len(listB):
+				*changes = append(*changes, FieldChange{Path: element, Change: FieldRemoved, A: listA[i]})
+			default:
+				diffValues(element, listA[i], listB[i], changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, FieldChange{Path: path, Change: FieldChanged, A: a, B: b})
+	}
+}
+
+// mergePatch applies patch to target as a JSON merge patch (RFC 7386)
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		if patchObject, ok := v.(map[string]interface{}); ok {
+			existing, _ := target[k].(map[string]interface{})
+			target[k] = mergePatch(existing, patchObject)
+			continue
+		}
+		target[k] = v
+	}
+	return target
+}
+
+// checkSettings rejects settings, passed as the named argument, that would
+// overwrite fields the request carries separately or the API manages, other
+// than any allowed
+func checkSettings(argument string, settings map[string]interface{}, allowed ...string) error {
+	var reserved []string
+	for k := range settings {
+		if (managedFields[k] || k == "name" || k == "description") && !slices.Contains(allowed, k) {
+			reserved = append(reserved, k)
+		}
+	}
+	if len(reserved) > 0 {
+		sort.Strings(reserved)
+		return invalid("%s can't set %s; use the separate parameters where there are any", argument, strings.Join(reserved, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package m2a
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// encoderConfig decodes a configuration as the API would return it
+func encoderConfig(t *testing.T, data string) *EncoderConfig {
+	t.Helper()
+	var c EncoderConfig
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	return &c
+}
+
+// object parses a JSON object into generic values, as tool arguments arrive
+func object(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{"replace a value", `{"a":1,"b":2}`, `{"a":3}`, `{"a":3,"b":2}`},
+		{"add a field", `{"a":1}`, `{"b":{"c":2}}`, `{"a":1,"b":{"c":2}}`},
+		{"null removes", `{"a":1,"b":2}`, `{"b":null}`, `{"a":1}`},
+		{"null for a missing field", `{"a":1}`, `{"b":null}`, `{"a":1}`},
+		{"nested merge", `{"video":{"codec":"H.264","fps":50}}`, `{"video":{"fps":25,"gop":2}}`, `{"video":{"codec":"H.264","fps":25,"gop":2}}`},
+		{"nested null", `{"video":{"codec":"H.264","fps":50}}`, `{"video":{"fps":null}}`, `{"video":{"codec":"H.264"}}`},
+		{"null inside a new object is dropped", `{}`, `{"audio":{"codec":"AAC","lang":null}}`, `{"audio":{"codec":"AAC"}}`},
+		{"arrays are replaced whole", `{"ladder":[1,2,3]}`, `{"ladder":[4]}`, `{"ladder":[4]}`},
+		{"object replaces a scalar", `{"a":1}`, `{"a":{"b":2}}`, `{"a":{"b":2}}`},
+		{"scalar replaces an object", `{"a":{"b":2}}`, `{"a":"flat"}`, `{"a":"flat"}`},
+	}
+	for _, tt := range tests {
+		got := mergePatch(object(t, tt.target), object(t, tt.patch))
+		if want := object(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestDiffEncoderConfigs(t *testing.T) {
+	a := encoderConfig(t, `{"id":"enc-1","name":"HD","created_at":"2025-01-01T00:00:00Z",
+		"video":{"codec":"H.264","ladder":[{"height":1080,"bitrate_kbps":6000},{"height":720,"bitrate_kbps":3500},{"height":540,"bitrate_kbps":2000}]},
+		"audio":{"codec":"AAC","bitrate_kbps":128},"dvr":true}`)
+	b := encoderConfig(t, `{"id":"enc-2","name":"HD copy","description":"Copy","created_at":"2025-06-01T00:00:00Z",
+		"video":{"codec":"H.264","ladder":[{"height":1080,"bitrate_kbps":8000},{"height":720,"bitrate_kbps":3500}]},
+		"audio":{"codec":"AAC"},"captions":"608"}`)
+
+	changes, err := DiffEncoderConfigs(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldChange{
+		{Path: "audio.bitrate_kbps", Change: FieldRemoved, A: 128.0},
+		{Path: "captions", Change: FieldAdded, B: "608"},
+		{Path: "description", Change: FieldAdded, B: "Copy"},
+		{Path: "dvr", Change: FieldRemoved, A: true},
+		{Path: "name", Change: FieldChanged, A: "HD", B: "HD copy"},
+		{Path: "video.ladder[0].bitrate_kbps", Change: FieldChanged, A: 6000.0, B: 8000.0},
+		{Path: "video.ladder[2]", Change: FieldRemoved, A: map[string]interface{}{"height": 540.0, "bitrate_kbps": 2000.0}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got  %+v\nwant %+v", changes, want)
+	}
+
+	// The other way round, the removed element is added
+	changes, _ = DiffEncoderConfigs(b, a)
+	var added []string
+	for _, c := range changes {
+		if c.Change == FieldAdded {
+			added = append(added, c.Path)
+		}
+	}
+	if strings.Join(added, " ") != "audio.bitrate_kbps dvr video.ladder[2]" {
+		t.Errorf("added = %v", added)
+	}
+
+	// Identity and timestamps never count as differences
+	changes, _ = DiffEncoderConfigs(a, encoderConfig(t, `{"id":"enc-9","name":"HD","updated_at":"2025-09-01T00:00:00Z",
+		"video":{"codec":"H.264","ladder":[{"height":1080,"bitrate_kbps":6000},{"height":720,"bitrate_kbps":3500},{"height":540,"bitrate_kbps":2000}]},
+		"audio":{"codec":"AAC","bitrate_kbps":128},"dvr":true}`))
+	if len(changes) != 0 {
+		t.Errorf("identical settings differ: %+v", changes)
+	}
+}
+
+func TestClone(t *testing.T) {
+	source := encoderConfig(t, `{"id":"enc-1","name":"HD","description":"Sport","created_at":"2025-01-01T00:00:00Z",
+		"video":{"codec":"H.264","fps":50},"audio":{"codec":"AAC","bitrate_kbps":128},"dvr":true}`)
+
+	req, err := source.Clone("HD (high audio)", object(t, `{"audio":{"bitrate_kbps":256},"dvr":null,"video":{"fps":null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := object(t, `{"video":{"codec":"H.264"},"audio":{"codec":"AAC","bitrate_kbps":256}}`)
+	if req.Name != "HD (high audio)" || req.Description != "Sport" || !reflect.DeepEqual(req.Settings, want) {
+		t.Errorf("Clone = %+v", req)
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("cloned request is invalid: %v", err)
+	}
+
+	// The description can be replaced or removed
+	req, _ = source.Clone("HD 2", object(t, `{"description":"Entertainment"}`))
+	if req.Description != "Entertainment" {
+		t.Errorf("description = %q, want Entertainment", req.Description)
+	}
+	req, _ = source.Clone("HD 3", object(t, `{"description":null}`))
+	if req.Description != "" {
+		t.Errorf("description = %q, want none", req.Description)
+	}
+
+	// The name and fields the API manages can't be overridden, and the
+	// description can only be replaced with a string
+	for overrides, want := range map[string]string{
+		`{"name":"Other"}`:                   "overrides can't set name",
+		`{"id":"enc-9"}`:                     "overrides can't set id",
+		`{"created_at":null,"updated_at":1}`: "overrides can't set created_at, updated_at",
+		`{"description":42}`:                 "overrides: description must be a string",
+		`{"description":{"text":"Sport"}}`:   "overrides: description must be a string",
+	} {
+		_, err := source.Clone("HD 4", object(t, overrides))
+		if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), want) {
+			t.Errorf("Clone with %s: err = %v, want %q", overrides, err, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package m2a
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Extra holds fields returned by the API that the typed models don't know
+// about. They are kept so that nothing is lost when a model is re-encoded,
+// and so schema drift in the platform is visible to callers.
+type Extra map[string]json.RawMessage
+
+// unmarshalWithExtra decodes data into known, which must be a pointer to a
+// struct without custom JSON methods, and collects any fields it doesn't
+// declare into extra
+func unmarshalWithExtra(data []byte, known interface{}, extra *Extra) error {
+	if err := json.Unmarshal(data, known); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, name := range jsonFieldNames(reflect.TypeOf(known).Elem()) {
+		delete(fields, name)
+	}
+
+	*extra = nil
+	if len(fields) > 0 {
+		*extra = fields
+	}
+	return nil
+}
+
+// marshalWithExtra encodes known, a struct without custom JSON methods, and
+// merges in extra fields it doesn't declare. Round trips are lossless except
+// for known omitempty fields the API sent as "" or 0: those are dropped on
+// re-encode, and Extra can't restore them because it only holds fields the
+// struct doesn't declare.
+func marshalWithExtra(known interface{}, extra Extra) ([]byte, error) {
+	data, err := json.Marshal(known)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range extra {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// jsonFieldNames lists the JSON names of a struct type's encoded fields
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// IDList is a list of resource IDs. The API returns these either as a JSON
+// array or as a comma-separated string; both decode to the same list.
+type IDList []string
+
+// UnmarshalJSON accepts an array of strings or a comma-separated string
+func (l *IDList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*l = splitList(s)
+		return nil
+	}
+
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return err
+	}
+	*l = ids
+	return nil
+}
+
+// Contains reports whether the list includes id
+func (l IDList) Contains(id string) bool {
+	for _, v := range l {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-separated string, dropping blanks
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package m2a
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Unknown fields survive a decode and re-encode
+func TestExtraRoundTrip(t *testing.T) {
+	in := `{"id":"src-1","name":"Cam","type":"srt","latency_ms":120,"tags":["a","b"],"ingest":{"region":"eu-west-1"}}`
+
+	var source Source
+	if err := json.Unmarshal([]byte(in), &source); err != nil {
+		t.Fatal(err)
+	}
+	if source.ID != "src-1" || source.Type != "srt" {
+		t.Errorf("known fields not decoded: %+v", source)
+	}
+	if len(source.Extra) != 3 || string(source.Extra["latency_ms"]) != "120" {
+		t.Errorf("Extra = %v, want latency_ms, tags and ingest", source.Extra)
+	}
+	if _, ok := source.Extra["name"]; ok {
+		t.Error("a known field was kept in Extra")
+	}
+
+	out, err := json.Marshal(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got map[string]interface{}
+	json.Unmarshal([]byte(in), &want)
+	json.Unmarshal(out, &got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %s, want %s", out, in)
+	}
+
+	// A known field set on the model wins over a stale copy in Extra
+	source.Name = "Renamed"
+	source.Extra["name"] = json.RawMessage(`"Stale"`)
+	out, _ = json.Marshal(source)
+	json.Unmarshal(out, &got)
+	if got["name"] != "Renamed" {
+		t.Errorf("name = %v, want Renamed", got["name"])
+	}
+
+	// Known omitempty fields sent empty are the one loss, as documented on
+	// marshalWithExtra
+	var empty Source
+	json.Unmarshal([]byte(`{"id":"src-1","description":""}`), &empty)
+	if out, _ = json.Marshal(empty); string(out) != `{"id":"src-1"}` {
+		t.Errorf("empty description re-encoded as %s", out)
+	}
+
+	// Without unknown fields Extra stays nil, even when decoding into a
+	// model that had some
+	if err := json.Unmarshal([]byte(`{"id":"src-2"}`), &source); err != nil {
+		t.Fatal(err)
+	}
+	if source.Extra != nil {
+		t.Errorf("Extra = %v, want nil", source.Extra)
+	}
+}
+
+func TestIDList(t *testing.T) {
+	for in, want := range map[string][]string{
+		`["src-1","src-2"]`:      {"src-1", "src-2"},
+		`"src-1, src-2,,src-3 "`: {"src-1", "src-2", "src-3"},
+		`"src-1"`:                {"src-1"},
+		`[]`:                     {},
+	} {
+		var subscription Subscription
+		if err := json.Unmarshal([]byte(`{"source_ids":`+in+`}`), &subscription); err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if len(subscription.SourceIDs) != len(want) || (len(want) > 0 && !reflect.DeepEqual([]string(subscription.SourceIDs), want)) {
+			t.Errorf("%s decoded to %q, want %q", in, subscription.SourceIDs, want)
+		}
+	}
+
+	var ids IDList
+	if err := json.Unmarshal([]byte(`""`), &ids); err != nil || len(ids) != 0 {
+		t.Errorf(`"" decoded to %q, %v`, ids, err)
+	}
+	for _, in := range []string{`42`, `[1,2]`, `{"id":"x"}`} {
+		if err := json.Unmarshal([]byte(in), &ids); err == nil {
+			t.Errorf("%s decoded to %q, want an error", in, ids)
+		}
+	}
+
+	if ids := (IDList{"src-1", "src-2"}); !ids.Contains("src-2") || ids.Contains("src-3") {
+		t.Errorf("Contains is wrong for %q", ids)
+	}
+}
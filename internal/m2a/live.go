@@ -0,0 +1,204 @@
+package m2a
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+)
+
+// Channel states reported by the Live API
+const (
+	ChannelIdle       = "IDLE"
+	ChannelCreating   = "CREATING"
+	ChannelStarting   = "STARTING"
+	ChannelRunning    = "RUNNING"
+	ChannelStopping   = "STOPPING"
+	ChannelDeleting   = "DELETING"
+	ChannelRecovering = "RECOVERING"
+	ChannelUpdating   = "UPDATING"
+)
+
+// ErrChannelTransition is returned by WaitForChannel when a channel settles
+// in a state other than the one being waited for
+var ErrChannelTransition = errors.New("channel transition failed")
+
+// transitionalStates are the states a channel passes through on its way
+// between IDLE and RUNNING
+var transitionalStates = map[string]bool{
+	ChannelCreating:   true,
+	ChannelStarting:   true,
+	ChannelStopping:   true,
+	ChannelRecovering: true,
+	ChannelUpdating:   true,
+}
+
+// ChannelWait controls how WaitForChannel polls
+type ChannelWait struct {
+	// Target is the state to wait for, ChannelRunning or ChannelIdle
+	Target string
+	// Interval is the time between polls
+	Interval time.Duration
+	// OnPoll, if set, is called with the channel after every poll
+	OnPoll func(channel *Channel, elapsed time.Duration)
+}
+
+// LiveService covers M2A Live channels, encoder configurations and workflows
+type LiveService struct {
+	client *client.M2AClient
+}
+
+// NewLiveService creates a LiveService
+func NewLiveService(c *client.M2AClient) *LiveService {
+	return &LiveService{client: c}
+}
+
+// ListChannels lists MediaLive channels
+func (s *LiveService) ListChannels(ctx context.Context, opts ListOptions) ([]Channel, error) {
+	return list[Channel](ctx, s.client, "/api/v3/live/channels", opts)
+}
+
+// GetChannel gets a MediaLive channel
+func (s *LiveService) GetChannel(ctx context.Context, id string) (*Channel, error) {
+	return get[Channel](ctx, s.client, "/api/v3/live/channels/"+pathID(id))
+}
+
+// CreateChannel creates a MediaLive channel
+func (s *LiveService) CreateChannel(ctx context.Context, req CreateChannelRequest) (*Channel, error) {
+	return post[Channel](ctx, s.client, "/api/v3/live/channels", req)
+}
+
+// StartChannel asks for a channel to be started. The returned channel is
+// typically still STARTING.
+func (s *LiveService) StartChannel(ctx context.Context, id string) (*Channel, error) {
+	return post[Channel](ctx, s.client, "/api/v3/live/channels/"+pathID(id)+"/start", nil, client.Retryable())
+}
+
+// StopChannel asks for a channel to be stopped. The returned channel is
+// typically still STOPPING.
+func (s *LiveService) StopChannel(ctx context.Context, id string) (*Channel, error) {
+	return post[Channel](ctx, s.client, "/api/v3/live/channels/"+pathID(id)+"/stop", nil, client.Retryable())
+}
+
+// WaitForChannel polls a channel until it reaches wait.Target, returning the
+// channel in its final state. Until the channel has been seen in a
+// transitional state it may still be in the state it is leaving, since the
+// API can take a moment to act on a start or stop. Any other state is an
+// ErrChannelTransition. When ctx ends first, the last channel seen is
+// returned with an error wrapping ctx.Err().
+func (s *LiveService) WaitForChannel(ctx context.Context, id string, wait ChannelWait) (*Channel, error) {
+	leaving := ChannelIdle
+	if wait.Target == ChannelIdle {
+		leaving = ChannelRunning
+	}
+
+	started := time.Now()
+	moving := false
+	var channel *Channel
+	for {
+		polled, err := s.GetChannel(ctx, id)
+		if err != nil {
+			if channel != nil && ctx.Err() != nil {
+				return channel, fmt.Errorf("channel %s is still %s after %s: %w", id, channel.State, time.Since(started).Round(time.Second), ctx.Err())
+			}
+			return channel, err
+		}
+		channel = polled
+		if wait.OnPoll != nil {
+			wait.OnPoll(channel, time.Since(started))
+		}
+
+		switch {
+		case channel.State == wait.Target:
+			return channel, nil
+		case transitionalStates[channel.State]:
+			moving = true
+		case channel.State == leaving && !moving:
+		default:
+			return channel, fmt.Errorf("%w: channel %s is %s, expected %s", ErrChannelTransition, id, channel.State, wait.Target)
+		}
+
+		timer := time.NewTimer(wait.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return channel, fmt.Errorf("channel %s is still %s after %s: %w", id, channel.State, time.Since(started).Round(time.Second), ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// DeleteChannel deletes a MediaLive channel
+func (s *LiveService) DeleteChannel(ctx context.Context, id string) error {
+	return del(ctx, s.client, "/api/v3/live/channels/"+pathID(id))
+}
+
+// ListEncoderConfigs lists encoder configuration fragments
+func (s *LiveService) ListEncoderConfigs(ctx context.Context, opts ListOptions) ([]EncoderConfig, error) {
+	return list[EncoderConfig](ctx, s.client, "/api/v1/live/encoder-configs", opts)
+}
+
+// GetEncoderConfig gets an encoder configuration fragment
+func (s *LiveService) GetEncoderConfig(ctx context.Context, id string) (*EncoderConfig, error) {
+	return get[EncoderConfig](ctx, s.client, "/api/v1/live/encoder-configs/"+pathID(id))
+}
+
+// CreateEncoderConfig creates an encoder configuration fragment
+func (s *LiveService) CreateEncoderConfig(ctx context.Context, req CreateEncoderConfigRequest) (*EncoderConfig, error) {
+	return post[EncoderConfig](ctx, s.client, "/api/v1/live/encoder-configs", req)
+}
+
+// UpdateEncoderConfig applies a partial update to an encoder configuration
+// fragment
+func (s *LiveService) UpdateEncoderConfig(ctx context.Context, id string, req UpdateEncoderConfigRequest) (*EncoderConfig, error) {
+	return put[EncoderConfig](ctx, s.client, "/api/v1/live/encoder-configs/"+pathID(id), req)
+}
+
+// DeleteEncoderConfig deletes an encoder configuration fragment
+func (s *LiveService) DeleteEncoderConfig(ctx context.Context, id string) error {
+	return del(ctx, s.client, "/api/v1/live/encoder-configs/"+pathID(id))
+}
+
+// ChannelsForEncoderConfig lists the channels that use an encoder
+// configuration
+func (s *LiveService) ChannelsForEncoderConfig(ctx context.Context, configID string) ([]Channel, error) {
+	channels, err := s.ListChannels(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []Channel{}
+	for _, channel := range channels {
+		if channel.EncoderConfigID == configID {
+			matching = append(matching, channel)
+		}
+	}
+	return matching, nil
+}
+
+// ListWorkflows lists live streaming workflows
+func (s *LiveService) ListWorkflows(ctx context.Context, opts ListOptions) ([]Workflow, error) {
+	return list[Workflow](ctx, s.client, "/api/v1/live/workflows", opts)
+}
+
+// GetWorkflow gets a live streaming workflow
+func (s *LiveService) GetWorkflow(ctx context.Context, id string) (*Workflow, error) {
+	return get[Workflow](ctx, s.client, "/api/v1/live/workflows/"+pathID(id))
+}
+
+// CreateWorkflow creates a live streaming workflow
+func (s *LiveService) CreateWorkflow(ctx context.Context, req CreateWorkflowRequest) (*Workflow, error) {
+	return post[Workflow](ctx, s.client, "/api/v1/live/workflows", req)
+}
+
+// UpdateWorkflow applies a partial update to a live streaming workflow
+func (s *LiveService) UpdateWorkflow(ctx context.Context, id string, req UpdateWorkflowRequest) (*Workflow, error) {
+	return put[Workflow](ctx, s.client, "/api/v1/live/workflows/"+pathID(id), req)
+}
+
+// DeleteWorkflow deletes a live streaming workflow
+func (s *LiveService) DeleteWorkflow(ctx context.Context, id string) error {
+	return del(ctx, s.client, "/api/v1/live/workflows/"+pathID(id))
+}
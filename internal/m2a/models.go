@@ -0,0 +1,275 @@
+package m2a
+
+// Resource models. Each keeps unknown fields in Extra and writes them back
+// out when re-encoded, so a model round-trips whatever the API returned.
+
+// Source is a video source in M2A Connect
+type Source struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Type        string `json:"type,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Extra       Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Source, keeping unknown fields in Extra
+func (v *Source) UnmarshalJSON(data []byte) error {
+	type known Source
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Source, including unknown fields from Extra
+func (v Source) MarshalJSON() ([]byte, error) {
+	type known Source
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Subscriber is a party that receives sources through subscriptions
+type Subscriber struct {
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Status       string `json:"status,omitempty"`
+	Extra        Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Subscriber, keeping unknown fields in Extra
+func (v *Subscriber) UnmarshalJSON(data []byte) error {
+	type known Subscriber
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Subscriber, including unknown fields from Extra
+func (v Subscriber) MarshalJSON() ([]byte, error) {
+	type known Subscriber
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Subscription is a package of sources delivered to a subscriber
+type Subscription struct {
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	SubscriberID string `json:"subscriber_id,omitempty"`
+	SourceIDs    IDList `json:"source_ids,omitempty"`
+	Status       string `json:"status,omitempty"`
+	Extra        Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Subscription, keeping unknown fields in Extra
+func (v *Subscription) UnmarshalJSON(data []byte) error {
+	type known Subscription
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Subscription, including unknown fields from Extra
+func (v Subscription) MarshalJSON() ([]byte, error) {
+	type known Subscription
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Schedule is a scheduled event on a source. The occurrences of a recurring
+// schedule share a SeriesID.
+type Schedule struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	SourceID  string `json:"source_id,omitempty"`
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+	Status    string `json:"status,omitempty"`
+	SeriesID  string `json:"series_id,omitempty"`
+	Extra     Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Schedule, keeping unknown fields in Extra
+func (v *Schedule) UnmarshalJSON(data []byte) error {
+	type known Schedule
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Schedule, including unknown fields from Extra
+func (v Schedule) MarshalJSON() ([]byte, error) {
+	type known Schedule
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Channel is a MediaLive channel
+type Channel struct {
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name,omitempty"`
+	State           string `json:"state,omitempty"`
+	InputType       string `json:"input_type,omitempty"`
+	EncoderConfigID string `json:"encoder_config_id,omitempty"`
+	Extra           Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Channel, keeping unknown fields in Extra
+func (v *Channel) UnmarshalJSON(data []byte) error {
+	type known Channel
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Channel, including unknown fields from Extra
+func (v Channel) MarshalJSON() ([]byte, error) {
+	type known Channel
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// EncoderConfig is an encoder configuration fragment
+type EncoderConfig struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Extra       Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a EncoderConfig, keeping unknown fields in Extra
+func (v *EncoderConfig) UnmarshalJSON(data []byte) error {
+	type known EncoderConfig
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a EncoderConfig, including unknown fields from Extra
+func (v EncoderConfig) MarshalJSON() ([]byte, error) {
+	type known EncoderConfig
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Workflow is a live streaming workflow
+type Workflow struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Extra       Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Workflow, keeping unknown fields in Extra
+func (v *Workflow) UnmarshalJSON(data []byte) error {
+	type known Workflow
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Workflow, including unknown fields from Extra
+func (v Workflow) MarshalJSON() ([]byte, error) {
+	type known Workflow
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Capture is a live-to-VOD capture job
+type Capture struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	ChannelID string `json:"channel_id,omitempty"`
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+	Status    string `json:"status,omitempty"`
+	// FrameRate is the frame rate of the recording, e.g. "25" or "29.97"
+	FrameRate string `json:"frame_rate,omitempty"`
+	// DurationSeconds is the length of the recording
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	// AssetID is the VOD asset the capture produced, once it has completed
+	AssetID string `json:"asset_id,omitempty"`
+	Extra   Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Capture, keeping unknown fields in Extra
+func (v *Capture) UnmarshalJSON(data []byte) error {
+	type known Capture
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Capture, including unknown fields from Extra
+func (v Capture) MarshalJSON() ([]byte, error) {
+	type known Capture
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Export is a completed VOD export from a capture
+type Export struct {
+	ID        string `json:"id,omitempty"`
+	CaptureID string `json:"capture_id,omitempty"`
+	AssetID   string `json:"asset_id,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Extra     Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Export, keeping unknown fields in Extra
+func (v *Export) UnmarshalJSON(data []byte) error {
+	type known Export
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Export, including unknown fields from Extra
+func (v Export) MarshalJSON() ([]byte, error) {
+	type known Export
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Clip is a frame-accurate clip cut from a capture
+type Clip struct {
+	ID            string `json:"id,omitempty"`
+	Name          string `json:"name,omitempty"`
+	CaptureID     string `json:"capture_id,omitempty"`
+	StartTimecode string `json:"start_timecode,omitempty"`
+	EndTimecode   string `json:"end_timecode,omitempty"`
+	Status        string `json:"status,omitempty"`
+	AssetID       string `json:"asset_id,omitempty"`
+	Extra         Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Clip, keeping unknown fields in Extra
+func (v *Clip) UnmarshalJSON(data []byte) error {
+	type known Clip
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Clip, including unknown fields from Extra
+func (v Clip) MarshalJSON() ([]byte, error) {
+	type known Clip
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// VODAsset is a video on demand asset
+type VODAsset struct {
+	ID          string `json:"id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Tags        IDList `json:"tags,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Extra       Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a VODAsset, keeping unknown fields in Extra
+func (v *VODAsset) UnmarshalJSON(data []byte) error {
+	type known VODAsset
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a VODAsset, including unknown fields from Extra
+func (v VODAsset) MarshalJSON() ([]byte, error) {
+	type known VODAsset
+	return marshalWithExtra(known(v), v.Extra)
+}
+
+// Playback is a streaming playback URL for a VOD asset
+type Playback struct {
+	URL       string `json:"url,omitempty"`
+	Format    string `json:"format,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Extra     Extra  `json:"-"`
+}
+
+// UnmarshalJSON decodes a Playback, keeping unknown fields in Extra
+func (v *Playback) UnmarshalJSON(data []byte) error {
+	type known Playback
+	return unmarshalWithExtra(data, (*known)(v), &v.Extra)
+}
+
+// MarshalJSON encodes a Playback, including unknown fields from Extra
+func (v Playback) MarshalJSON() ([]byte, error) {
+	type known Playback
+	return marshalWithExtra(known(v), v.Extra)
+}
@@ -0,0 +1,445 @@
+package m2a
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/timecode"
+)
+
+// ErrInvalidRequest is matched by errors.Is for requests rejected by
+// client-side validation before anything is sent to the API
+var ErrInvalidRequest = errors.New("invalid request")
+
+// invalid builds a validation error wrapping ErrInvalidRequest
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRequest, fmt.Sprintf(format, args...))
+}
+
+// requireFields checks that every named value is non-empty
+func requireFields(fields ...string) error {
+	for i := 0; i+1 < len(fields); i += 2 {
+		if strings.TrimSpace(fields[i+1]) == "" {
+			return invalid("%s is required", fields[i])
+		}
+	}
+	return nil
+}
+
+// oneOf checks that value, if set, is one of allowed
+func oneOf(name, value string, allowed ...string) error {
+	if value == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return invalid("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value)
+}
+
+// timeWindow checks that start and end are ISO 8601 times with end after start
+func timeWindow(start, end string) error {
+	_, _, err := parseWindow(start, end)
+	return err
+}
+
+// parseWindow parses start and end as ISO 8601 times with end after start
+func parseWindow(start, end string) (time.Time, time.Time, error) {
+	startTime, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, invalid("start_time must be an ISO 8601 time such as 2025-10-01T14:00:00Z")
+	}
+	endTime, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, invalid("end_time must be an ISO 8601 time such as 2025-10-01T16:00:00Z")
+	}
+	if endTime.Equal(startTime) {
+		return time.Time{}, time.Time{}, invalid("start_time and end_time are both %s; the window must not be empty", start)
+	}
+	if endTime.Before(startTime) {
+		return time.Time{}, time.Time{}, invalid("end_time %s must be after start_time %s", end, start)
+	}
+	return startTime, endTime, nil
+}
+
+// Source types and channel input types accepted by the API
+var (
+	SourceTypes       = []string{"rtmp", "srt", "udp", "rtp"}
+	ChannelInputTypes = []string{"RTMP_PUSH", "RTP_PUSH", "UDP_PUSH", "MEDIACONNECT"}
+	PlaybackFormats   = []string{"hls", "dash", "mp4"}
+)
+
+// CreateSourceRequest is the body of a create source call
+type CreateSourceRequest struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r CreateSourceRequest) Validate() error {
+	if err := requireFields("name", r.Name, "type", r.Type, "url", r.URL); err != nil {
+		return err
+	}
+	return oneOf("type", r.Type, SourceTypes...)
+}
+
+// UpdateSourceRequest is a partial update; only set fields are sent
+type UpdateSourceRequest struct {
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r UpdateSourceRequest) Validate() error {
+	if r == (UpdateSourceRequest{}) {
+		return invalid("at least one field to update is required")
+	}
+	return nil
+}
+
+// CreateSubscriberRequest is the body of a create subscriber call
+type CreateSubscriberRequest struct {
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r CreateSubscriberRequest) Validate() error {
+	if err := requireFields("name", r.Name, "email", r.Email); err != nil {
+		return err
+	}
+	if _, err := mail.ParseAddress(r.Email); err != nil {
+		return invalid("email %q is not a valid address", r.Email)
+	}
+	return nil
+}
+
+// UpdateSubscriberRequest is a partial update; only set fields are sent
+type UpdateSubscriberRequest struct {
+	Name         string `json:"name,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r UpdateSubscriberRequest) Validate() error {
+	if r == (UpdateSubscriberRequest{}) {
+		return invalid("at least one field to update is required")
+	}
+	if r.Email != "" {
+		if _, err := mail.ParseAddress(r.Email); err != nil {
+			return invalid("email %q is not a valid address", r.Email)
+		}
+	}
+	return nil
+}
+
+// CreateSubscriptionRequest is the body of a create subscription call.
+// SourceIDs is sent as a comma-separated string, as the API expects.
+type CreateSubscriptionRequest struct {
+	Name         string `json:"name"`
+	SubscriberID string `json:"subscriber_id"`
+	SourceIDs    string `json:"source_ids"`
+}
+
+// Validate checks the request before it is sent
+func (r CreateSubscriptionRequest) Validate() error {
+	if err := requireFields("name", r.Name, "subscriber_id", r.SubscriberID, "source_ids", r.SourceIDs); err != nil {
+		return err
+	}
+	if len(splitList(r.SourceIDs)) == 0 {
+		return invalid("source_ids must list at least one source ID")
+	}
+	return nil
+}
+
+// UpdateSubscriptionRequest is a partial update; only set fields are sent.
+// SourceIDs replaces the package's sources and is sent as a comma-separated
+// string, as the API expects.
+type UpdateSubscriptionRequest struct {
+	Name      string `json:"name,omitempty"`
+	SourceIDs string `json:"source_ids,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r UpdateSubscriptionRequest) Validate() error {
+	if r == (UpdateSubscriptionRequest{}) {
+		return invalid("at least one field to update is required")
+	}
+	if r.SourceIDs != "" && len(splitList(r.SourceIDs)) == 0 {
+		return invalid("source_ids must list at least one source ID")
+	}
+	return nil
+}
+
+// CreateScheduleRequest is the body of a create schedule call
+type CreateScheduleRequest struct {
+	Name      string `json:"name"`
+	SourceID  string `json:"source_id"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	SeriesID  string `json:"series_id,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r CreateScheduleRequest) Validate() error {
+	if err := requireFields("name", r.Name, "source_id", r.SourceID, "start_time", r.StartTime, "end_time", r.EndTime); err != nil {
+		return err
+	}
+	return timeWindow(r.StartTime, r.EndTime)
+}
+
+// UpdateScheduleRequest is a partial update; only set fields are sent
+type UpdateScheduleRequest struct {
+	Name      string `json:"name,omitempty"`
+	SourceID  string `json:"source_id,omitempty"`
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r UpdateScheduleRequest) Validate() error {
+	if r == (UpdateScheduleRequest{}) {
+		return invalid("at least one field to update is required")
+	}
+	switch {
+	case r.StartTime != "" && r.EndTime != "":
+		return timeWindow(r.StartTime, r.EndTime)
+	case r.StartTime != "":
+		if _, err := time.Parse(time.RFC3339, r.StartTime); err != nil {
+			return invalid("start_time must be an ISO 8601 time such as 2025-10-01T14:00:00Z")
+		}
+	case r.EndTime != "":
+		if _, err := time.Parse(time.RFC3339, r.EndTime); err != nil {
+			return invalid("end_time must be an ISO 8601 time such as 2025-10-01T16:00:00Z")
+		}
+	}
+	return nil
+}
+
+// Apply returns the schedule as it will be after the update
+func (r UpdateScheduleRequest) Apply(existing Schedule) Schedule {
+	for _, field := range []struct{ value, target *string }{
+		{&r.Name, &existing.Name},
+		{&r.SourceID, &existing.SourceID},
+		{&r.StartTime, &existing.StartTime},
+		{&r.EndTime, &existing.EndTime},
+	} {
+		if *field.value != "" {
+			*field.target = *field.value
+		}
+	}
+	return existing
+}
+
+// ValidateFor checks the request against the schedule it updates, so that
+// moving just one end of the window can't leave it empty or inverted
+func (r UpdateScheduleRequest) ValidateFor(existing *Schedule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	updated := r.Apply(*existing)
+	return timeWindow(updated.StartTime, updated.EndTime)
+}
+
+// CreateChannelRequest is the body of a create channel call
+type CreateChannelRequest struct {
+	Name            string `json:"name"`
+	InputType       string `json:"input_type"`
+	EncoderConfigID string `json:"encoder_config_id,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r CreateChannelRequest) Validate() error {
+	if err := requireFields("name", r.Name, "input_type", r.InputType); err != nil {
+		return err
+	}
+	return oneOf("input_type", r.InputType, ChannelInputTypes...)
+}
+
+// CreateEncoderConfigRequest is the body of a create encoder config call.
+// Settings holds the configuration itself (codecs, ladder and so on) and is
+// sent alongside the name and description.
+type CreateEncoderConfigRequest struct {
+	Name        string
+	Description string
+	Settings    map[string]interface{}
+}
+
+// Validate checks the request before it is sent
+func (r CreateEncoderConfigRequest) Validate() error {
+	if err := requireFields("name", r.Name); err != nil {
+		return err
+	}
+	return checkSettings("settings", r.Settings)
+}
+
+// MarshalJSON sends Settings as top-level fields next to name and description
+func (r CreateEncoderConfigRequest) MarshalJSON() ([]byte, error) {
+	body := map[string]interface{}{}
+	for k, v := range r.Settings {
+		body[k] = v
+	}
+	body["name"] = r.Name
+	if r.Description != "" {
+		body["description"] = r.Description
+	}
+	return json.Marshal(body)
+}
+
+// UpdateEncoderConfigRequest is a partial update; only set fields are sent.
+// Each top-level field in Settings replaces the configuration's field of
+// the same name.
+type UpdateEncoderConfigRequest struct {
+	Name        string
+	Description string
+	Settings    map[string]interface{}
+}
+
+// Validate checks the request before it is sent
+func (r UpdateEncoderConfigRequest) Validate() error {
+	if r.Name == "" && r.Description == "" && len(r.Settings) == 0 {
+		return invalid("at least one field to update is required")
+	}
+	return checkSettings("settings", r.Settings)
+}
+
+// MarshalJSON sends Settings as top-level fields next to name and description
+func (r UpdateEncoderConfigRequest) MarshalJSON() ([]byte, error) {
+	body := map[string]interface{}{}
+	for k, v := range r.Settings {
+		body[k] = v
+	}
+	if r.Name != "" {
+		body["name"] = r.Name
+	}
+	if r.Description != "" {
+		body["description"] = r.Description
+	}
+	return json.Marshal(body)
+}
+
+// CreateWorkflowRequest is the body of a create workflow call
+type CreateWorkflowRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r CreateWorkflowRequest) Validate() error {
+	return requireFields("name", r.Name)
+}
+
+// UpdateWorkflowRequest is a partial update; only set fields are sent
+type UpdateWorkflowRequest struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r UpdateWorkflowRequest) Validate() error {
+	if r == (UpdateWorkflowRequest{}) {
+		return invalid("at least one field to update is required")
+	}
+	return nil
+}
+
+// CreateCaptureRequest is the body of a create capture call
+type CreateCaptureRequest struct {
+	Name      string `json:"name"`
+	ChannelID string `json:"channel_id"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+// Validate checks the request before it is sent
+func (r CreateCaptureRequest) Validate() error {
+	if err := requireFields("name", r.Name, "channel_id", r.ChannelID, "start_time", r.StartTime, "end_time", r.EndTime); err != nil {
+		return err
+	}
+	return timeWindow(r.StartTime, r.EndTime)
+}
+
+// CreateClipRequest is the body of a create clip call
+type CreateClipRequest struct {
+	CaptureID     string `json:"capture_id"`
+	StartTimecode string `json:"start_timecode"`
+	EndTimecode   string `json:"end_timecode"`
+	Name          string `json:"name"`
+}
+
+// Validate checks the request before it is sent. Without the capture's
+// frame rate, timecodes are only checked for shape; see ValidateFor.
+func (r CreateClipRequest) Validate() error {
+	if err := requireFields("capture_id", r.CaptureID, "start_timecode", r.StartTimecode, "end_timecode", r.EndTimecode, "name", r.Name); err != nil {
+		return err
+	}
+	_, _, err := r.timecodes(timecode.Rate60)
+	return err
+}
+
+// ValidateFor checks the request against the capture it cuts from: the
+// timecodes must exist at the capture's frame rate, end after they start
+// and not run past the end of the recording. Checks that need a frame rate
+// or duration the API didn't report are skipped.
+func (r CreateClipRequest) ValidateFor(capture *Capture) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	rate, ok := capture.Rate()
+	if !ok {
+		return nil
+	}
+
+	_, end, err := r.timecodes(rate)
+	if err != nil {
+		return err
+	}
+	if duration := capture.Duration(); duration > 0 && end.Offset() > duration {
+		last, _ := timecode.FromOffset(duration, rate)
+		return invalid("end_timecode %s is past the end of capture %s, which is %s long at %s fps", r.EndTimecode, capture.ID, last, rate)
+	}
+	return nil
+}
+
+// timecodes parses the clip's start and end at rate r
+func (r CreateClipRequest) timecodes(rate timecode.Rate) (start, end timecode.Timecode, err error) {
+	start, err = timecode.Parse(r.StartTimecode, rate)
+	if err != nil {
+		return start, end, invalid("start_timecode: %v", err)
+	}
+	end, err = timecode.Parse(r.EndTimecode, rate)
+	if err != nil {
+		return start, end, invalid("end_timecode: %v", err)
+	}
+	if !start.Before(end) {
+		return start, end, invalid("end_timecode %s must be after start_timecode %s", r.EndTimecode, r.StartTimecode)
+	}
+	return start, end, nil
+}
+
+// UpdateVODMetadataRequest is a partial update of a VOD asset's metadata.
+// Tags is sent as a comma-separated string, as the API expects.
+type UpdateVODMetadataRequest struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Tags        string `json:"tags,omitempty"`
+}
+
+// Validate checks the request before it is sent
+func (r UpdateVODMetadataRequest) Validate() error {
+	if r == (UpdateVODMetadataRequest{}) {
+		return invalid("at least one metadata field is required")
+	}
+	return nil
+}
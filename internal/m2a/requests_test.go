@@ -0,0 +1,98 @@
+package m2a
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	const start, end = "2025-10-01T14:00:00Z", "2025-10-01T16:00:00Z"
+	tests := []struct {
+		name    string
+		request validator
+		want    string // empty for a valid request
+	}{
+		{"source", CreateSourceRequest{Name: "Cam", Type: "srt", URL: "srt://x"}, ""},
+		{"source without url", CreateSourceRequest{Name: "Cam", Type: "srt"}, "url is required"},
+		{"source with blank name", CreateSourceRequest{Name: "  ", Type: "srt", URL: "srt://x"}, "name is required"},
+		{"source of unknown type", CreateSourceRequest{Name: "Cam", Type: "ndi", URL: "ndi://x"}, `type must be one of rtmp, srt, udp, rtp, got "ndi"`},
+		{"empty source update", UpdateSourceRequest{}, "at least one field"},
+		{"subscriber", CreateSubscriberRequest{Name: "Ops", Email: "ops@example.com"}, ""},
+		{"subscriber with bad email", CreateSubscriberRequest{Name: "Ops", Email: "not-an-email"}, "not a valid address"},
+		{"subscriber update with bad email", UpdateSubscriberRequest{Email: "nope"}, "not a valid address"},
+		{"empty subscriber update", UpdateSubscriberRequest{}, "at least one field"},
+		{"subscription", CreateSubscriptionRequest{Name: "Pkg", SubscriberID: "sub-1", SourceIDs: "src-1, src-2"}, ""},
+		{"subscription without sources", CreateSubscriptionRequest{Name: "Pkg", SubscriberID: "sub-1", SourceIDs: " , "}, "at least one source ID"},
+		{"subscription update without sources", UpdateSubscriptionRequest{SourceIDs: ","}, "at least one source ID"},
+		{"schedule", CreateScheduleRequest{Name: "Final", SourceID: "src-1", StartTime: start, EndTime: end}, ""},
+		{"schedule with bad start", CreateScheduleRequest{Name: "Final", SourceID: "src-1", StartTime: "tomorrow", EndTime: end}, "start_time must be an ISO 8601 time"},
+		{"empty schedule", CreateScheduleRequest{Name: "Final", SourceID: "src-1", StartTime: start, EndTime: start}, "must not be empty"},
+		{"inverted schedule", CreateScheduleRequest{Name: "Final", SourceID: "src-1", StartTime: end, EndTime: start}, "must be after start_time"},
+		{"schedule update with bad end", UpdateScheduleRequest{EndTime: "later"}, "end_time must be an ISO 8601 time"},
+		{"empty schedule update", UpdateScheduleRequest{}, "at least one field"},
+		{"channel", CreateChannelRequest{Name: "News", InputType: "RTMP_PUSH"}, ""},
+		{"channel with bad input", CreateChannelRequest{Name: "News", InputType: "rtmp"}, "input_type must be one of"},
+		{"encoder config", CreateEncoderConfigRequest{Name: "HD", Settings: map[string]interface{}{"bitrate": 6000}}, ""},
+		{"encoder config setting its name", CreateEncoderConfigRequest{Name: "HD", Settings: map[string]interface{}{"name": "x", "id": "y"}}, "settings can't set id, name"},
+		{"empty encoder config update", UpdateEncoderConfigRequest{}, "at least one field"},
+		{"workflow without name", CreateWorkflowRequest{Description: "x"}, "name is required"},
+		{"empty workflow update", UpdateWorkflowRequest{}, "at least one field"},
+		{"capture", CreateCaptureRequest{Name: "News", ChannelID: "ch-1", StartTime: start, EndTime: end}, ""},
+		{"inverted capture", CreateCaptureRequest{Name: "News", ChannelID: "ch-1", StartTime: end, EndTime: start}, "must be after start_time"},
+		{"clip", CreateClipRequest{CaptureID: "cap-1", Name: "Goal", StartTimecode: "00:01:00:00", EndTimecode: "00:01:30:00"}, ""},
+		{"clip with bad timecode", CreateClipRequest{CaptureID: "cap-1", Name: "Goal", StartTimecode: "1 minute", EndTimecode: "00:01:30:00"}, "start_timecode"},
+		{"inverted clip", CreateClipRequest{CaptureID: "cap-1", Name: "Goal", StartTimecode: "00:02:00:00", EndTimecode: "00:01:30:00"}, "must be after start_timecode"},
+		{"empty metadata update", UpdateVODMetadataRequest{}, "at least one metadata field"},
+	}
+	for _, tt := range tests {
+		err := tt.request.Validate()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.want != "" && (!errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: err = %v, want an invalid request mentioning %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// Updates that move one end of a schedule are checked against the other
+func TestUpdateScheduleValidateFor(t *testing.T) {
+	existing := &Schedule{StartTime: "2025-10-01T14:00:00Z", EndTime: "2025-10-01T16:00:00Z"}
+	if err := (UpdateScheduleRequest{EndTime: "2025-10-01T17:00:00Z"}).ValidateFor(existing); err != nil {
+		t.Errorf("extending the end: %v", err)
+	}
+	for _, r := range []UpdateScheduleRequest{
+		{StartTime: "2025-10-01T17:00:00Z"},
+		{EndTime: "2025-10-01T14:00:00Z"},
+	} {
+		if err := r.ValidateFor(existing); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%+v: err = %v, want an invalid request", r, err)
+		}
+	}
+}
+
+// Clips are checked against the capture's frame rate and length
+func TestCreateClipValidateFor(t *testing.T) {
+	capture := &Capture{ID: "cap-1", FrameRate: "25", DurationSeconds: 600}
+	clip := func(start, end string) CreateClipRequest {
+		return CreateClipRequest{CaptureID: "cap-1", Name: "Goal", StartTimecode: start, EndTimecode: end}
+	}
+
+	if err := clip("00:01:00:00", "00:09:59:24").ValidateFor(capture); err != nil {
+		t.Errorf("clip within the capture: %v", err)
+	}
+	for _, r := range []CreateClipRequest{
+		clip("00:01:00:00", "00:10:00:01"),
+		clip("00:01:00:00", "00:01:30:29"),
+	} {
+		if err := r.ValidateFor(capture); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%s-%s: err = %v, want an invalid request", r.StartTimecode, r.EndTimecode, err)
+		}
+	}
+
+	// Without a frame rate only the shape of the timecodes is checked
+	if err := clip("00:01:00:00", "00:20:00:29").ValidateFor(&Capture{ID: "cap-2"}); err != nil {
+		t.Errorf("capture without a frame rate: %v", err)
+	}
+}
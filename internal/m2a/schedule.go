@@ -0,0 +1,95 @@
+package m2a
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScheduleCancelled is the status of a schedule that no longer books its
+// source
+const ScheduleCancelled = "cancelled"
+
+// ErrScheduleConflict is matched by errors.Is when a schedule would overlap
+// another booking of the same source
+var ErrScheduleConflict = errors.New("schedule conflict")
+
+// ScheduleConflictError lists the schedules that a booking overlaps
+type ScheduleConflictError struct {
+	SourceID  string
+	Conflicts []Schedule
+}
+
+// Error describes each clashing schedule
+func (e *ScheduleConflictError) Error() string {
+	clashes := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		clashes = append(clashes, fmt.Sprintf("%s %q (%s to %s)", c.ID, c.Name, c.StartTime, c.EndTime))
+	}
+	return fmt.Sprintf("%v: source %s is already booked by %s", ErrScheduleConflict, e.SourceID, strings.Join(clashes, ", "))
+}
+
+// Is matches ErrScheduleConflict
+func (e *ScheduleConflictError) Is(target error) bool {
+	return target == ErrScheduleConflict
+}
+
+// IDs returns the IDs of the clashing schedules
+func (e *ScheduleConflictError) IDs() []string {
+	ids := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// CheckScheduleConflicts checks that booking sourceID from start to end
+// wouldn't overlap any of the source's other schedules, returning a
+// *ScheduleConflictError listing them if it would. Windows are half-open,
+// so back-to-back schedules don't clash. exclude names a schedule to ignore,
+// such as the one being updated; cancelled schedules and ones whose times
+// can't be read are ignored too.
+func (s *ConnectService) CheckScheduleConflicts(ctx context.Context, sourceID, start, end, exclude string) error {
+	startTime, endTime, err := parseWindow(start, end)
+	if err != nil {
+		return err
+	}
+	schedules, err := s.SchedulesForSource(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+
+	conflicts := conflicting(schedules, startTime, endTime, func(schedule Schedule) bool { return schedule.ID == exclude })
+	if len(conflicts) > 0 {
+		return &ScheduleConflictError{SourceID: sourceID, Conflicts: conflicts}
+	}
+	return nil
+}
+
+// conflicting returns the schedules whose windows [start, end) overlaps,
+// other than cancelled ones, ones whose times can't be read and ones skip
+// reports true for
+func conflicting(schedules []Schedule, start, end time.Time, skip func(Schedule) bool) []Schedule {
+	var conflicts []Schedule
+	for _, schedule := range schedules {
+		if skip(schedule) || strings.EqualFold(schedule.Status, ScheduleCancelled) {
+			continue
+		}
+		otherStart, otherEnd, err := parseWindow(schedule.StartTime, schedule.EndTime)
+		if err != nil {
+			continue
+		}
+		if overlaps(start, end, otherStart, otherEnd) {
+			conflicts = append(conflicts, schedule)
+		}
+	}
+	return conflicts
+}
+
+// overlaps reports whether the half-open windows [startA, endA) and
+// [startB, endB) intersect
+func overlaps(startA, endA, startB, endB time.Time) bool {
+	return startA.Before(endB) && startB.Before(endA)
+}
@@ -0,0 +1,422 @@
+package m2a
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/andy-wilson/m2a-mcp/internal/recurrence"
+)
+
+// Statuses of the occurrences in a series result
+const (
+	OccurrenceValid    = "valid"
+	OccurrenceConflict = "conflict"
+	OccurrenceCreated  = "created"
+	OccurrenceUpdated  = "updated"
+	OccurrenceDeleted  = "deleted"
+	OccurrenceFailed   = "failed"
+)
+
+// Limits on how far a recurring schedule is expanded
+const (
+	DefaultSeriesHorizon = 90 * 24 * time.Hour
+	MaxSeriesHorizon     = 366 * 24 * time.Hour
+	MaxSeriesOccurrences = 200
+)
+
+// RecurringScheduleRequest describes a schedule that repeats by an iCalendar
+// RRULE. It is expanded into one schedule per occurrence, all sharing a
+// series ID.
+type RecurringScheduleRequest struct {
+	Name     string
+	SourceID string
+	// Start is when the first occurrence starts, as wall-clock time in
+	// TimeZone (2025-10-04T15:00:00) or with an offset
+	Start    string
+	Duration time.Duration
+	Rule     string
+	// TimeZone is an IANA zone such as Europe/London (default UTC).
+	// Occurrences keep their wall-clock time across daylight saving changes.
+	TimeZone string
+	// Exclude is a comma-separated list of EXDATEs: times of single
+	// occurrences, or dates to skip entirely
+	Exclude string
+	// Horizon bounds how far ahead an open-ended rule is expanded (default
+	// DefaultSeriesHorizon, at most MaxSeriesHorizon)
+	Horizon time.Duration
+}
+
+// Window is the start and end of one occurrence
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Series is an expanded RecurringScheduleRequest
+type Series struct {
+	// ID is set once the series' schedules are created
+	ID       string
+	Rule     recurrence.Rule
+	Location *time.Location
+	// Until is the end of the horizon
+	Until   time.Time
+	Windows []Window
+}
+
+// Validate checks the request before it is expanded
+func (r RecurringScheduleRequest) Validate() error {
+	_, err := r.Expand()
+	return err
+}
+
+// Expand parses the rule and returns the occurrences it generates within the
+// horizon. It fails if there are none, more than MaxSeriesOccurrences, or if
+// any two overlap.
+func (r RecurringScheduleRequest) Expand() (*Series, error) {
+	if err := requireFields("name", r.Name, "source_id", r.SourceID, "start_time", r.Start, "rrule", r.Rule); err != nil {
+		return nil, err
+	}
+	if r.Duration <= 0 {
+		return nil, invalid("duration must be positive, e.g. 2h or 01:30:00")
+	}
+	zone := r.TimeZone
+	if zone == "" {
+		zone = "UTC"
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, invalid("timezone %q is not an IANA time zone such as Europe/London", zone)
+	}
+	horizon := r.Horizon
+	if horizon <= 0 {
+		horizon = DefaultSeriesHorizon
+	}
+	if horizon > MaxSeriesHorizon {
+		return nil, invalid("the horizon can be at most %d days", int(MaxSeriesHorizon.Hours()/24))
+	}
+
+	rule, err := recurrence.ParseRule(r.Rule, loc)
+	if err != nil {
+		return nil, invalid("rrule: %v", err)
+	}
+	start, allDay, err := recurrence.ParseDateTime(r.Start, loc)
+	if err != nil {
+		return nil, invalid("start_time: %v", err)
+	}
+	if allDay {
+		return nil, invalid("start_time needs a time of day as well as a date")
+	}
+	exclude, err := recurrence.ParseExclusions(r.Exclude, loc)
+	if err != nil {
+		return nil, invalid("exdates: %v", err)
+	}
+
+	series := &Series{Rule: rule, Location: loc, Until: start.Add(horizon)}
+	set := recurrence.Set{Start: start, Rule: rule, Exclude: exclude}
+	starts := set.Occurrences(series.Until, MaxSeriesOccurrences+1)
+	switch {
+	case len(starts) == 0:
+		return nil, invalid("the rule has no occurrences between %s and %s", start.Format(time.RFC3339), series.Until.Format(time.RFC3339))
+	case len(starts) > MaxSeriesOccurrences:
+		return nil, invalid("the rule has more than %d occurrences before %s; add COUNT or UNTIL, or shorten the horizon", MaxSeriesOccurrences, series.Until.Format(time.RFC3339))
+	}
+	for _, t := range starts {
+		series.Windows = append(series.Windows, Window{Start: t, End: t.Add(r.Duration)})
+	}
+	if err := checkWindows(series.Windows); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// checkWindows checks that no two of a series' windows overlap
+func checkWindows(windows []Window) error {
+	sorted := slices.SortedFunc(slices.Values(windows), func(a, b Window) int { return a.Start.Compare(b.Start) })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Start.Before(sorted[i-1].End) {
+			return invalid("occurrences starting %s and %s overlap; each must end before the next starts", sorted[i-1].Start.Format(time.RFC3339), sorted[i].Start.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// SeriesOptions controls how a series is created or changed
+type SeriesOptions struct {
+	// AllowOverlap saves occurrences that overlap other schedules of their
+	// source, which otherwise fail the whole call
+	AllowOverlap bool
+	// DryRun checks every occurrence without changing anything
+	DryRun bool
+}
+
+// OccurrenceResult is the outcome for one occurrence of a series
+type OccurrenceResult struct {
+	Index      int    `json:"index"`
+	ScheduleID string `json:"schedule_id,omitempty"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	// LocalStart is the start in the series' time zone
+	LocalStart string `json:"local_start,omitempty"`
+	// Status is OccurrenceValid or OccurrenceConflict (dry run),
+	// OccurrenceCreated, OccurrenceUpdated, OccurrenceDeleted or
+	// OccurrenceFailed
+	Status       string   `json:"status"`
+	OverlapsWith []string `json:"overlaps_with,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	// Err is why the occurrence failed
+	Err error `json:"-"`
+}
+
+// fail marks the result failed with err
+func (o *OccurrenceResult) fail(err error) {
+	o.Status, o.Err, o.Error = OccurrenceFailed, err, err.Error()
+}
+
+// NewSeriesID returns a random ID for a series of schedules
+func NewSeriesID() string {
+	buf := make([]byte, 6)
+	rand.Read(buf)
+	return "series-" + hex.EncodeToString(buf)
+}
+
+// CreateScheduleSeries expands a recurring schedule and creates a schedule
+// for each occurrence, tagged with a new series ID. Every occurrence is
+// checked against the source's other schedules first; unless
+// opts.AllowOverlap is set, any clash fails the call with a
+// *ScheduleConflictError and nothing is created. With opts.DryRun the
+// occurrences are only expanded and checked. Schedules that fail to create
+// are reported and don't stop the rest.
+func (s *ConnectService) CreateScheduleSeries(ctx context.Context, req RecurringScheduleRequest, opts SeriesOptions) (*Series, []OccurrenceResult, error) {
+	series, err := req.Expand()
+	if err != nil {
+		return nil, nil, err
+	}
+	existing, err := s.SchedulesForSource(ctx, req.SourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]OccurrenceResult, len(series.Windows))
+	var conflicts []Schedule
+	for i, w := range series.Windows {
+		results[i] = OccurrenceResult{
+			Index:      i + 1,
+			StartTime:  w.Start.UTC().Format(time.RFC3339),
+			EndTime:    w.End.UTC().Format(time.RFC3339),
+			LocalStart: w.Start.Format(time.RFC3339),
+			Status:     OccurrenceValid,
+		}
+		clashes := conflicting(existing, w.Start, w.End, func(Schedule) bool { return false })
+		results[i].OverlapsWith = scheduleIDs(clashes)
+		if len(clashes) > 0 && !opts.AllowOverlap {
+			results[i].Status = OccurrenceConflict
+			conflicts = appendNew(conflicts, clashes)
+		}
+	}
+	if opts.DryRun {
+		return series, results, nil
+	}
+	if len(conflicts) > 0 {
+		return series, results, &ScheduleConflictError{SourceID: req.SourceID, Conflicts: conflicts}
+	}
+
+	series.ID = NewSeriesID()
+	for i := range results {
+		schedule, err := s.CreateSchedule(ctx, CreateScheduleRequest{
+			Name:      req.Name,
+			SourceID:  req.SourceID,
+			StartTime: results[i].StartTime,
+			EndTime:   results[i].EndTime,
+			SeriesID:  series.ID,
+		})
+		if err != nil {
+			results[i].fail(err)
+			continue
+		}
+		results[i].ScheduleID, results[i].Status = schedule.ID, OccurrenceCreated
+	}
+	return series, results, nil
+}
+
+// ScheduleSeries lists the schedules in a series, earliest first
+func (s *ConnectService) ScheduleSeries(ctx context.Context, seriesID string) ([]Schedule, error) {
+	schedules, err := s.ListSchedules(ctx, ListOptions{Filters: url.Values{"series_id": {seriesID}}})
+	if err != nil {
+		return nil, err
+	}
+
+	// The filter is also applied here in case the API ignores it
+	matching := []Schedule{}
+	for _, schedule := range schedules {
+		if schedule.SeriesID == seriesID {
+			matching = append(matching, schedule)
+		}
+	}
+	slices.SortStableFunc(matching, func(a, b Schedule) int {
+		at, errA := time.Parse(time.RFC3339, a.StartTime)
+		bt, errB := time.Parse(time.RFC3339, b.StartTime)
+		if errA != nil || errB != nil {
+			return 0
+		}
+		return at.Compare(bt)
+	})
+	return matching, nil
+}
+
+// UpdateScheduleSeriesRequest changes every schedule in a series alike.
+// Shift moves each occurrence by the same amount; Duration sets each one's
+// length, keeping its start.
+type UpdateScheduleSeriesRequest struct {
+	Name     string
+	SourceID string
+	Shift    time.Duration
+	Duration time.Duration
+}
+
+// Validate checks the request before it is applied
+func (r UpdateScheduleSeriesRequest) Validate() error {
+	if r == (UpdateScheduleSeriesRequest{}) {
+		return invalid("at least one field to update is required")
+	}
+	if r.Duration < 0 {
+		return invalid("duration must be positive, e.g. 2h or 01:30:00")
+	}
+	return nil
+}
+
+// For returns the update to make to one schedule of the series
+func (r UpdateScheduleSeriesRequest) For(schedule Schedule) (UpdateScheduleRequest, error) {
+	update := UpdateScheduleRequest{Name: r.Name, SourceID: r.SourceID}
+	if r.Shift == 0 && r.Duration == 0 {
+		return update, nil
+	}
+
+	start, end, err := parseWindow(schedule.StartTime, schedule.EndTime)
+	if err != nil {
+		return UpdateScheduleRequest{}, invalid("schedule %s: %v", schedule.ID, err)
+	}
+	start, end = start.Add(r.Shift), end.Add(r.Shift)
+	if r.Duration > 0 {
+		end = start.Add(r.Duration)
+	}
+	update.StartTime = start.UTC().Format(time.RFC3339)
+	update.EndTime = end.UTC().Format(time.RFC3339)
+	return update, nil
+}
+
+// UpdateScheduleSeries applies req to each of a series' schedules. As with
+// CreateScheduleSeries, the changed occurrences are checked against the
+// other schedules of their source first and a clash fails the call unless
+// opts.AllowOverlap is set; with opts.DryRun nothing is changed.
+func (s *ConnectService) UpdateScheduleSeries(ctx context.Context, members []Schedule, req UpdateScheduleSeriesRequest, opts SeriesOptions) ([]OccurrenceResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	updates := make([]UpdateScheduleRequest, len(members))
+	results := make([]OccurrenceResult, len(members))
+	windows := make([]Window, len(members))
+	for i, member := range members {
+		update, err := req.For(member)
+		if err != nil {
+			return nil, err
+		}
+		updated := update.Apply(member)
+		start, end, err := parseWindow(updated.StartTime, updated.EndTime)
+		if err != nil {
+			return nil, invalid("schedule %s: %v", member.ID, err)
+		}
+		updates[i], windows[i] = update, Window{Start: start, End: end}
+		results[i] = OccurrenceResult{
+			Index:      i + 1,
+			ScheduleID: member.ID,
+			StartTime:  updated.StartTime,
+			EndTime:    updated.EndTime,
+			Status:     OccurrenceValid,
+		}
+	}
+
+	// Only a new source or new times can create a clash. The series' own
+	// schedules are left out, as they are all moving together.
+	var conflicts []Schedule
+	if req.SourceID != "" || req.Shift != 0 || req.Duration != 0 {
+		if err := checkWindows(windows); err != nil {
+			return nil, err
+		}
+		all, err := s.ListSchedules(ctx, ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		inSeries := func(schedule Schedule) bool {
+			return slices.ContainsFunc(members, func(m Schedule) bool { return m.ID == schedule.ID })
+		}
+		for i, member := range members {
+			sourceID := updates[i].Apply(member).SourceID
+			clashes := conflicting(all, windows[i].Start, windows[i].End, func(schedule Schedule) bool {
+				return schedule.SourceID != sourceID || inSeries(schedule)
+			})
+			results[i].OverlapsWith = scheduleIDs(clashes)
+			if len(clashes) > 0 && !opts.AllowOverlap {
+				results[i].Status = OccurrenceConflict
+				conflicts = appendNew(conflicts, clashes)
+			}
+		}
+	}
+	if opts.DryRun {
+		return results, nil
+	}
+	if len(conflicts) > 0 {
+		return results, &ScheduleConflictError{SourceID: conflicts[0].SourceID, Conflicts: conflicts}
+	}
+
+	for i, member := range members {
+		if _, err := s.UpdateSchedule(ctx, member.ID, updates[i]); err != nil {
+			results[i].fail(err)
+			continue
+		}
+		results[i].Status = OccurrenceUpdated
+	}
+	return results, nil
+}
+
+// DeleteScheduleSeries deletes each of a series' schedules, reporting any
+// that fail rather than stopping
+func (s *ConnectService) DeleteScheduleSeries(ctx context.Context, members []Schedule) []OccurrenceResult {
+	results := make([]OccurrenceResult, len(members))
+	for i, member := range members {
+		results[i] = OccurrenceResult{
+			Index:      i + 1,
+			ScheduleID: member.ID,
+			StartTime:  member.StartTime,
+			EndTime:    member.EndTime,
+			Status:     OccurrenceDeleted,
+		}
+		if err := s.DeleteSchedule(ctx, member.ID); err != nil {
+			results[i].fail(err)
+		}
+	}
+	return results
+}
+
+// scheduleIDs returns the schedules' IDs
+func scheduleIDs(schedules []Schedule) []string {
+	var ids []string
+	for _, schedule := range schedules {
+		ids = append(ids, schedule.ID)
+	}
+	return ids
+}
+
+// appendNew appends the schedules not already in list
+func appendNew(list, schedules []Schedule) []Schedule {
+	for _, schedule := range schedules {
+		if !slices.ContainsFunc(list, func(s Schedule) bool { return s.ID == schedule.ID }) {
+			list = append(list, schedule)
+		}
+	}
+	return list
+}
@@ -0,0 +1,103 @@
+// Package m2a is a typed Go SDK for the M2A Media APIs. It wraps
+// client.M2AClient with request and response models for each resource and
+// one service per API family, and has no dependency on MCP.
+package m2a
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+)
+
+// validator is implemented by request bodies that can check themselves
+type validator interface {
+	Validate() error
+}
+
+// ListOptions controls how a collection is listed. By default every item is
+// returned, following pagination.
+type ListOptions struct {
+	// MaxItems stops listing after this many items (0 = no limit)
+	MaxItems int
+	// Filters are added to the query string, e.g. {"status": {"active"}}
+	Filters url.Values
+}
+
+// endpoint returns path with the options' filters applied
+func (o ListOptions) endpoint(path string) string {
+	if query := o.Filters.Encode(); query != "" {
+		return path + "?" + query
+	}
+	return path
+}
+
+// list fetches every item of a collection
+func list[T any](ctx context.Context, c *client.M2AClient, path string, opts ListOptions) ([]T, error) {
+	return client.ListAll[T](ctx, c, opts.endpoint(path), client.PaginateOptions{MaxItems: opts.MaxItems})
+}
+
+// get fetches and decodes a single resource
+func get[T any](ctx context.Context, c *client.M2AClient, endpoint string) (*T, error) {
+	data, err := c.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return decode[T](data, endpoint)
+}
+
+// post validates and sends body, then decodes the response
+func post[T any](ctx context.Context, c *client.M2AClient, endpoint string, body interface{}, opts ...client.RequestOption) (*T, error) {
+	if v, ok := body.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := c.Post(ctx, endpoint, body, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decode[T](data, endpoint)
+}
+
+// put validates and sends body, then decodes the response
+func put[T any](ctx context.Context, c *client.M2AClient, endpoint string, body interface{}) (*T, error) {
+	if v, ok := body.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := c.Put(ctx, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	return decode[T](data, endpoint)
+}
+
+// del deletes a resource
+func del(ctx context.Context, c *client.M2AClient, endpoint string) error {
+	_, err := c.Delete(ctx, endpoint)
+	return err
+}
+
+// decode unmarshals a response body. An empty body decodes to the zero value,
+// since some actions return no content.
+func decode[T any](data []byte, endpoint string) (*T, error) {
+	v := new(T)
+	if len(data) == 0 {
+		return v, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("unexpected response from %s: %w", endpoint, err)
+	}
+	return v, nil
+}
+
+// pathID escapes a resource ID for use in a URL path
+func pathID(id string) string {
+	return url.PathEscape(id)
+}
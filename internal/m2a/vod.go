@@ -0,0 +1,47 @@
+package m2a
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+)
+
+// VODService covers M2A VOD assets and playback
+type VODService struct {
+	client *client.M2AClient
+}
+
+// NewVODService creates a VODService
+func NewVODService(c *client.M2AClient) *VODService {
+	return &VODService{client: c}
+}
+
+// ListAssets lists VOD assets
+func (s *VODService) ListAssets(ctx context.Context, opts ListOptions) ([]VODAsset, error) {
+	return list[VODAsset](ctx, s.client, "/api/v1/vod/assets", opts)
+}
+
+// GetAsset gets a VOD asset
+func (s *VODService) GetAsset(ctx context.Context, id string) (*VODAsset, error) {
+	return get[VODAsset](ctx, s.client, "/api/v1/vod/assets/"+pathID(id))
+}
+
+// UpdateMetadata applies a partial metadata update to a VOD asset
+func (s *VODService) UpdateMetadata(ctx context.Context, id string, req UpdateVODMetadataRequest) (*VODAsset, error) {
+	return put[VODAsset](ctx, s.client, "/api/v1/vod/assets/"+pathID(id), req)
+}
+
+// DeleteAsset deletes a VOD asset
+func (s *VODService) DeleteAsset(ctx context.Context, id string) error {
+	return del(ctx, s.client, "/api/v1/vod/assets/"+pathID(id))
+}
+
+// GetPlayback gets a streaming playback URL for a VOD asset in the given
+// format (hls, dash or mp4)
+func (s *VODService) GetPlayback(ctx context.Context, id, format string) (*Playback, error) {
+	if err := oneOf("format", format, PlaybackFormats...); err != nil {
+		return nil, err
+	}
+	return get[Playback](ctx, s.client, "/api/v1/vod/assets/"+pathID(id)+"/playback?format="+url.QueryEscape(format))
+}
@@ -0,0 +1,275 @@
+// Package prompts loads MCP prompts: runbooks for common broadcast operations
+// that walk the model through a sequence of tool calls.
+//
+// Each prompt is a Markdown file with a header between "---" lines followed
+// by a text/template body:
+//
+//	---
+//	name: onboard_subscriber
+//	description: Onboard a new subscriber to one or more sources
+//	argument: email (required) Subscriber email address
+//	argument: organization Organization name
+//	tools: get_source, create_subscriber, create_subscription
+//	---
+//	Onboard {{.email}}{{if .organization}} from {{.organization}}{{end}}...
+//
+// name defaults to the file name. tools lists the tools the runbook calls;
+// prompts whose tools aren't all registered (e.g. in read-only mode) are
+// skipped. Arguments the client omits render as empty strings.
+package prompts
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+//go:embed builtin/*.md
+var builtin embed.FS
+
+// validName matches prompt, argument and tool names
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Prompt is a parsed prompt definition
+type Prompt struct {
+	Name        string
+	Description string
+	Arguments   []Argument
+	Tools       []string
+	// Source is the file the prompt was loaded from; built-in prompts are
+	// relative to this package
+	Source string
+
+	template *template.Template
+}
+
+// Argument is a named value the client supplies when getting a prompt
+type Argument struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Load returns the built-in prompts, plus any in dir. A prompt in dir
+// replaces a built-in prompt with the same name. dir may be empty.
+func Load(dir string) ([]*Prompt, error) {
+	byName := make(map[string]*Prompt)
+
+	builtins, err := loadFS(builtin, "builtin/*.md")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range builtins {
+		byName[p.Name] = p
+	}
+
+	if dir != "" {
+		custom, err := loadFS(os.DirFS(dir), "*.md")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load prompts from %s: %w", dir, err)
+		}
+		for _, p := range custom {
+			p.Source = filepath.Join(dir, p.Source)
+			byName[p.Name] = p
+		}
+	}
+
+	prompts := make([]*Prompt, 0, len(byName))
+	for _, p := range byName {
+		prompts = append(prompts, p)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts, nil
+}
+
+// loadFS parses every file in fsys matching pattern
+func loadFS(fsys fs.FS, pattern string) ([]*Prompt, error) {
+	paths, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]string)
+	var prompts []*Prompt
+	for _, path := range paths {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		p, err := Parse(name, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if other, ok := seen[p.Name]; ok {
+			return nil, fmt.Errorf("%s: prompt %q is already defined in %s", filepath.Base(path), p.Name, other)
+		}
+		seen[p.Name] = filepath.Base(path)
+		p.Source = path
+		prompts = append(prompts, p)
+	}
+	return prompts, nil
+}
+
+// Parse parses a prompt definition. defaultName is used if the header
+// doesn't set a name.
+func Parse(defaultName, text string) (*Prompt, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		return nil, fmt.Errorf("expected a header starting with ---")
+	}
+	header, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		return nil, fmt.Errorf("header is not closed with ---")
+	}
+
+	p := &Prompt{Name: defaultName}
+	scanner := bufio.NewScanner(strings.NewReader(header))
+	for lineNo := 2; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "name":
+			p.Name = value
+		case "description":
+			p.Description = value
+		case "argument":
+			arg, err := parseArgument(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			for _, existing := range p.Arguments {
+				if existing.Name == arg.Name {
+					return nil, fmt.Errorf("line %d: argument %q is already defined", lineNo, arg.Name)
+				}
+			}
+			p.Arguments = append(p.Arguments, arg)
+		case "tools":
+			for _, tool := range strings.Split(value, ",") {
+				if tool = strings.TrimSpace(tool); tool != "" {
+					p.Tools = append(p.Tools, tool)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q (expected name, description, argument or tools)", lineNo, key)
+		}
+	}
+
+	if !validName.MatchString(p.Name) {
+		return nil, fmt.Errorf("invalid prompt name %q: use lower case letters, digits and underscores", p.Name)
+	}
+	if p.Description == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("prompt body is empty")
+	}
+
+	tmpl, err := template.New(p.Name).Option("missingkey=zero").Parse(strings.TrimSpace(body))
+	if err != nil {
+		return nil, err
+	}
+	p.template = tmpl
+	return p, nil
+}
+
+// parseArgument parses "<name> [(required)] <description>"
+func parseArgument(value string) (Argument, error) {
+	name, rest, _ := strings.Cut(value, " ")
+	if !validName.MatchString(name) {
+		return Argument{}, fmt.Errorf("invalid argument name %q", name)
+	}
+
+	arg := Argument{Name: name}
+	rest = strings.TrimSpace(rest)
+	if description, ok := strings.CutPrefix(rest, "(required)"); ok {
+		arg.Required = true
+		rest = strings.TrimSpace(description)
+	}
+	arg.Description = rest
+	return arg, nil
+}
+
+// Render fills in the prompt's template with the given arguments
+func (p *Prompt) Render(arguments map[string]string) (string, error) {
+	values := make(map[string]string, len(p.Arguments))
+	for _, arg := range p.Arguments {
+		value := strings.TrimSpace(arguments[arg.Name])
+		if arg.Required && value == "" {
+			return "", fmt.Errorf("argument %s is required", arg.Name)
+		}
+		values[arg.Name] = value
+	}
+
+	var text strings.Builder
+	if err := p.template.Execute(&text, values); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", p.Name, err)
+	}
+	return text.String(), nil
+}
+
+// Register adds the prompts to the server, skipping any that need a tool
+// the server doesn't have
+func Register(s *server.MCPServer, prompts []*Prompt) {
+	for _, p := range prompts {
+		if missing := missingTools(s, p); len(missing) > 0 {
+			log.Printf("Skipping prompt %s: tools not available: %s", p.Name, strings.Join(missing, ", "))
+			continue
+		}
+
+		opts := []mcp.PromptOption{mcp.WithPromptDescription(p.Description)}
+		for _, arg := range p.Arguments {
+			argOpts := []mcp.ArgumentOption{mcp.ArgumentDescription(arg.Description)}
+			if arg.Required {
+				argOpts = append(argOpts, mcp.RequiredArgument())
+			}
+			opts = append(opts, mcp.WithArgument(arg.Name, argOpts...))
+		}
+		s.AddPrompt(mcp.NewPrompt(p.Name, opts...), p.handle)
+	}
+}
+
+// missingTools lists the tools a prompt needs that aren't registered
+func missingTools(s *server.MCPServer, p *Prompt) []string {
+	var missing []string
+	for _, tool := range p.Tools {
+		if s.GetTool(tool) == nil {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
+
+// handle serves prompts/get
+func (p *Prompt) handle(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text, err := p.Render(request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewGetPromptResult(p.Description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	}), nil
+}
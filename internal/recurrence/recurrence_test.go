@@ -0,0 +1,172 @@
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOccurrences(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		start   string
+		rule    string
+		exclude string
+		want    []string
+	}{
+		{
+			// British Summer Time ends on 26 October, and kick-off stays at 15:00
+			name:  "weekly across daylight saving",
+			start: "2025-10-18T15:00:00",
+			rule:  "RRULE:FREQ=WEEKLY;BYDAY=SA;COUNT=3",
+			want:  []string{"2025-10-18T14:00:00Z", "2025-10-25T14:00:00Z", "2025-11-01T15:00:00Z"},
+		},
+		{
+			name:    "bulletins on weekdays with exclusions",
+			start:   "2025-12-24T00:00:00",
+			rule:    "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;BYHOUR=9,18;UNTIL=20251229",
+			exclude: "2025-12-25, 20251226T090000",
+			want: []string{
+				"2025-12-24T09:00:00Z", "2025-12-24T18:00:00Z",
+				"2025-12-26T18:00:00Z",
+				"2025-12-29T09:00:00Z", "2025-12-29T18:00:00Z",
+			},
+		},
+		{
+			name:  "last Friday of the month",
+			start: "2025-10-01T20:00:00",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			want:  []string{"2025-10-31T20:00:00Z", "2025-11-28T20:00:00Z", "2025-12-26T20:00:00Z"},
+		},
+		{
+			name:  "month day missing from short months",
+			start: "2026-01-31T12:00:00",
+			rule:  "FREQ=MONTHLY;COUNT=3",
+			want:  []string{"2026-01-31T12:00:00Z", "2026-03-31T11:00:00Z", "2026-05-31T11:00:00Z"},
+		},
+		{
+			name:  "fortnightly on two days",
+			start: "2025-10-06T19:30:00",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=4",
+			want:  []string{"2025-10-06T18:30:00Z", "2025-10-09T18:30:00Z", "2025-10-20T18:30:00Z", "2025-10-23T18:30:00Z"},
+		},
+		{
+			// An excluded occurrence still uses up one of the COUNT
+			name:    "count includes exclusions",
+			start:   "2025-11-01T10:00:00",
+			rule:    "FREQ=DAILY;COUNT=3",
+			exclude: "2025-11-02T10:00:00Z",
+			want:    []string{"2025-11-01T10:00:00Z", "2025-11-03T10:00:00Z"},
+		},
+		{
+			name:  "yearly on a numbered weekday",
+			start: "2025-01-01T14:00:00",
+			rule:  "FREQ=YEARLY;BYMONTH=5;BYDAY=2SA;COUNT=2",
+			want:  []string{"2025-05-10T13:00:00Z", "2026-05-09T13:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule, london)
+			if err != nil {
+				t.Fatalf("ParseRule: %v", err)
+			}
+			exclude, err := ParseExclusions(tt.exclude, london)
+			if err != nil {
+				t.Fatalf("ParseExclusions: %v", err)
+			}
+			start, _, err := ParseDateTime(tt.start, london)
+			if err != nil {
+				t.Fatalf("ParseDateTime: %v", err)
+			}
+
+			set := Set{Start: start, Rule: rule, Exclude: exclude}
+			var got []string
+			for _, o := range set.Occurrences(start.AddDate(2, 0, 0), 100) {
+				got = append(got, o.UTC().Format(time.RFC3339))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got  %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Open-ended rules stop at the horizon and the limit
+func TestOccurrencesBounded(t *testing.T) {
+	rule, err := ParseRule("FREQ=DAILY", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 10, 1, 18, 0, 0, 0, time.UTC)
+	set := Set{Start: start, Rule: rule}
+
+	if got := len(set.Occurrences(start.AddDate(0, 0, 7), 100)); got != 7 {
+		t.Errorf("a week of a daily rule has %d occurrences, want 7", got)
+	}
+	if got := len(set.Occurrences(start.AddDate(1, 0, 0), 10)); got != 10 {
+		t.Errorf("limited to 10, got %d occurrences", got)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	for _, s := range []string{
+		"FREQ=WEEKLY;BYDAY=SA",
+		"FREQ=DAILY;COUNT=10;BYHOUR=9,13,18;BYMINUTE=0,30",
+		"FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=1,-1",
+		"FREQ=YEARLY;UNTIL=20301231T235959Z;BYMONTH=5;BYDAY=2SA;WKST=SU",
+	} {
+		rule, err := ParseRule(s, time.UTC)
+		if err != nil {
+			t.Errorf("ParseRule(%s): %v", s, err)
+			continue
+		}
+		if got := rule.String(); got != s {
+			t.Errorf("ParseRule(%s).String() = %s", s, got)
+		}
+	}
+
+	for s, want := range map[string]string{
+		"":                                   "empty",
+		"BYDAY=MO":                           "FREQ",
+		"FREQ=HOURLY":                        "not supported",
+		"FREQ=WEEKLY;BYSETPOS=1":             "not supported",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20251231": "not both",
+		"FREQ=WEEKLY;BYDAY=2SA":              "MONTHLY or YEARLY",
+		"FREQ=YEARLY;BYDAY=1MO":              "needs BYMONTH",
+		"FREQ=MONTHLY;BYDAY=XX":              "day code",
+		"FREQ=DAILY;INTERVAL=0":              "INTERVAL",
+		"FREQ=DAILY;FREQ=WEEKLY":             "more than once",
+		"FREQ=DAILY;COLOUR=RED":              "unknown",
+		"FREQ=WEEKLY;BYMONTHDAY=1":           "WEEKLY",
+	} {
+		_, err := ParseRule(s, time.UTC)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseRule(%q) = %v, want an error mentioning %q", s, err, want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"01:30:00": 90 * time.Minute,
+		"2:00":     2 * time.Hour,
+		"-00:15":   -15 * time.Minute,
+		"+45m":     45 * time.Minute,
+		"1h30m":    90 * time.Minute,
+	} {
+		if got, err := ParseDuration(s); err != nil || got != want {
+			t.Errorf("ParseDuration(%s) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "01:60", "soon"} {
+		if _, err := ParseDuration(s); err == nil {
+			t.Errorf("ParseDuration(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+// Package recurrence parses iCalendar (RFC 5545) recurrence rules and
+// expands them into concrete occurrences.
+//
+// A Set anchors a Rule at a start time in a time zone and excludes any
+// EXDATEs. Occurrences keep the start's wall-clock time, so a 19:00 bulletin
+// stays at 19:00 local time across daylight saving changes.
+//
+// The rules schedules need are supported: FREQ of DAILY, WEEKLY, MONTHLY or
+// YEARLY with INTERVAL, COUNT, UNTIL, BYDAY (with ordinals such as 2SA or
+// -1FR for monthly and yearly rules), BYMONTHDAY, BYMONTH, BYHOUR, BYMINUTE
+// and WKST. Sub-daily frequencies, BYSETPOS, BYYEARDAY, BYWEEKNO and
+// BYSECOND are rejected rather than approximated.
+package recurrence
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the period a rule repeats over
+type Frequency string
+
+// Supported frequencies
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// weekdays maps iCalendar day codes to weekdays
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// dayCodes is weekdays inverted
+var dayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// byDayPattern matches a BYDAY entry such as MO, 2SA or -1FR
+var byDayPattern = regexp.MustCompile(`^([+-]?\d{1,2})?(SU|MO|TU|WE|TH|FR|SA)$`)
+
+// WeekdayNum is a BYDAY entry: a weekday, and for monthly and yearly rules
+// optionally which one in the month (1 is the first, -1 the last). N is 0
+// for every such weekday.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// String formats the entry as in a rule, e.g. -1FR
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return dayCodes[w.Weekday]
+	}
+	return strconv.Itoa(w.N) + dayCodes[w.Weekday]
+}
+
+// Rule is a parsed RRULE
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count limits the rule to this many occurrences (0 = no limit)
+	Count int
+	// Until is the last time an occurrence may start (zero = no limit)
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	ByHour     []int
+	ByMinute   []int
+	WeekStart  time.Weekday
+}
+
+// ParseRule parses an RRULE such as FREQ=WEEKLY;BYDAY=SA;COUNT=10, with or
+// without its "RRULE:" prefix. A floating or date-only UNTIL is read in loc.
+func ParseRule(s string, loc *time.Location) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return Rule{}, fmt.Errorf("rule is empty")
+	}
+
+	r := Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || key == "" || value == "" {
+			return Rule{}, fmt.Errorf("rule part %q must be NAME=VALUE", part)
+		}
+		if seen[key] {
+			return Rule{}, fmt.Errorf("rule part %s appears more than once", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			err = r.parseFreq(value)
+		case "INTERVAL":
+			r.Interval, err = parseInt(key, value, 1, 1000)
+		case "COUNT":
+			r.Count, err = parseInt(key, value, 1, 10000)
+		case "UNTIL":
+			var allDay bool
+			r.Until, allDay, err = ParseDateTime(value, loc)
+			if allDay {
+				// A date-only UNTIL includes the whole of that day
+				r.Until = r.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(key, value, -31, 31, false)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(key, value, 1, 12, false)
+			for _, m := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "BYHOUR":
+			r.ByHour, err = parseInts(key, value, 0, 23, true)
+		case "BYMINUTE":
+			r.ByMinute, err = parseInts(key, value, 0, 59, true)
+		case "WKST":
+			day, ok := weekdays[value]
+			if !ok {
+				err = fmt.Errorf("WKST must be a day code such as MO, not %q", value)
+			}
+			r.WeekStart = day
+		case "BYSETPOS", "BYYEARDAY", "BYWEEKNO", "BYSECOND":
+			err = fmt.Errorf("%s is not supported", key)
+		default:
+			err = fmt.Errorf("unknown rule part %s", key)
+		}
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+	if err := r.check(); err != nil {
+		return Rule{}, err
+	}
+
+	slices.Sort(r.ByHour)
+	slices.Sort(r.ByMinute)
+	return r, nil
+}
+
+// parseFreq sets the frequency, refusing ones finer than a day
+func (r *Rule) parseFreq(value string) error {
+	switch Frequency(value) {
+	case Daily, Weekly, Monthly, Yearly:
+		r.Freq = Frequency(value)
+		return nil
+	case "SECONDLY", "MINUTELY", "HOURLY":
+		return fmt.Errorf("FREQ=%s is not supported; use FREQ=DAILY with BYHOUR for several occurrences a day", value)
+	}
+	return fmt.Errorf("FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY, not %q", value)
+}
+
+// check validates the combination of parts
+func (r Rule) check() error {
+	switch {
+	case r.Freq == "":
+		return fmt.Errorf("rule must have a FREQ")
+	case r.Count > 0 && !r.Until.IsZero():
+		return fmt.Errorf("rule may have COUNT or UNTIL, not both")
+	case r.Freq == Weekly && len(r.ByMonthDay) > 0:
+		return fmt.Errorf("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	}
+	for _, day := range r.ByDay {
+		switch {
+		case day.N == 0:
+		case r.Freq != Monthly && r.Freq != Yearly:
+			return fmt.Errorf("BYDAY=%s: a numbered weekday needs FREQ=MONTHLY or YEARLY", day)
+		case r.Freq == Yearly && len(r.ByMonth) == 0:
+			return fmt.Errorf("BYDAY=%s: a numbered weekday in a yearly rule needs BYMONTH", day)
+		case day.N < -5 || day.N > 5:
+			return fmt.Errorf("BYDAY=%s: a weekday can only be numbered -5 to 5 within a month", day)
+		}
+	}
+	return nil
+}
+
+// String formats the rule in canonical order, without the RRULE: prefix.
+// UNTIL is written in UTC.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	list := func(name string, n int, item func(int) string) {
+		if n == 0 {
+			return
+		}
+		items := make([]string, n)
+		for i := range items {
+			items[i] = item(i)
+		}
+		parts = append(parts, name+"="+strings.Join(items, ","))
+	}
+	list("BYMONTH", len(r.ByMonth), func(i int) string { return strconv.Itoa(int(r.ByMonth[i])) })
+	list("BYMONTHDAY", len(r.ByMonthDay), func(i int) string { return strconv.Itoa(r.ByMonthDay[i]) })
+	list("BYDAY", len(r.ByDay), func(i int) string { return r.ByDay[i].String() })
+	list("BYHOUR", len(r.ByHour), func(i int) string { return strconv.Itoa(r.ByHour[i]) })
+	list("BYMINUTE", len(r.ByMinute), func(i int) string { return strconv.Itoa(r.ByMinute[i]) })
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+dayCodes[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+// parseByDay parses a BYDAY list such as MO,WE,FR or 1SA,-1SA
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		m := byDayPattern.FindStringSubmatch(strings.TrimSpace(item))
+		if m == nil {
+			return nil, fmt.Errorf("BYDAY entry %q must be a day code such as MO, optionally numbered as in 2SA or -1FR", item)
+		}
+		day := WeekdayNum{Weekday: weekdays[m[2]]}
+		if m[1] != "" {
+			day.N, _ = strconv.Atoi(m[1])
+			if day.N == 0 {
+				return nil, fmt.Errorf("BYDAY entry %q can't be numbered 0", item)
+			}
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// parseInts parses a comma-separated list of integers in [lo, hi]. Zero is
+// refused unless zeroOK is set.
+func parseInts(key, value string, lo, hi int, zeroOK bool) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n < lo || n > hi || (n == 0 && !zeroOK) {
+			return nil, fmt.Errorf("%s values must be whole numbers from %d to %d, not %q", key, lo, hi, item)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// parseInt parses an integer in [lo, hi]
+func parseInt(key, value string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%s must be a whole number from %d to %d, not %q", key, lo, hi, value)
+	}
+	return n, nil
+}
+
+// dateTimeLayouts are the layouts ParseDateTime accepts, and whether each is
+// a date alone
+var dateTimeLayouts = []struct {
+	layout string
+	allDay bool
+}{
+	{"20060102T150405Z", false},
+	{"20060102T150405", false},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02T15:04", false},
+	{"20060102", true},
+	{"2006-01-02", true},
+}
+
+// ParseDateTime parses a time written in ISO 8601 or iCalendar form. A time
+// with a zone or offset, such as 2025-10-04T15:00:00Z or 20251004T150000Z,
+// is converted to loc; a floating one, such as 2025-10-04T15:00:00 or
+// 20251004T150000, is read as wall-clock time in loc. A date alone, such as
+// 2025-12-25 or 20251225, is midnight in loc and reported as allDay.
+func ParseDateTime(s string, loc *time.Location) (t time.Time, allDay bool, err error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(loc), false, nil
+	}
+	for _, l := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(l.layout, strings.ToUpper(s), loc); err == nil {
+			if strings.HasSuffix(l.layout, "Z") {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC).In(loc)
+			}
+			return t, l.allDay, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("%q is not a date or time; use e.g. 2025-10-04T15:00:00, 2025-10-04T14:00:00Z or 2025-10-04", s)
+}
+
+// clockDuration matches a duration written as [+-]HH:MM or [+-]HH:MM:SS
+var clockDuration = regexp.MustCompile(`^([+-]?)(\d+):(\d{2})(?::(\d{2}))?$`)
+
+// ParseDuration parses a length or shift written as HH:MM[:SS], such as
+// 01:30:00, or as a duration such as 90m. Either may be signed.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	m := clockDuration.FindStringSubmatch(s)
+	if m == nil {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("%q must be HH:MM:SS or a duration such as 90m", s)
+		}
+		return d, nil
+	}
+
+	hours, _ := strconv.Atoi(m[2])
+	minutes, _ := strconv.Atoi(m[3])
+	seconds, _ := strconv.Atoi(m[4])
+	if minutes > 59 || seconds > 59 {
+		return 0, fmt.Errorf("%q: minutes and seconds must be below 60", s)
+	}
+	d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,219 @@
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Exclusion is an EXDATE: one occurrence, or every occurrence on a date
+type Exclusion struct {
+	Time   time.Time
+	AllDay bool
+}
+
+// ParseExclusions parses a comma-separated list of EXDATEs in loc. Each is
+// a time, which excludes the occurrence starting then, or a date, which
+// excludes every occurrence that day.
+func ParseExclusions(s string, loc *time.Location) ([]Exclusion, error) {
+	var exclusions []Exclusion
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		t, allDay, err := ParseDateTime(item, loc)
+		if err != nil {
+			return nil, fmt.Errorf("exclusion: %v", err)
+		}
+		exclusions = append(exclusions, Exclusion{Time: t, AllDay: allDay})
+	}
+	return exclusions, nil
+}
+
+// Matches reports whether the exclusion removes an occurrence starting at t
+func (e Exclusion) Matches(t time.Time) bool {
+	if !e.AllDay {
+		return e.Time.Equal(t)
+	}
+	y, m, d := t.In(e.Time.Location()).Date()
+	ey, em, ed := e.Time.Date()
+	return y == ey && m == em && d == ed
+}
+
+// Set is a recurrence set: a rule anchored at a start time, less any
+// exclusions. Occurrences are in Start's location.
+type Set struct {
+	Start   time.Time
+	Rule    Rule
+	Exclude []Exclusion
+}
+
+// Occurrences returns up to limit start times the set generates before end,
+// in order. Only times that match the rule are generated, so Start itself is
+// included only if it does. Excluded occurrences still count towards the
+// rule's COUNT, as RFC 5545 requires.
+func (s Set) Occurrences(end time.Time, limit int) []time.Time {
+	var out []time.Time
+	s.Rule.each(s.Start, end, func(t time.Time) bool {
+		if !slices.ContainsFunc(s.Exclude, func(e Exclusion) bool { return e.Matches(t) }) {
+			out = append(out, t)
+		}
+		return len(out) < limit
+	})
+	return out
+}
+
+// each calls fn with each occurrence of the rule from dtstart, in order,
+// until fn returns false, the rule is exhausted or an occurrence would start
+// at or after end
+func (r Rule) each(dtstart, end time.Time, fn func(time.Time) bool) {
+	loc := dtstart.Location()
+	year, month, day := dtstart.Date()
+	weekStart := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	weekStart = weekStart.AddDate(0, 0, -((int(weekStart.Weekday()) - int(r.WeekStart) + 7) % 7))
+
+	count := 0
+	for period := 0; ; period++ {
+		step := period * r.Interval
+		var first time.Time
+		var dates []time.Time
+		switch r.Freq {
+		case Daily:
+			first = time.Date(year, month, day+step, 0, 0, 0, 0, loc)
+			if r.matchesDay(first, false) {
+				dates = append(dates, first)
+			}
+		case Weekly:
+			first = weekStart.AddDate(0, 0, 7*step)
+			for i := 0; i < 7; i++ {
+				if date := first.AddDate(0, 0, i); r.matchesWeekday(date, dtstart) {
+					dates = append(dates, date)
+				}
+			}
+		case Monthly:
+			first = time.Date(year, month+time.Month(step), 1, 0, 0, 0, 0, loc)
+			dates = r.monthDates(first, day)
+		case Yearly:
+			first = time.Date(year+step, 1, 1, 0, 0, 0, 0, loc)
+			months := r.ByMonth
+			switch {
+			case len(months) > 0:
+			case len(r.ByDay) > 0 || len(r.ByMonthDay) > 0:
+				months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			default:
+				months = []time.Month{month}
+			}
+			for _, m := range slices.Sorted(slices.Values(months)) {
+				dates = append(dates, r.monthDates(time.Date(year+step, m, 1, 0, 0, 0, 0, loc), day)...)
+			}
+		}
+		if !first.Before(end) || (!r.Until.IsZero() && first.After(r.Until)) {
+			return
+		}
+
+		for _, date := range dates {
+			for _, t := range r.times(date, dtstart) {
+				if t.Before(dtstart) {
+					continue
+				}
+				if !t.Before(end) || (!r.Until.IsZero() && t.After(r.Until)) {
+					return
+				}
+				if !fn(t) {
+					return
+				}
+				if count++; r.Count > 0 && count >= r.Count {
+					return
+				}
+			}
+		}
+	}
+}
+
+// monthDates returns the dates in the month starting at first that the
+// rule picks. With no BYDAY or BYMONTHDAY that is the start's day of the
+// month, skipping months too short to have it.
+func (r Rule) monthDates(first time.Time, startDay int) []time.Time {
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, first.Month()) {
+		return nil
+	}
+	days := first.AddDate(0, 1, -1).Day()
+	var dates []time.Time
+	for d := 1; d <= days; d++ {
+		date := first.AddDate(0, 0, d-1)
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			if d == startDay {
+				dates = append(dates, date)
+			}
+			continue
+		}
+		if r.matchesDay(date, true) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// matchesDay reports whether date passes the rule's BYMONTH, BYMONTHDAY and
+// BYDAY filters. numbered allows BYDAY entries such as 2SA, counted within
+// the month.
+func (r Rule) matchesDay(date time.Time, numbered bool) bool {
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, date.Month()) {
+		return false
+	}
+	days := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location()).Day()
+	if len(r.ByMonthDay) > 0 && !slices.Contains(r.ByMonthDay, date.Day()) && !slices.Contains(r.ByMonthDay, date.Day()-days-1) {
+		return false
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday != date.Weekday() {
+			continue
+		}
+		switch {
+		case wd.N == 0:
+			return true
+		case !numbered:
+		case wd.N > 0 && (date.Day()-1)/7+1 == wd.N:
+			return true
+		case wd.N < 0 && -((days-date.Day())/7+1) == wd.N:
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday reports whether date is one of a weekly rule's days: its
+// BYDAY weekdays, or the start's weekday if it has none
+func (r Rule) matchesWeekday(date, dtstart time.Time) bool {
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, date.Month()) {
+		return false
+	}
+	if len(r.ByDay) == 0 {
+		return date.Weekday() == dtstart.Weekday()
+	}
+	return slices.ContainsFunc(r.ByDay, func(wd WeekdayNum) bool { return wd.Weekday == date.Weekday() })
+}
+
+// times returns the occurrences on date: at each BYHOUR and BYMINUTE, which
+// default to the start's hour and minute. The wall-clock time is kept across
+// daylight saving changes.
+func (r Rule) times(date, dtstart time.Time) []time.Time {
+	hours, minutes := r.ByHour, r.ByMinute
+	if len(hours) == 0 {
+		hours = []int{dtstart.Hour()}
+	}
+	if len(minutes) == 0 {
+		minutes = []int{dtstart.Minute()}
+	}
+	var times []time.Time
+	for _, h := range hours {
+		for _, m := range minutes {
+			times = append(times, time.Date(date.Year(), date.Month(), date.Day(), h, m, dtstart.Second(), 0, date.Location()))
+		}
+	}
+	return times
+}
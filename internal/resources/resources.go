@@ -0,0 +1,354 @@
+// Package resources exposes M2A entities as MCP resources: one collection
+// resource per entity type (m2a://live/channels) and a template for single
+// entities (m2a://live/channels/{id}). Clients can subscribe to any of them;
+// subscribed resources are polled and a notifications/resources/updated is
+// sent to each subscriber when one changes.
+package resources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
+)
+
+const (
+	scheme   = "m2a://"
+	mimeType = "application/json"
+
+	// maxCollectionItems bounds how many items a collection resource returns
+	maxCollectionItems = 500
+
+	// absent is the fingerprint of a resource that doesn't exist
+	absent = "absent"
+	// unread is the fingerprint of a subscribed resource that couldn't be
+	// read yet. The next poll that reads it records its content without
+	// notifying anyone.
+	unread = ""
+)
+
+// kind is one type of entity, e.g. live/channels
+type kind struct {
+	path        string
+	name        string
+	description string
+	list        func(ctx context.Context) (interface{}, error)
+	get         func(ctx context.Context, id string) (interface{}, error)
+}
+
+// lister adapts a typed service list method
+func lister[T any](list func(context.Context, m2a.ListOptions) ([]T, error)) func(context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		items, err := list(ctx, m2a.ListOptions{MaxItems: maxCollectionItems})
+		if err != nil {
+			return nil, err
+		}
+		result := map[string]interface{}{"items": items, "total": len(items)}
+		if items == nil {
+			result["items"] = []T{}
+		}
+		if len(items) >= maxCollectionItems {
+			result["truncated"] = true
+		}
+		return result, nil
+	}
+}
+
+// getter adapts a typed service get method
+func getter[T any](get func(context.Context, string) (*T, error)) func(context.Context, string) (interface{}, error) {
+	return func(ctx context.Context, id string) (interface{}, error) {
+		return get(ctx, id)
+	}
+}
+
+// Resources serves M2A entities as MCP resources and tracks subscriptions
+// to them
+type Resources struct {
+	kinds        map[string]kind
+	pollInterval time.Duration
+	mcpServer    *server.MCPServer
+
+	mu sync.Mutex
+	// sessions holds the IDs of connected sessions
+	sessions map[string]bool
+	// subscriptions maps session ID to the set of URIs it subscribed to
+	subscriptions map[string]map[string]bool
+	// fingerprints holds the last seen content hash of each subscribed URI
+	fingerprints map[string]string
+}
+
+// New creates the resources for an M2A client. Subscribed resources are
+// polled every pollInterval; zero disables change notifications.
+func New(c *client.M2AClient, pollInterval time.Duration) *Resources {
+	connect := m2a.NewConnectService(c)
+	live := m2a.NewLiveService(c)
+	capture := m2a.NewCaptureService(c)
+	vod := m2a.NewVODService(c)
+
+	r := &Resources{
+		kinds:         make(map[string]kind),
+		pollInterval:  pollInterval,
+		sessions:      make(map[string]bool),
+		subscriptions: make(map[string]map[string]bool),
+		fingerprints:  make(map[string]string),
+	}
+	for _, k := range []kind{
+		{"connect/sources", "Sources", "Video sources in M2A Connect", lister(connect.ListSources), getter(connect.GetSource)},
+		{"connect/subscribers", "Subscribers", "Subscribers in M2A Connect", lister(connect.ListSubscribers), getter(connect.GetSubscriber)},
+		{"connect/subscriptions", "Subscriptions", "Subscription packages in M2A Connect", lister(connect.ListSubscriptions), getter(connect.GetSubscription)},
+		{"connect/schedules", "Schedules", "Scheduled events in M2A Connect", lister(connect.ListSchedules), getter(connect.GetSchedule)},
+		{"live/channels", "Channels", "MediaLive channels and their current state", lister(live.ListChannels), getter(live.GetChannel)},
+		{"live/encoder-configs", "Encoder configurations", "Encoder configuration fragments", lister(live.ListEncoderConfigs), getter(live.GetEncoderConfig)},
+		{"live/workflows", "Workflows", "Live streaming workflows", lister(live.ListWorkflows), getter(live.GetWorkflow)},
+		{"capture/captures", "Captures", "Live-to-VOD capture jobs and their status", lister(capture.ListCaptures), getter(capture.GetCapture)},
+		{"capture/exports", "Capture exports", "VOD exports from completed captures", lister(capture.ListExports), getter(capture.GetExport)},
+		{"vod/assets", "VOD assets", "Video on demand assets", lister(vod.ListAssets), getter(vod.GetAsset)},
+	} {
+		r.kinds[k.path] = k
+	}
+	return r
+}
+
+// Register adds a collection resource and an entity template for every
+// kind of entity
+func (r *Resources) Register(s *server.MCPServer) {
+	r.mcpServer = s
+
+	paths := make([]string, 0, len(r.kinds))
+	for path := range r.kinds {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		k := r.kinds[path]
+		s.AddResource(mcp.NewResource(scheme+path, k.name,
+			mcp.WithResourceDescription(fmt.Sprintf("All %s (up to %d)", strings.ToLower(k.description[:1])+k.description[1:], maxCollectionItems)),
+			mcp.WithMIMEType(mimeType),
+		), r.readResource)
+		s.AddResourceTemplate(mcp.NewResourceTemplate(scheme+path+"/{id}", strings.TrimSuffix(k.name, "s"),
+			mcp.WithTemplateDescription(fmt.Sprintf("One of the %s, by ID", strings.ToLower(k.name))),
+			mcp.WithTemplateMIMEType(mimeType),
+		), r.readResource)
+	}
+}
+
+// readResource serves resources/read for any M2A resource
+func (r *Resources) readResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	v, err := r.read(ctx, request.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: request.Params.URI, MIMEType: mimeType, Text: string(data)},
+	}, nil
+}
+
+// read fetches the entity or collection a URI names
+func (r *Resources) read(ctx context.Context, uri string) (interface{}, error) {
+	k, id, err := r.parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return k.list(ctx)
+	}
+	return k.get(ctx, id)
+}
+
+// parse splits a URI into its kind and, for single entities, the ID
+func (r *Resources) parse(uri string) (kind, string, error) {
+	path, ok := strings.CutPrefix(uri, scheme)
+	if !ok {
+		return kind{}, "", fmt.Errorf("unsupported resource URI %q: expected %s...", uri, scheme)
+	}
+	if k, ok := r.kinds[path]; ok {
+		return k, "", nil
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return kind{}, "", fmt.Errorf("unknown resource %q", uri)
+	}
+	k, ok := r.kinds[path[:i]]
+	if !ok || path[i+1:] == "" {
+		return kind{}, "", fmt.Errorf("unknown resource %q", uri)
+	}
+	return k, path[i+1:], nil
+}
+
+// Subscribe starts sending sessionID updates about uri
+func (r *Resources) Subscribe(ctx context.Context, sessionID, uri string) error {
+	if _, _, err := r.parse(uri); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if !r.sessions[sessionID] {
+		r.mu.Unlock()
+		return fmt.Errorf("session %q can't receive notifications; connect before subscribing", sessionID)
+	}
+	if r.subscriptions[sessionID] == nil {
+		r.subscriptions[sessionID] = make(map[string]bool)
+	}
+	r.subscriptions[sessionID][uri] = true
+	_, known := r.fingerprints[uri]
+	if !known {
+		// Polled from now on, even if the read below fails
+		r.fingerprints[uri] = unread
+	}
+	r.mu.Unlock()
+
+	// Record the current content, so the first poll only reports real changes
+	if !known {
+		if fingerprint, ok := r.fingerprint(ctx, uri); ok {
+			r.mu.Lock()
+			if previous, known := r.fingerprints[uri]; known && previous == unread {
+				r.fingerprints[uri] = fingerprint
+			}
+			r.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// Unsubscribe stops sending sessionID updates about uri
+func (r *Resources) Unsubscribe(sessionID, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.subscriptions[sessionID], uri)
+	if len(r.subscriptions[sessionID]) == 0 {
+		delete(r.subscriptions, sessionID)
+	}
+	r.prune()
+}
+
+// Forget drops every subscription held by a session that has gone away
+func (r *Resources) Forget(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, sessionID)
+	delete(r.subscriptions, sessionID)
+	r.prune()
+}
+
+// prune drops fingerprints of URIs nobody is subscribed to. The caller must
+// hold r.mu.
+func (r *Resources) prune() {
+	for uri := range r.fingerprints {
+		if len(r.subscribers(uri)) == 0 {
+			delete(r.fingerprints, uri)
+		}
+	}
+}
+
+// subscribers lists the sessions subscribed to uri. The caller must hold r.mu.
+func (r *Resources) subscribers(uri string) []string {
+	var sessions []string
+	for sessionID, uris := range r.subscriptions {
+		if uris[uri] {
+			sessions = append(sessions, sessionID)
+		}
+	}
+	sort.Strings(sessions)
+	return sessions
+}
+
+// Run polls subscribed resources until ctx is cancelled
+func (r *Resources) Run(ctx context.Context) {
+	if r.pollInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.poll(ctx)
+		}
+	}
+}
+
+// poll reads every subscribed resource once and notifies the subscribers
+// of any that changed
+func (r *Resources) poll(ctx context.Context) {
+	r.mu.Lock()
+	uris := make([]string, 0, len(r.fingerprints))
+	for uri := range r.fingerprints {
+		uris = append(uris, uri)
+	}
+	r.mu.Unlock()
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		fingerprint, ok := r.fingerprint(ctx, uri)
+		if !ok {
+			continue
+		}
+
+		r.mu.Lock()
+		previous, known := r.fingerprints[uri]
+		var sessions []string
+		if known && previous != fingerprint {
+			r.fingerprints[uri] = fingerprint
+			if previous != unread {
+				sessions = r.subscribers(uri)
+			}
+		}
+		r.mu.Unlock()
+
+		for _, sessionID := range sessions {
+			err := r.mcpServer.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+			if err != nil {
+				log.Printf("Failed to notify session %s that %s changed: %v", sessionID, uri, err)
+			}
+		}
+	}
+}
+
+// fingerprint hashes a resource's current content. ok is false if it
+// couldn't be read for a reason other than the resource not existing.
+func (r *Resources) fingerprint(ctx context.Context, uri string) (string, bool) {
+	if r.pollInterval > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, max(r.pollInterval, 10*time.Second))
+		defer cancel()
+	}
+
+	v, err := r.read(ctx, uri)
+	if errors.Is(err, client.ErrNotFound) {
+		return absent, true
+	}
+	if err != nil {
+		return "", false
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
@@ -0,0 +1,84 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// The MCP library answers resources/subscribe and resources/unsubscribe with
+// "method not found", so the transports pass those messages to HandleMessage
+// before handing everything else to the MCP server.
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+)
+
+// Hooks tracks which sessions are connected, so subscriptions are only
+// accepted from sessions that can receive notifications and are dropped
+// when the session ends
+func (r *Resources) Hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		r.mu.Lock()
+		r.sessions[session.SessionID()] = true
+		r.mu.Unlock()
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		r.Forget(session.SessionID())
+	})
+	return hooks
+}
+
+// HandleMessage answers a resources/subscribe or resources/unsubscribe
+// request from sessionID. handled is false for any other message, which the
+// caller should pass on to the MCP server unchanged.
+func (r *Resources) HandleMessage(ctx context.Context, sessionID string, raw []byte) (response []byte, handled bool) {
+	var message struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(raw, &message); err != nil {
+		return nil, false
+	}
+	if message.Method != methodSubscribe && message.Method != methodUnsubscribe {
+		return nil, false
+	}
+
+	var err error
+	switch {
+	case message.Params.URI == "":
+		err = fmt.Errorf("uri is required")
+	case message.Method == methodUnsubscribe:
+		r.Unsubscribe(sessionID, message.Params.URI)
+	default:
+		err = r.Subscribe(ctx, sessionID, message.Params.URI)
+	}
+
+	if err != nil {
+		return reply(message.ID, "error", map[string]interface{}{
+			"code":    mcp.INVALID_PARAMS,
+			"message": err.Error(),
+		}), true
+	}
+	return reply(message.ID, "result", struct{}{}), true
+}
+
+// reply encodes a JSON-RPC response, echoing the request ID verbatim
+func reply(id json.RawMessage, key string, value interface{}) []byte {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      id,
+		key:       value,
+	})
+	return data
+}
@@ -0,0 +1,106 @@
+package timecode
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is a frame rate, expressed exactly as Num/Den frames per second
+type Rate struct {
+	Num int64
+	Den int64
+	// DropFrame selects drop-frame timecode, which skips frame numbers so
+	// that timecode keeps pace with the clock at 29.97 and 59.94 fps
+	DropFrame bool
+}
+
+// Supported frame rates
+var (
+	Rate23976   = Rate{Num: 24000, Den: 1001}
+	Rate24      = Rate{Num: 24, Den: 1}
+	Rate25      = Rate{Num: 25, Den: 1}
+	Rate2997DF  = Rate{Num: 30000, Den: 1001, DropFrame: true}
+	Rate2997NDF = Rate{Num: 30000, Den: 1001}
+	Rate30      = Rate{Num: 30, Den: 1}
+	Rate50      = Rate{Num: 50, Den: 1}
+	Rate5994DF  = Rate{Num: 60000, Den: 1001, DropFrame: true}
+	Rate5994NDF = Rate{Num: 60000, Den: 1001}
+	Rate60      = Rate{Num: 60, Den: 1}
+)
+
+// rateNames maps the accepted spellings of each rate
+var rateNames = map[string]Rate{
+	"23.976": Rate23976, "23.98": Rate23976, "24000/1001": Rate23976,
+	"24":    Rate24,
+	"25":    Rate25,
+	"29.97": Rate2997DF, "29.97df": Rate2997DF, "30000/1001": Rate2997DF,
+	"29.97ndf": Rate2997NDF,
+	"30":       Rate30,
+	"50":       Rate50,
+	"59.94":    Rate5994DF, "59.94df": Rate5994DF, "60000/1001": Rate5994DF,
+	"59.94ndf": Rate5994NDF,
+	"60":       Rate60,
+}
+
+// ParseRate parses a frame rate such as "25", "23.976" or "29.97df". The
+// NTSC rates 29.97 and 59.94 are drop-frame unless written with "ndf".
+func ParseRate(s string) (Rate, error) {
+	if r, ok := rateNames[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return r, nil
+	}
+	return Rate{}, fmt.Errorf("unsupported frame rate %q: expected 23.976, 24, 25, 29.97, 30, 50, 59.94 or 60", s)
+}
+
+// String formats the rate as it is usually written, e.g. "29.97df"
+func (r Rate) String() string {
+	if r.Den == 1 {
+		return strconv.FormatInt(r.Num, 10)
+	}
+	s := strconv.FormatFloat(float64(r.Num)/float64(r.Den), 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if r.Num%30000 == 0 {
+		if r.DropFrame {
+			return s + "df"
+		}
+		return s + "ndf"
+	}
+	return s
+}
+
+// FPS returns the rate in frames per second
+func (r Rate) FPS() float64 {
+	return float64(r.Num) / float64(r.Den)
+}
+
+// Nominal is the number of frames counted per timecode second, e.g. 30 for
+// 29.97 fps
+func (r Rate) Nominal() int64 {
+	return (r.Num + r.Den - 1) / r.Den
+}
+
+// dropped is how many frame numbers drop-frame timecode skips at the start
+// of each minute not divisible by ten
+func (r Rate) dropped() int64 {
+	if !r.DropFrame {
+		return 0
+	}
+	return r.Nominal() / 15
+}
+
+// Duration is the offset at which frame n starts, rounded up to the next
+// nanosecond so that Frames(Duration(n)) == n
+func (r Rate) Duration(frames int64) time.Duration {
+	ns := new(big.Int).Mul(big.NewInt(frames), big.NewInt(r.Den*int64(time.Second)))
+	ns.Add(ns, big.NewInt(r.Num-1))
+	return time.Duration(ns.Quo(ns, big.NewInt(r.Num)).Int64())
+}
+
+// Frames is the number of the frame showing at offset d, i.e. how many
+// whole frames have started before it. d must not be negative.
+func (r Rate) Frames(d time.Duration) int64 {
+	n := new(big.Int).Mul(big.NewInt(int64(d)), big.NewInt(r.Num))
+	return n.Quo(n, big.NewInt(r.Den*int64(time.Second))).Int64()
+}
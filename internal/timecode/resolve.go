@@ -0,0 +1,81 @@
+package timecode
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockOffset matches an offset written as HH:MM:SS with optional fractional
+// seconds
+var clockOffset = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2}(?:\.\d+)?)$`)
+
+// IsTimecode reports whether s is written as a timecode rather than a time
+// or offset
+func IsTimecode(s string) bool {
+	return pattern.MatchString(s)
+}
+
+// Resolve finds the timecode of a position in a recording that started at
+// start and is length long. s may be:
+//
+//   - a timecode, HH:MM:SS:FF
+//   - an ISO 8601 time, e.g. 2025-10-01T15:42:10Z
+//   - an offset from the start, e.g. +00:12:30 or +90s
+//   - an offset back from the end, e.g. -00:01:30 or -90s
+//
+// Offsets are written HH:MM:SS[.fff] or as a duration such as 1m30s.
+func Resolve(s string, start time.Time, length time.Duration, r Rate) (Timecode, error) {
+	s = strings.TrimSpace(s)
+	if IsTimecode(s) {
+		return Parse(s, r)
+	}
+
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		d, err := parseOffset(s[1:])
+		if err != nil {
+			return Timecode{}, fmt.Errorf("offset %q: %v", s, err)
+		}
+		if s[0] == '+' {
+			return FromOffset(d, r)
+		}
+		if length <= 0 {
+			return Timecode{}, fmt.Errorf("offset %q is from the end, but the length of the recording is unknown", s)
+		}
+		if d > length {
+			return Timecode{}, fmt.Errorf("offset %q is before the start of the recording, which is %s long", s, length)
+		}
+		return FromOffset(length-d, r)
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return Timecode{}, fmt.Errorf("%q is not a timecode (HH:MM:SS:FF), ISO 8601 time or offset (+00:12:30, -90s)", s)
+	}
+	if start.IsZero() {
+		return Timecode{}, fmt.Errorf("time %s can't be converted: the start of the recording is unknown", s)
+	}
+	return FromTime(t, start, r)
+}
+
+// parseOffset parses HH:MM:SS[.fff] or a duration such as 90s
+func parseOffset(s string) (time.Duration, error) {
+	m := clockOffset.FindStringSubmatch(s)
+	if m == nil {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("must be HH:MM:SS or a duration such as 90s")
+		}
+		return d, nil
+	}
+
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	seconds, _ := time.ParseDuration(m[3] + "s")
+	if minutes > 59 || seconds >= time.Minute {
+		return 0, fmt.Errorf("minutes and seconds must be below 60")
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + seconds, nil
+}
@@ -0,0 +1,131 @@
+// Package timecode parses, validates and converts SMPTE timecodes, including
+// drop-frame timecode at 29.97 and 59.94 fps.
+//
+// A Timecode is a position measured from the start of a recording, such as
+// a capture. It converts to and from frame counts, offsets and, given the
+// recording's start time, wall-clock times.
+package timecode
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// pattern matches HH:MM:SS:FF, or HH:MM:SS;FF as drop-frame timecode is
+// conventionally written
+var pattern = regexp.MustCompile(`^(\d{2}):(\d{2}):(\d{2})[:;](\d{2})$`)
+
+// Timecode is an HH:MM:SS:FF position at a given frame rate
+type Timecode struct {
+	Hours   int
+	Minutes int
+	Seconds int
+	Frames  int
+	Rate    Rate
+}
+
+// Parse parses an HH:MM:SS:FF timecode at rate r, checking that every field
+// is in range and, for drop-frame rates, that the frame number isn't one
+// that drop-frame timecode skips. Either ':' or ';' may separate the frames.
+func Parse(s string, r Rate) (Timecode, error) {
+	m := pattern.FindStringSubmatch(s)
+	if m == nil {
+		return Timecode{}, fmt.Errorf("timecode %q must be HH:MM:SS:FF", s)
+	}
+
+	tc := Timecode{Rate: r}
+	tc.Hours, _ = strconv.Atoi(m[1])
+	tc.Minutes, _ = strconv.Atoi(m[2])
+	tc.Seconds, _ = strconv.Atoi(m[3])
+	tc.Frames, _ = strconv.Atoi(m[4])
+
+	switch {
+	case tc.Minutes > 59:
+		return Timecode{}, fmt.Errorf("timecode %s: minutes must be 00-59", s)
+	case tc.Seconds > 59:
+		return Timecode{}, fmt.Errorf("timecode %s: seconds must be 00-59", s)
+	case int64(tc.Frames) >= r.Nominal():
+		return Timecode{}, fmt.Errorf("timecode %s: frames must be 00-%02d at %s fps", s, r.Nominal()-1, r)
+	case r.DropFrame && tc.Seconds == 0 && tc.Minutes%10 != 0 && int64(tc.Frames) < r.dropped():
+		return Timecode{}, fmt.Errorf("timecode %s does not exist in drop-frame timecode: frames 00-%02d are skipped at the start of each minute not divisible by ten", s, r.dropped()-1)
+	}
+	return tc, nil
+}
+
+// FromFrames returns the timecode of frame n, counting from zero
+func FromFrames(n int64, r Rate) Timecode {
+	if drop := r.dropped(); drop > 0 {
+		perMinute := r.Nominal()*60 - drop
+		perTenMinutes := r.Nominal()*600 - drop*9
+		tens, rem := n/perTenMinutes, n%perTenMinutes
+		n += drop * 9 * tens
+		if rem >= drop {
+			n += drop * ((rem - drop) / perMinute)
+		}
+	}
+
+	nominal := r.Nominal()
+	return Timecode{
+		Hours:   int(n / (nominal * 3600)),
+		Minutes: int(n / (nominal * 60) % 60),
+		Seconds: int(n / nominal % 60),
+		Frames:  int(n % nominal),
+		Rate:    r,
+	}
+}
+
+// FromOffset returns the timecode of the frame showing at offset d
+func FromOffset(d time.Duration, r Rate) (Timecode, error) {
+	if d < 0 {
+		return Timecode{}, fmt.Errorf("offset %s is before the start", d)
+	}
+	return FromFrames(r.Frames(d), r), nil
+}
+
+// FromTime returns the timecode of the frame showing at t, in a recording
+// that started at start
+func FromTime(t, start time.Time, r Rate) (Timecode, error) {
+	if t.Before(start) {
+		return Timecode{}, fmt.Errorf("%s is before the start at %s", t.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	return FromOffset(t.Sub(start), r)
+}
+
+// FrameCount is the number of frames before this timecode
+func (tc Timecode) FrameCount() int64 {
+	nominal := tc.Rate.Nominal()
+	n := ((int64(tc.Hours)*60+int64(tc.Minutes))*60+int64(tc.Seconds))*nominal + int64(tc.Frames)
+	if drop := tc.Rate.dropped(); drop > 0 {
+		minutes := int64(tc.Hours)*60 + int64(tc.Minutes)
+		n -= drop * (minutes - minutes/10)
+	}
+	return n
+}
+
+// Offset is how far into the recording the timecode's frame starts
+func (tc Timecode) Offset() time.Duration {
+	return tc.Rate.Duration(tc.FrameCount())
+}
+
+// Time is the wall-clock time of the timecode's frame in a recording that
+// started at start
+func (tc Timecode) Time(start time.Time) time.Time {
+	return start.Add(tc.Offset())
+}
+
+// String formats the timecode as HH:MM:SS:FF, or HH:MM:SS;FF for
+// drop-frame timecode
+func (tc Timecode) String() string {
+	sep := ":"
+	if tc.Rate.DropFrame {
+		sep = ";"
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", tc.Hours, tc.Minutes, tc.Seconds, sep, tc.Frames)
+}
+
+// Before reports whether tc comes before other
+func (tc Timecode) Before(other Timecode) bool {
+	return tc.FrameCount() < other.FrameCount()
+}
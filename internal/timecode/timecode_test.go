@@ -0,0 +1,152 @@
+package timecode
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDropFrameConversions(t *testing.T) {
+	tests := []struct {
+		tc     string
+		frames int64
+	}{
+		{"00:00:00;00", 0},
+		{"00:00:59;29", 1799},
+		{"00:01:00;02", 1800},
+		{"00:09:59;29", 17981},
+		{"00:10:00;00", 17982},
+		{"00:10:00;02", 17984},
+		{"00:11:00;02", 19782},
+		{"01:00:00;00", 107892},
+		{"23:59:59;29", 2589407},
+	}
+	for _, tt := range tests {
+		tc, err := Parse(tt.tc, Rate2997DF)
+		if err != nil {
+			t.Errorf("Parse(%s): %v", tt.tc, err)
+			continue
+		}
+		if got := tc.FrameCount(); got != tt.frames {
+			t.Errorf("%s: FrameCount = %d, want %d", tt.tc, got, tt.frames)
+		}
+		if got := FromFrames(tt.frames, Rate2997DF).String(); got != tt.tc {
+			t.Errorf("FromFrames(%d) = %s, want %s", tt.frames, got, tt.tc)
+		}
+	}
+}
+
+// Every frame in a day survives a round trip through timecode and offset
+func TestRoundTrip(t *testing.T) {
+	for _, r := range []Rate{Rate23976, Rate25, Rate2997DF, Rate2997NDF, Rate5994DF} {
+		day := r.Frames(24 * time.Hour)
+		for n := int64(0); n < day; n += 997 {
+			tc := FromFrames(n, r)
+			parsed, err := Parse(tc.String(), r)
+			if err != nil {
+				t.Fatalf("%s: Parse(%s): %v", r, tc, err)
+			}
+			if got := parsed.FrameCount(); got != n {
+				t.Fatalf("%s: frame %d -> %s -> %d", r, n, tc, got)
+			}
+			if got := r.Frames(tc.Offset()); got != n {
+				t.Fatalf("%s: frame %d -> offset %s -> frame %d", r, n, tc.Offset(), got)
+			}
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		tc   string
+		rate Rate
+		want string
+	}{
+		{"1:00:00:00", Rate25, "HH:MM:SS:FF"},
+		{"00:60:00:00", Rate25, "minutes"},
+		{"00:00:60:00", Rate25, "seconds"},
+		{"00:00:00:25", Rate25, "frames must be 00-24"},
+		{"00:01:00;00", Rate2997DF, "does not exist"},
+		{"00:01:00;01", Rate2997DF, "does not exist"},
+		{"00:01:00;03", Rate5994DF, "does not exist"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.tc, tt.rate); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%s, %s) = %v, want error containing %q", tt.tc, tt.rate, err, tt.want)
+		}
+	}
+
+	// The frames skipped at 00:01:00 are valid without drop-frame
+	if _, err := Parse("00:01:00:00", Rate2997NDF); err != nil {
+		t.Errorf("non-drop-frame: %v", err)
+	}
+	if _, err := Parse("00:10:00;00", Rate2997DF); err != nil {
+		t.Errorf("tenth minute: %v", err)
+	}
+}
+
+func TestTime(t *testing.T) {
+	start := time.Date(2025, 10, 1, 15, 0, 0, 0, time.UTC)
+
+	tc, err := FromTime(start.Add(12*time.Minute+30*time.Second+500*time.Millisecond), start, Rate25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.String() != "00:12:30:12" {
+		t.Errorf("FromTime = %s, want 00:12:30:12", tc)
+	}
+	if got := tc.Time(start); !got.Equal(start.Add(12*time.Minute + 30*time.Second + 480*time.Millisecond)) {
+		t.Errorf("Time = %s", got)
+	}
+
+	if _, err := FromTime(start.Add(-time.Second), start, Rate25); err == nil {
+		t.Error("FromTime before start: want error")
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for s, want := range map[string]Rate{"25": Rate25, "29.97": Rate2997DF, "29.97NDF": Rate2997NDF, " 23.98 ": Rate23976} {
+		if got, err := ParseRate(s); err != nil || got != want {
+			t.Errorf("ParseRate(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseRate("12"); err == nil {
+		t.Error("ParseRate(12): want error")
+	}
+	if got := Rate2997DF.String(); got != "29.97df" {
+		t.Errorf("String = %s", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	start := time.Date(2025, 10, 1, 15, 0, 0, 0, time.UTC)
+	length := 30 * time.Minute
+
+	tests := map[string]string{
+		"00:01:00;02":          "00:01:00;02",
+		"2025-10-01T15:42:10Z": "00:42:10;00",
+		"+00:12:30":            "00:12:29;29",
+		"+90s":                 "00:01:29;29",
+		"-1m30s":               "00:28:30;00",
+		"-00:00:00.5":          "00:29:59;15",
+	}
+	for s, want := range tests {
+		tc, err := Resolve(s, start, length, Rate2997DF)
+		if err != nil {
+			t.Errorf("Resolve(%s): %v", s, err)
+			continue
+		}
+		if tc.String() != want {
+			t.Errorf("Resolve(%s) = %s, want %s", s, tc, want)
+		}
+	}
+
+	for _, s := range []string{"tomorrow", "+1:99:00", "-31m", "2025-10-01T14:59:59Z", "+-5s"} {
+		if tc, err := Resolve(s, start, length, Rate2997DF); err == nil {
+			t.Errorf("Resolve(%s) = %s, want error", s, tc)
+		}
+	}
+	if _, err := Resolve("-90s", start, 0, Rate25); err == nil {
+		t.Error("offset from the end of an unknown length: want error")
+	}
+}
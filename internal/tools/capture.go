@@ -1,24 +1,34 @@
 package tools
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/jobs"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
+	"github.com/andy-wilson/m2a-mcp/internal/timecode"
 )
 
 // CaptureTools handles M2A Capture API operations
 type CaptureTools struct {
-	client *client.M2AClient
+	client  *client.M2AClient
+	capture *m2a.CaptureService
+	jobs    *jobs.Tracker
 }
 
-// NewCaptureTools creates a new CaptureTools instance
-func NewCaptureTools(client *client.M2AClient) *CaptureTools {
-	return &CaptureTools{client: client}
+// NewCaptureTools creates a new CaptureTools instance. Captures and clips
+// it creates are tracked as jobs.
+func NewCaptureTools(client *client.M2AClient, tracker *jobs.Tracker) *CaptureTools {
+	return &CaptureTools{client: client, capture: m2a.NewCaptureService(client), jobs: tracker}
 }
 
 // ListCaptures lists all capture jobs
-func (t *CaptureTools) ListCaptures(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *CaptureTools) ListCaptures(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v1/connect/capture"
 
 	status, _ := arguments["status"].(string)
@@ -26,145 +36,223 @@ func (t *CaptureTools) ListCaptures(arguments map[string]interface{}) (*mcp.Call
 		endpoint += "?status=" + status
 	}
 
-	data, err := t.client.Get(endpoint)
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list captures"), nil
+	}
+
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list captures: %v", err)), nil
+		return apiErrorResult("failed to list captures", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetCapture gets details of a specific capture job
-func (t *CaptureTools) GetCapture(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *CaptureTools) GetCapture(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	captureID, ok := arguments["capture_id"].(string)
 	if !ok || captureID == "" {
-		return mcp.NewToolResultError("capture_id is required"), nil
+		return invalidArgument("capture_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v1/connect/capture/%s", captureID)
-	data, err := t.client.Get(endpoint)
+	capture, err := t.capture.GetCapture(ctx, captureID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get capture: %v", err)), nil
+		return apiErrorResult("failed to get capture", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(capture), nil
 }
 
 // CreateCapture creates a new live-to-VOD capture job
-func (t *CaptureTools) CreateCapture(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	name, ok := arguments["name"].(string)
-	if !ok || name == "" {
-		return mcp.NewToolResultError("name is required"), nil
-	}
-
-	channelID, ok := arguments["channel_id"].(string)
-	if !ok || channelID == "" {
-		return mcp.NewToolResultError("channel_id is required"), nil
-	}
-
-	startTime, ok := arguments["start_time"].(string)
-	if !ok || startTime == "" {
-		return mcp.NewToolResultError("start_time is required"), nil
-	}
-
-	endTime, ok := arguments["end_time"].(string)
-	if !ok || endTime == "" {
-		return mcp.NewToolResultError("end_time is required"), nil
-	}
-
-	body := map[string]interface{}{
-		"name":       name,
-		"channel_id": channelID,
-		"start_time": startTime,
-		"end_time":   endTime,
-	}
-
-	endpoint := "/api/v1/connect/capture"
-	data, err := t.client.Post(endpoint, body)
+func (t *CaptureTools) CreateCapture(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateCaptureRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.ChannelID, _ = arguments["channel_id"].(string)
+	req.StartTime, _ = arguments["start_time"].(string)
+	req.EndTime, _ = arguments["end_time"].(string)
+
+	capture, err := t.capture.CreateCapture(ctx, req)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create capture: %v", err)), nil
+		return apiErrorResult("failed to create capture", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	job := t.jobs.Track(ctx, jobs.KindCapture, capture.ID, capture.Name, capture.Status)
+	return jobResult(capture, job), nil
 }
 
 // CancelCapture cancels an in-progress capture job
-func (t *CaptureTools) CancelCapture(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *CaptureTools) CancelCapture(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	captureID, ok := arguments["capture_id"].(string)
 	if !ok || captureID == "" {
-		return mcp.NewToolResultError("capture_id is required"), nil
+		return invalidArgument("capture_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v1/connect/capture/%s/cancel", captureID)
-	data, err := t.client.Post(endpoint, nil)
+	capture, err := t.capture.CancelCapture(ctx, captureID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to cancel capture: %v", err)), nil
+		return apiErrorResult("failed to cancel capture", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(capture), nil
 }
 
 // ListCaptureExports lists all completed VOD exports from captures
-func (t *CaptureTools) ListCaptureExports(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *CaptureTools) ListCaptureExports(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v1/connect/capture/exports"
-	data, err := t.client.Get(endpoint)
+
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list capture exports"), nil
+	}
+
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list capture exports: %v", err)), nil
+		return apiErrorResult("failed to list capture exports", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetCaptureExport gets details of a specific capture export
-func (t *CaptureTools) GetCaptureExport(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *CaptureTools) GetCaptureExport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	exportID, ok := arguments["export_id"].(string)
 	if !ok || exportID == "" {
-		return mcp.NewToolResultError("export_id is required"), nil
+		return invalidArgument("export_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v1/connect/capture/exports/%s", exportID)
-	data, err := t.client.Get(endpoint)
+	export, err := t.capture.GetExport(ctx, exportID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get capture export: %v", err)), nil
+		return apiErrorResult("failed to get capture export", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(export), nil
 }
 
 // CreateClip creates a frame-accurate clip from a capture
-func (t *CaptureTools) CreateClip(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	captureID, ok := arguments["capture_id"].(string)
-	if !ok || captureID == "" {
-		return mcp.NewToolResultError("capture_id is required"), nil
+func (t *CaptureTools) CreateClip(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateClipRequest{}
+	req.CaptureID, _ = arguments["capture_id"].(string)
+	req.StartTimecode, _ = arguments["start_timecode"].(string)
+	req.EndTimecode, _ = arguments["end_timecode"].(string)
+	req.Name, _ = arguments["name"].(string)
+	for _, name := range []string{"capture_id", "start_timecode", "end_timecode", "name"} {
+		if value, _ := arguments[name].(string); strings.TrimSpace(value) == "" {
+			return invalidArgument(name + " is required"), nil
+		}
 	}
 
-	startTimecode, ok := arguments["start_timecode"].(string)
-	if !ok || startTimecode == "" {
-		return mcp.NewToolResultError("start_timecode is required"), nil
+	// Times and offsets are resolved, and the timecodes checked against the
+	// capture's frame rate and length, here where the error can say why
+	// rather than leaving it to the platform
+	capture, err := t.capture.GetCapture(ctx, req.CaptureID)
+	if err != nil {
+		return apiErrorResult("failed to get capture", err), nil
+	}
+	resolved := map[string]resolvedPosition{}
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{{"start_timecode", &req.StartTimecode}, {"end_timecode", &req.EndTimecode}} {
+		if timecode.IsTimecode(*field.value) {
+			continue
+		}
+		tc, err := capture.Resolve(*field.value)
+		if err != nil {
+			return apiErrorResult("invalid "+field.name, err), nil
+		}
+		position := resolvedPosition{Input: *field.value, Timecode: tc.String()}
+		if start, err := time.Parse(time.RFC3339, capture.StartTime); err == nil {
+			position.Time = tc.Time(start).UTC().Format(time.RFC3339Nano)
+		}
+		resolved[field.name] = position
+		*field.value = tc.String()
+	}
+	if err := req.ValidateFor(capture); err != nil {
+		return apiErrorResult("invalid clip", err), nil
 	}
 
-	endTimecode, ok := arguments["end_timecode"].(string)
-	if !ok || endTimecode == "" {
-		return mcp.NewToolResultError("end_timecode is required"), nil
+	clip, err := t.capture.CreateClip(ctx, req)
+	if err != nil {
+		return apiErrorResult("failed to create clip", err), nil
 	}
 
-	name, ok := arguments["name"].(string)
-	if !ok || name == "" {
-		return mcp.NewToolResultError("name is required"), nil
+	if len(resolved) > 0 {
+		data, _ := json.Marshal(resolved)
+		if clip.Extra == nil {
+			clip.Extra = m2a.Extra{}
+		}
+		clip.Extra["resolved"] = data
 	}
 
-	body := map[string]interface{}{
-		"capture_id":     captureID,
-		"start_timecode": startTimecode,
-		"end_timecode":   endTimecode,
-		"name":           name,
+	job := t.jobs.Track(ctx, jobs.KindClip, clip.ID, clip.Name, clip.Status)
+	return jobResult(clip, job), nil
+}
+
+// maxClipConcurrency caps how many clips create_clips_batch submits at once
+const maxClipConcurrency = 10
+
+// clipBatchRow is one row of create_clips_batch's result table
+type clipBatchRow struct {
+	m2a.ClipResult
+	JobID string `json:"job_id,omitempty"`
+}
+
+// CreateClipsBatch creates a clip for every event in an EDL, CSV or JSON
+// cut list, or with dry_run only validates them
+func (t *CaptureTools) CreateClipsBatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	captureID, _ := arguments["capture_id"].(string)
+	if captureID == "" {
+		return invalidArgument("capture_id is required"), nil
+	}
+	cutList, _ := arguments["cut_list"].(string)
+	if strings.TrimSpace(cutList) == "" {
+		return invalidArgument("cut_list is required"), nil
+	}
+	format, _ := arguments["format"].(string)
+	opts := m2a.ClipBatchOptions{}
+	opts.DryRun, _ = arguments["dry_run"].(bool)
+	if concurrency, _ := arguments["concurrency"].(float64); concurrency > 0 {
+		opts.Concurrency = min(int(concurrency), maxClipConcurrency)
 	}
 
-	endpoint := "/api/v1/connect/capture/clips"
-	data, err := t.client.Post(endpoint, body)
+	events, err := m2a.ParseCutList([]byte(cutList), format)
+	if err != nil {
+		return apiErrorResult("invalid cut_list", err), nil
+	}
+	capture, err := t.capture.GetCapture(ctx, captureID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create clip: %v", err)), nil
+		return apiErrorResult("failed to get capture", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	counts := map[string]int{}
+	rows := []clipBatchRow{}
+	for _, result := range t.capture.CreateClips(ctx, capture, events, opts) {
+		row := clipBatchRow{ClipResult: result}
+		if result.Clip != nil {
+			row.JobID = t.jobs.Track(ctx, jobs.KindClip, result.Clip.ID, result.Clip.Name, result.Clip.Status).ID
+		}
+		counts[result.Status]++
+		rows = append(rows, row)
+	}
+
+	return jsonResult(map[string]interface{}{
+		"capture_id": capture.ID,
+		"dry_run":    opts.DryRun,
+		"total":      len(rows),
+		"counts":     counts,
+		"rows":       rows,
+	}), nil
+}
+
+// resolvedPosition reports the timecode a clip time or offset was resolved
+// to, and the wall-clock time of that frame
+type resolvedPosition struct {
+	Input    string `json:"input"`
+	Timecode string `json:"timecode"`
+	Time     string `json:"time,omitempty"`
 }
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/auth"
+	"github.com/andy-wilson/m2a-mcp/internal/confirm"
+)
+
+// confirmationScope builds the scope a destructive call is confirmed for,
+// bound to the calling client
+func confirmationScope(ctx context.Context, action, resourceID string) confirm.Scope {
+	id, _ := auth.FromContext(ctx)
+	return confirm.Scope{Action: action, ResourceID: resourceID, Client: id.Name}
+}
+
+// previewResult issues a confirmation token and returns what the destructive
+// action would affect. The agent must call the tool again with the token.
+func previewResult(ctx context.Context, store *confirm.Store, action, resourceID string, resource interface{}, dependents map[string]interface{}) *mcp.CallToolResult {
+	token, expires := store.Issue(confirmationScope(ctx, action, resourceID))
+
+	result := map[string]interface{}{
+		"confirmation_required": true,
+		"action":                action,
+		"resource_id":           resourceID,
+		"resource":              resource,
+		"confirm_token":         token,
+		"expires_at":            expires.UTC().Format(time.RFC3339),
+		"message":               fmt.Sprintf("Nothing has been changed yet. Review this preview, then call %s again with the same arguments and confirm_token to proceed.", action),
+	}
+	if len(dependents) > 0 {
+		result["dependents"] = dependents
+	}
+	jsonData, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(jsonData))
+}
+
+// redeemConfirmation checks a confirmation token, returning an error result
+// if it doesn't authorise the action
+func redeemConfirmation(ctx context.Context, store *confirm.Store, action, resourceID, token string) *mcp.CallToolResult {
+	if err := store.Redeem(token, confirmationScope(ctx, action, resourceID)); err != nil {
+		return newErrorResult(toolError{
+			Kind:    kindBadConfirmation,
+			Message: fmt.Sprintf("%s not performed: %v; call %s without confirm_token to get a fresh preview", action, err, action),
+		})
+	}
+	return nil
+}
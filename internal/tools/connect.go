@@ -1,25 +1,38 @@
 package tools
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/confirm"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
+	"github.com/andy-wilson/m2a-mcp/internal/recurrence"
 )
 
 // ConnectTools handles M2A Connect API operations
 type ConnectTools struct {
-	client *client.M2AClient
+	client        *client.M2AClient
+	connect       *m2a.ConnectService
+	confirmations *confirm.Store
 }
 
 // NewConnectTools creates a new ConnectTools instance
-func NewConnectTools(client *client.M2AClient) *ConnectTools {
-	return &ConnectTools{client: client}
+func NewConnectTools(client *client.M2AClient, confirmations *confirm.Store) *ConnectTools {
+	return &ConnectTools{client: client, connect: m2a.NewConnectService(client), confirmations: confirmations}
 }
 
 // ListSources lists all video sources
-func (t *ConnectTools) ListSources(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) ListSources(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	status, _ := arguments["status"].(string)
 
 	endpoint := "/api/v2/connect/sources"
@@ -27,109 +40,108 @@ func (t *ConnectTools) ListSources(arguments map[string]interface{}) (*mcp.CallT
 		endpoint += "?status=" + status
 	}
 
-	data, err := t.client.Get(endpoint)
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list sources"), nil
+	}
+
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list sources: %v", err)), nil
+		return apiErrorResult("failed to list sources", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetSource gets details of a specific source
-func (t *ConnectTools) GetSource(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) GetSource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	sourceID, ok := arguments["source_id"].(string)
 	if !ok || sourceID == "" {
-		return mcp.NewToolResultError("source_id is required"), nil
+		return invalidArgument("source_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v2/connect/sources/%s", sourceID)
-	data, err := t.client.Get(endpoint)
+	source, err := t.connect.GetSource(ctx, sourceID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get source: %v", err)), nil
+		return apiErrorResult("failed to get source", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(source), nil
 }
 
 // CreateSource creates a new video source
-func (t *ConnectTools) CreateSource(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	name, ok := arguments["name"].(string)
-	if !ok || name == "" {
-		return mcp.NewToolResultError("name is required"), nil
-	}
-
-	sourceType, ok := arguments["type"].(string)
-	if !ok || sourceType == "" {
-		return mcp.NewToolResultError("type is required"), nil
-	}
-
-	url, ok := arguments["url"].(string)
-	if !ok || url == "" {
-		return mcp.NewToolResultError("url is required"), nil
-	}
-
-	body := map[string]interface{}{
-		"name": name,
-		"type": sourceType,
-		"url":  url,
-	}
-
-	desc, _ := arguments["description"].(string)
-	if desc != "" {
-		body["description"] = desc
-	}
-
-	endpoint := "/api/v2/connect/sources"
-	data, err := t.client.Post(endpoint, body)
+func (t *ConnectTools) CreateSource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateSourceRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.Type, _ = arguments["type"].(string)
+	req.URL, _ = arguments["url"].(string)
+	req.Description, _ = arguments["description"].(string)
+
+	source, err := t.connect.CreateSource(ctx, req)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create source: %v", err)), nil
+		return apiErrorResult("failed to create source", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(source), nil
 }
 
 // UpdateSource updates an existing source
-func (t *ConnectTools) UpdateSource(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) UpdateSource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	sourceID, ok := arguments["source_id"].(string)
 	if !ok || sourceID == "" {
-		return mcp.NewToolResultError("source_id is required"), nil
-	}
-
-	body := make(map[string]interface{})
-	if name, _ := arguments["name"].(string); name != "" {
-		body["name"] = name
-	}
-	if url, _ := arguments["url"].(string); url != "" {
-		body["url"] = url
-	}
-	if desc, _ := arguments["description"].(string); desc != "" {
-		body["description"] = desc
+		return invalidArgument("source_id is required"), nil
 	}
 
-	if len(body) == 0 {
-		return mcp.NewToolResultError("at least one field to update is required"), nil
-	}
+	req := m2a.UpdateSourceRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.URL, _ = arguments["url"].(string)
+	req.Description, _ = arguments["description"].(string)
 
-	endpoint := fmt.Sprintf("/api/v2/connect/sources/%s", sourceID)
-	data, err := t.client.Put(endpoint, body)
+	source, err := t.connect.UpdateSource(ctx, sourceID, req)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to update source: %v", err)), nil
+		return apiErrorResult("failed to update source", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(source), nil
 }
 
-// DeleteSource deletes a source
-func (t *ConnectTools) DeleteSource(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+// DeleteSource deletes a source. Without a confirm_token it only previews the
+// source and the subscriptions and schedules that reference it.
+func (t *ConnectTools) DeleteSource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	sourceID, ok := arguments["source_id"].(string)
 	if !ok || sourceID == "" {
-		return mcp.NewToolResultError("source_id is required"), nil
+		return invalidArgument("source_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v2/connect/sources/%s", sourceID)
-	_, err := t.client.Delete(endpoint)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to delete source: %v", err)), nil
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		source, err := t.connect.GetSource(ctx, sourceID)
+		if err != nil {
+			return apiErrorResult("failed to get source", err), nil
+		}
+
+		subscriptions, err := t.connect.SubscriptionsForSource(ctx, sourceID)
+		if err != nil {
+			return apiErrorResult("failed to list subscriptions", err), nil
+		}
+		schedules, err := t.connect.SchedulesForSource(ctx, sourceID)
+		if err != nil {
+			return apiErrorResult("failed to list schedules", err), nil
+		}
+
+		return previewResult(ctx, t.confirmations, "delete_source", sourceID, source, map[string]interface{}{
+			"subscriptions": subscriptions,
+			"schedules":     schedules,
+		}), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_source", sourceID, token); result != nil {
+		return result, nil
+	}
+
+	if err := t.connect.DeleteSource(ctx, sourceID); err != nil {
+		return apiErrorResult("failed to delete source", err), nil
 	}
 
 	result := map[string]interface{}{
@@ -141,9 +153,14 @@ func (t *ConnectTools) DeleteSource(arguments map[string]interface{}) (*mcp.Call
 }
 
 // ListSubscribers lists all subscribers
-func (t *ConnectTools) ListSubscribers(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) ListSubscribers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v2/connect/subscribers"
 
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list subscribers"), nil
+	}
+
 	// Add pagination parameters if provided
 	queryParams := ""
 	if limit, _ := arguments["limit"].(float64); limit > 0 {
@@ -159,122 +176,214 @@ func (t *ConnectTools) ListSubscribers(arguments map[string]interface{}) (*mcp.C
 	}
 	endpoint += queryParams
 
-	data, err := t.client.Get(endpoint)
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list subscribers: %v", err)), nil
+		return apiErrorResult("failed to list subscribers", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetSubscriber gets details of a specific subscriber
-func (t *ConnectTools) GetSubscriber(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) GetSubscriber(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	subscriberID, ok := arguments["subscriber_id"].(string)
 	if !ok || subscriberID == "" {
-		return mcp.NewToolResultError("subscriber_id is required"), nil
+		return invalidArgument("subscriber_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v2/connect/subscribers/%s", subscriberID)
-	data, err := t.client.Get(endpoint)
+	subscriber, err := t.connect.GetSubscriber(ctx, subscriberID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get subscriber: %v", err)), nil
+		return apiErrorResult("failed to get subscriber", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(subscriber), nil
 }
 
 // CreateSubscriber creates a new subscriber
-func (t *ConnectTools) CreateSubscriber(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	name, ok := arguments["name"].(string)
-	if !ok || name == "" {
-		return mcp.NewToolResultError("name is required"), nil
+func (t *ConnectTools) CreateSubscriber(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateSubscriberRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.Email, _ = arguments["email"].(string)
+	req.Organization, _ = arguments["organization"].(string)
+
+	subscriber, err := t.connect.CreateSubscriber(ctx, req)
+	if err != nil {
+		return apiErrorResult("failed to create subscriber", err), nil
 	}
 
-	email, ok := arguments["email"].(string)
-	if !ok || email == "" {
-		return mcp.NewToolResultError("email is required"), nil
+	return jsonResult(subscriber), nil
+}
+
+// UpdateSubscriber updates an existing subscriber
+func (t *ConnectTools) UpdateSubscriber(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	subscriberID, ok := arguments["subscriber_id"].(string)
+	if !ok || subscriberID == "" {
+		return invalidArgument("subscriber_id is required"), nil
 	}
 
-	body := map[string]interface{}{
-		"name":  name,
-		"email": email,
+	req := m2a.UpdateSubscriberRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.Email, _ = arguments["email"].(string)
+	req.Organization, _ = arguments["organization"].(string)
+
+	subscriber, err := t.connect.UpdateSubscriber(ctx, subscriberID, req)
+	if err != nil {
+		return apiErrorResult("failed to update subscriber", err), nil
 	}
 
-	org, _ := arguments["organization"].(string)
-	if org != "" {
-		body["organization"] = org
+	return jsonResult(subscriber), nil
+}
+
+// DeleteSubscriber deletes a subscriber. Without a confirm_token it only
+// previews the subscriber and its subscriptions.
+func (t *ConnectTools) DeleteSubscriber(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	subscriberID, ok := arguments["subscriber_id"].(string)
+	if !ok || subscriberID == "" {
+		return invalidArgument("subscriber_id is required"), nil
 	}
 
-	endpoint := "/api/v2/connect/subscribers"
-	data, err := t.client.Post(endpoint, body)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create subscriber: %v", err)), nil
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		subscriber, err := t.connect.GetSubscriber(ctx, subscriberID)
+		if err != nil {
+			return apiErrorResult("failed to get subscriber", err), nil
+		}
+
+		subscriptions, err := t.connect.SubscriptionsForSubscriber(ctx, subscriberID)
+		if err != nil {
+			return apiErrorResult("failed to list subscriptions", err), nil
+		}
+
+		return previewResult(ctx, t.confirmations, "delete_subscriber", subscriberID, subscriber, map[string]interface{}{
+			"subscriptions": subscriptions,
+		}), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_subscriber", subscriberID, token); result != nil {
+		return result, nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	if err := t.connect.DeleteSubscriber(ctx, subscriberID); err != nil {
+		return apiErrorResult("failed to delete subscriber", err), nil
+	}
+
+	result := map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Subscriber %s deleted successfully", subscriberID),
+	}
+	jsonData, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
 // ListSubscriptions lists all subscriptions
-func (t *ConnectTools) ListSubscriptions(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) ListSubscriptions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v2/connect/subscriptions"
-	data, err := t.client.Get(endpoint)
+
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list subscriptions"), nil
+	}
+
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list subscriptions: %v", err)), nil
+		return apiErrorResult("failed to list subscriptions", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetSubscription gets details of a specific subscription
-func (t *ConnectTools) GetSubscription(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) GetSubscription(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	subscriptionID, ok := arguments["subscription_id"].(string)
 	if !ok || subscriptionID == "" {
-		return mcp.NewToolResultError("subscription_id is required"), nil
+		return invalidArgument("subscription_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v2/connect/subscriptions/%s", subscriptionID)
-	data, err := t.client.Get(endpoint)
+	subscription, err := t.connect.GetSubscription(ctx, subscriptionID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get subscription: %v", err)), nil
+		return apiErrorResult("failed to get subscription", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(subscription), nil
 }
 
 // CreateSubscription creates a new subscription package
-func (t *ConnectTools) CreateSubscription(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	name, ok := arguments["name"].(string)
-	if !ok || name == "" {
-		return mcp.NewToolResultError("name is required"), nil
+func (t *ConnectTools) CreateSubscription(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateSubscriptionRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.SubscriberID, _ = arguments["subscriber_id"].(string)
+	req.SourceIDs, _ = arguments["source_ids"].(string)
+
+	subscription, err := t.connect.CreateSubscription(ctx, req)
+	if err != nil {
+		return apiErrorResult("failed to create subscription", err), nil
 	}
 
-	subscriberID, ok := arguments["subscriber_id"].(string)
-	if !ok || subscriberID == "" {
-		return mcp.NewToolResultError("subscriber_id is required"), nil
+	return jsonResult(subscription), nil
+}
+
+// UpdateSubscription updates an existing subscription package
+func (t *ConnectTools) UpdateSubscription(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	subscriptionID, ok := arguments["subscription_id"].(string)
+	if !ok || subscriptionID == "" {
+		return invalidArgument("subscription_id is required"), nil
 	}
 
-	sourceIDs, ok := arguments["source_ids"].(string)
-	if !ok || sourceIDs == "" {
-		return mcp.NewToolResultError("source_ids is required"), nil
+	req := m2a.UpdateSubscriptionRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.SourceIDs, _ = arguments["source_ids"].(string)
+
+	subscription, err := t.connect.UpdateSubscription(ctx, subscriptionID, req)
+	if err != nil {
+		return apiErrorResult("failed to update subscription", err), nil
 	}
 
-	body := map[string]interface{}{
-		"name":          name,
-		"subscriber_id": subscriberID,
-		"source_ids":    sourceIDs,
+	return jsonResult(subscription), nil
+}
+
+// DeleteSubscription deletes a subscription package. Without a confirm_token
+// it only previews the subscription.
+func (t *ConnectTools) DeleteSubscription(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	subscriptionID, ok := arguments["subscription_id"].(string)
+	if !ok || subscriptionID == "" {
+		return invalidArgument("subscription_id is required"), nil
 	}
 
-	endpoint := "/api/v2/connect/subscriptions"
-	data, err := t.client.Post(endpoint, body)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create subscription: %v", err)), nil
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		subscription, err := t.connect.GetSubscription(ctx, subscriptionID)
+		if err != nil {
+			return apiErrorResult("failed to get subscription", err), nil
+		}
+
+		return previewResult(ctx, t.confirmations, "delete_subscription", subscriptionID, subscription, nil), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_subscription", subscriptionID, token); result != nil {
+		return result, nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	if err := t.connect.DeleteSubscription(ctx, subscriptionID); err != nil {
+		return apiErrorResult("failed to delete subscription", err), nil
+	}
+
+	result := map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Subscription %s deleted successfully", subscriptionID),
+	}
+	jsonData, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
 // ListSchedules lists all schedules
-func (t *ConnectTools) ListSchedules(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) ListSchedules(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v2/connect/schedules"
 
 	queryParams := ""
@@ -293,64 +402,379 @@ func (t *ConnectTools) ListSchedules(arguments map[string]interface{}) (*mcp.Cal
 	}
 	endpoint += queryParams
 
-	data, err := t.client.Get(endpoint)
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list schedules"), nil
+	}
+
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list schedules: %v", err)), nil
+		return apiErrorResult("failed to list schedules", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetSchedule gets details of a specific schedule
-func (t *ConnectTools) GetSchedule(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *ConnectTools) GetSchedule(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	scheduleID, ok := arguments["schedule_id"].(string)
 	if !ok || scheduleID == "" {
-		return mcp.NewToolResultError("schedule_id is required"), nil
+		return invalidArgument("schedule_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v2/connect/schedules/%s", scheduleID)
-	data, err := t.client.Get(endpoint)
+	schedule, err := t.connect.GetSchedule(ctx, scheduleID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get schedule: %v", err)), nil
+		return apiErrorResult("failed to get schedule", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(schedule), nil
 }
 
 // CreateSchedule creates a new schedule
-func (t *ConnectTools) CreateSchedule(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	name, ok := arguments["name"].(string)
-	if !ok || name == "" {
-		return mcp.NewToolResultError("name is required"), nil
+func (t *ConnectTools) CreateSchedule(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateScheduleRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.SourceID, _ = arguments["source_id"].(string)
+	req.StartTime, _ = arguments["start_time"].(string)
+	req.EndTime, _ = arguments["end_time"].(string)
+	allowOverlap, _ := arguments["allow_overlap"].(bool)
+	if err := req.Validate(); err != nil {
+		return apiErrorResult("invalid schedule", err), nil
 	}
 
-	sourceID, ok := arguments["source_id"].(string)
-	if !ok || sourceID == "" {
-		return mcp.NewToolResultError("source_id is required"), nil
+	overlaps, result := t.checkScheduleConflicts(ctx, req.SourceID, req.StartTime, req.EndTime, "", allowOverlap)
+	if result != nil {
+		return result, nil
+	}
+
+	schedule, err := t.connect.CreateSchedule(ctx, req)
+	if err != nil {
+		return apiErrorResult("failed to create schedule", err), nil
 	}
 
-	startTime, ok := arguments["start_time"].(string)
-	if !ok || startTime == "" {
-		return mcp.NewToolResultError("start_time is required"), nil
+	return jsonResult(withOverlaps(schedule, overlaps)), nil
+}
+
+// checkScheduleConflicts looks for other bookings of the source that the
+// window overlaps. They fail the call unless allowOverlap is set, in which
+// case their IDs are returned to be reported alongside the result.
+func (t *ConnectTools) checkScheduleConflicts(ctx context.Context, sourceID, start, end, exclude string, allowOverlap bool) ([]string, *mcp.CallToolResult) {
+	err := t.connect.CheckScheduleConflicts(ctx, sourceID, start, end, exclude)
+	var conflictErr *m2a.ScheduleConflictError
+	switch {
+	case err == nil:
+		return nil, nil
+	case errors.As(err, &conflictErr) && allowOverlap:
+		return conflictErr.IDs(), nil
+	case errors.As(err, &conflictErr):
+		return nil, apiErrorResult("schedule not saved; pass allow_overlap=true to book the source anyway", err)
+	default:
+		return nil, apiErrorResult("failed to check for conflicting schedules", err)
 	}
+}
 
-	endTime, ok := arguments["end_time"].(string)
-	if !ok || endTime == "" {
-		return mcp.NewToolResultError("end_time is required"), nil
+// withOverlaps adds the IDs of schedules that were knowingly overlapped to a
+// schedule for display
+func withOverlaps(schedule *m2a.Schedule, overlaps []string) *m2a.Schedule {
+	if len(overlaps) == 0 {
+		return schedule
+	}
+	data, _ := json.Marshal(overlaps)
+	if schedule.Extra == nil {
+		schedule.Extra = m2a.Extra{}
 	}
+	schedule.Extra["overlaps_with"] = data
+	return schedule
+}
+
+// UpdateSchedule updates an existing schedule
+func (t *ConnectTools) UpdateSchedule(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	scheduleID, ok := arguments["schedule_id"].(string)
+	if !ok || scheduleID == "" {
+		return invalidArgument("schedule_id is required"), nil
+	}
+
+	req := m2a.UpdateScheduleRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.SourceID, _ = arguments["source_id"].(string)
+	req.StartTime, _ = arguments["start_time"].(string)
+	req.EndTime, _ = arguments["end_time"].(string)
+	allowOverlap, _ := arguments["allow_overlap"].(bool)
+	if err := req.Validate(); err != nil {
+		return apiErrorResult("invalid schedule", err), nil
+	}
+
+	// Only changes to the source or window can create a clash, and those are
+	// checked against the schedule as it will be after the update
+	var overlaps []string
+	if req.SourceID != "" || req.StartTime != "" || req.EndTime != "" {
+		existing, err := t.connect.GetSchedule(ctx, scheduleID)
+		if err != nil {
+			return apiErrorResult("failed to get schedule", err), nil
+		}
+		if err := req.ValidateFor(existing); err != nil {
+			return apiErrorResult("invalid schedule", err), nil
+		}
 
-	body := map[string]interface{}{
-		"name":       name,
-		"source_id":  sourceID,
-		"start_time": startTime,
-		"end_time":   endTime,
+		updated := req.Apply(*existing)
+		var result *mcp.CallToolResult
+		overlaps, result = t.checkScheduleConflicts(ctx, updated.SourceID, updated.StartTime, updated.EndTime, scheduleID, allowOverlap)
+		if result != nil {
+			return result, nil
+		}
 	}
 
-	endpoint := "/api/v2/connect/schedules"
-	data, err := t.client.Post(endpoint, body)
+	schedule, err := t.connect.UpdateSchedule(ctx, scheduleID, req)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create schedule: %v", err)), nil
+		return apiErrorResult("failed to update schedule", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(withOverlaps(schedule, overlaps)), nil
+}
+
+// DeleteSchedule deletes a schedule. Without a confirm_token it only
+// previews the schedule.
+func (t *ConnectTools) DeleteSchedule(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	scheduleID, ok := arguments["schedule_id"].(string)
+	if !ok || scheduleID == "" {
+		return invalidArgument("schedule_id is required"), nil
+	}
+
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		schedule, err := t.connect.GetSchedule(ctx, scheduleID)
+		if err != nil {
+			return apiErrorResult("failed to get schedule", err), nil
+		}
+
+		return previewResult(ctx, t.confirmations, "delete_schedule", scheduleID, schedule, nil), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_schedule", scheduleID, token); result != nil {
+		return result, nil
+	}
+
+	if err := t.connect.DeleteSchedule(ctx, scheduleID); err != nil {
+		return apiErrorResult("failed to delete schedule", err), nil
+	}
+
+	result := map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Schedule %s deleted successfully", scheduleID),
+	}
+	jsonData, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// CreateRecurringSchedule expands an iCalendar RRULE into occurrences and
+// creates a schedule for each, tagged with a shared series ID, or with
+// dry_run only previews them
+func (t *ConnectTools) CreateRecurringSchedule(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.RecurringScheduleRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.SourceID, _ = arguments["source_id"].(string)
+	req.Start, _ = arguments["start_time"].(string)
+	req.Rule, _ = arguments["rrule"].(string)
+	req.TimeZone, _ = arguments["timezone"].(string)
+	req.Exclude, _ = arguments["exdates"].(string)
+	for _, name := range []string{"name", "source_id", "start_time", "duration", "rrule"} {
+		if value, _ := arguments[name].(string); value == "" {
+			return invalidArgument(name + " is required"), nil
+		}
+	}
+	duration, err := recurrence.ParseDuration(arguments["duration"].(string))
+	if err != nil {
+		return invalidArgument("duration: " + err.Error()), nil
+	}
+	req.Duration = duration
+	if days, _ := arguments["horizon_days"].(float64); days > 0 {
+		req.Horizon = time.Duration(days * float64(24*time.Hour))
+	}
+	opts := m2a.SeriesOptions{}
+	opts.AllowOverlap, _ = arguments["allow_overlap"].(bool)
+	opts.DryRun, _ = arguments["dry_run"].(bool)
+
+	series, occurrences, err := t.connect.CreateScheduleSeries(ctx, req, opts)
+	if err != nil {
+		if errors.Is(err, m2a.ErrScheduleConflict) {
+			return apiErrorResult("series not created; pass dry_run=true to see which occurrences clash, then exclude them with exdates or pass allow_overlap=true", err), nil
+		}
+		return apiErrorResult("failed to create recurring schedule", err), nil
+	}
+
+	result := seriesResult(series.ID, opts.DryRun, occurrences)
+	result["name"] = req.Name
+	result["source_id"] = req.SourceID
+	result["rrule"] = series.Rule.String()
+	result["timezone"] = series.Location.String()
+	result["horizon_end"] = series.Until.Format(time.RFC3339)
+	return jsonResult(result), nil
+}
+
+// ListScheduleSeries lists the schedules in a series
+func (t *ConnectTools) ListScheduleSeries(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	seriesID, ok := arguments["series_id"].(string)
+	if !ok || seriesID == "" {
+		return invalidArgument("series_id is required"), nil
+	}
+	from, _ := arguments["from"].(string)
+
+	members, result := t.seriesMembers(ctx, seriesID, from)
+	if result != nil {
+		return result, nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"series_id": seriesID,
+		"total":     len(members),
+		"schedules": members,
+	}), nil
+}
+
+// UpdateScheduleSeries renames, moves or retimes every schedule in a series,
+// or those from a given time on
+func (t *ConnectTools) UpdateScheduleSeries(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	seriesID, ok := arguments["series_id"].(string)
+	if !ok || seriesID == "" {
+		return invalidArgument("series_id is required"), nil
+	}
+
+	req := m2a.UpdateScheduleSeriesRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.SourceID, _ = arguments["source_id"].(string)
+	for name, target := range map[string]*time.Duration{"shift": &req.Shift, "duration": &req.Duration} {
+		if value, _ := arguments[name].(string); value != "" {
+			d, err := recurrence.ParseDuration(value)
+			if err != nil {
+				return invalidArgument(name + ": " + err.Error()), nil
+			}
+			*target = d
+		}
+	}
+	if err := req.Validate(); err != nil {
+		return apiErrorResult("invalid series update", err), nil
+	}
+	from, _ := arguments["from"].(string)
+	opts := m2a.SeriesOptions{}
+	opts.AllowOverlap, _ = arguments["allow_overlap"].(bool)
+	opts.DryRun, _ = arguments["dry_run"].(bool)
+
+	members, result := t.seriesMembers(ctx, seriesID, from)
+	if result != nil {
+		return result, nil
+	}
+
+	occurrences, err := t.connect.UpdateScheduleSeries(ctx, members, req, opts)
+	if err != nil {
+		if errors.Is(err, m2a.ErrScheduleConflict) {
+			return apiErrorResult("series not updated; pass dry_run=true to see which occurrences clash, or allow_overlap=true to save anyway", err), nil
+		}
+		return apiErrorResult("failed to update schedule series", err), nil
+	}
+
+	return jsonResult(seriesResult(seriesID, opts.DryRun, occurrences)), nil
+}
+
+// DeleteScheduleSeries deletes every schedule in a series, or those from a
+// given time on. Without a confirm_token it only previews them.
+func (t *ConnectTools) DeleteScheduleSeries(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	seriesID, ok := arguments["series_id"].(string)
+	if !ok || seriesID == "" {
+		return invalidArgument("series_id is required"), nil
+	}
+	from, _ := arguments["from"].(string)
+
+	members, result := t.seriesMembers(ctx, seriesID, from)
+	if result != nil {
+		return result, nil
+	}
+
+	// The token is bound to from and to the schedules previewed, so it can't
+	// delete more of the series than was previewed, nor a different set of
+	// schedules if the series has changed since
+	scope := seriesScope(seriesID, from, members)
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		resource := map[string]interface{}{"series_id": seriesID, "total": len(members), "schedules": members}
+		return previewResult(ctx, t.confirmations, "delete_schedule_series", scope, resource, nil), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_schedule_series", scope, token); result != nil {
+		return result, nil
+	}
+
+	return jsonResult(seriesResult(seriesID, false, t.connect.DeleteScheduleSeries(ctx, members))), nil
+}
+
+// seriesScope builds the confirmation scope for deleting members of a
+// series, ending in a digest of their IDs
+func seriesScope(seriesID, from string, members []m2a.Schedule) string {
+	ids := make([]string, len(members))
+	for i, member := range members {
+		ids[i] = member.ID
+	}
+	slices.Sort(ids)
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+
+	scope := seriesID
+	if from != "" {
+		scope += " from " + from
+	}
+	return scope + " members " + hex.EncodeToString(sum[:6])
+}
+
+// seriesMembers gets the schedules in a series, or if from is set those
+// starting at or after it. A series with none is not found.
+func (t *ConnectTools) seriesMembers(ctx context.Context, seriesID, from string) ([]m2a.Schedule, *mcp.CallToolResult) {
+	var fromTime time.Time
+	if from != "" {
+		var err error
+		if fromTime, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, invalidArgument("from must be an ISO 8601 time such as 2025-11-01T00:00:00Z")
+		}
+	}
+
+	schedules, err := t.connect.ScheduleSeries(ctx, seriesID)
+	if err != nil {
+		return nil, apiErrorResult("failed to list schedule series", err)
+	}
+	members := []m2a.Schedule{}
+	for _, schedule := range schedules {
+		if start, err := time.Parse(time.RFC3339, schedule.StartTime); fromTime.IsZero() || (err == nil && !start.Before(fromTime)) {
+			members = append(members, schedule)
+		}
+	}
+
+	if len(members) == 0 {
+		message := fmt.Sprintf("schedule series %s not found", seriesID)
+		if len(schedules) > 0 {
+			message = fmt.Sprintf("schedule series %s has no schedules starting at or after %s", seriesID, from)
+		}
+		return nil, newErrorResult(toolError{Kind: kindNotFound, Message: message})
+	}
+	return members, nil
+}
+
+// seriesResult reports the outcome for each occurrence of a series, with a
+// count of each status
+func seriesResult(seriesID string, dryRun bool, occurrences []m2a.OccurrenceResult) map[string]interface{} {
+	counts := map[string]int{}
+	for _, o := range occurrences {
+		counts[o.Status]++
+	}
+	result := map[string]interface{}{
+		"dry_run":     dryRun,
+		"total":       len(occurrences),
+		"counts":      counts,
+		"occurrences": occurrences,
+	}
+	if seriesID != "" {
+		result["series_id"] = seriesID
+	}
+	return result
 }
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
+)
+
+// Error kinds reported to the agent in tool error payloads
+const (
+	kindInvalidArgument = "invalid_argument"
+	kindNotFound        = "not_found"
+	kindUnauthorized    = "unauthorized"
+	kindConflict        = "conflict"
+	kindRateLimited     = "rate_limited"
+	kindValidation      = "validation"
+	kindAPIError        = "api_error"
+	kindThrottled       = "throttled"
+	kindReadOnly        = "read_only"
+	kindBadConfirmation = "confirmation_invalid"
+	kindTimeout         = "timeout"
+	kindCancelled       = "cancelled"
+	kindTransition      = "transition_failed"
+	kindRequestFailed   = "request_failed"
+)
+
+// toolError is the machine-readable error payload returned by every tool
+type toolError struct {
+	Kind       string `json:"kind"`
+	Message    string `json:"message"`
+	Retryable  bool   `json:"retryable"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Code       string `json:"code,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+	Method     string `json:"method,omitempty"`
+	Endpoint   string `json:"endpoint,omitempty"`
+	// QueuedMS is how long the call waited in the client-side rate limiter
+	QueuedMS int64 `json:"queued_ms,omitempty"`
+	// ConflictsWith lists the IDs of the resources a request clashed with
+	ConflictsWith []string `json:"conflicts_with,omitempty"`
+}
+
+// newErrorResult renders a toolError as an MCP error result
+func newErrorResult(e toolError) *mcp.CallToolResult {
+	jsonData, _ := json.Marshal(map[string]interface{}{"error": e})
+	return mcp.NewToolResultError(string(jsonData))
+}
+
+// invalidArgument reports a missing or malformed tool argument
+func invalidArgument(message string) *mcp.CallToolResult {
+	return newErrorResult(toolError{Kind: kindInvalidArgument, Message: message})
+}
+
+// apiErrorResult reports a failed API call, e.g. apiErrorResult("failed to get source", err)
+func apiErrorResult(action string, err error) *mcp.CallToolResult {
+	e := toolError{
+		Kind:    kindRequestFailed,
+		Message: fmt.Sprintf("%s: %v", action, err),
+	}
+
+	var apiErr *client.APIError
+	var throttleErr *client.ThrottleError
+	var conflictErr *m2a.ScheduleConflictError
+	switch {
+	case errors.Is(err, m2a.ErrInvalidRequest):
+		e.Kind = kindInvalidArgument
+	case errors.As(err, &conflictErr):
+		e.Kind = kindConflict
+		e.ConflictsWith = conflictErr.IDs()
+	case errors.Is(err, client.ErrReadOnly):
+		e.Kind = kindReadOnly
+	case errors.Is(err, m2a.ErrChannelTransition):
+		e.Kind = kindTransition
+	case errors.As(err, &throttleErr):
+		e.Kind = kindThrottled
+		e.Retryable = true
+		e.QueuedMS = throttleErr.Waited.Milliseconds()
+	case errors.As(err, &apiErr):
+		e.StatusCode = apiErr.StatusCode
+		e.Code = apiErr.Code
+		e.RequestID = apiErr.RequestID
+		e.Method = apiErr.Method
+		e.Endpoint = apiErr.Endpoint
+		e.QueuedMS = apiErr.Queued.Milliseconds()
+		e.Kind, e.Retryable = classifyAPIError(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		e.Kind = kindTimeout
+		e.Retryable = true
+	case errors.Is(err, context.Canceled):
+		e.Kind = kindCancelled
+	default:
+		e.Retryable = true
+	}
+
+	return newErrorResult(e)
+}
+
+// classifyAPIError maps an API error onto an error kind and whether it is
+// worth the agent trying again later
+func classifyAPIError(err error) (string, bool) {
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		return kindNotFound, false
+	case errors.Is(err, client.ErrUnauthorized):
+		return kindUnauthorized, false
+	case errors.Is(err, client.ErrConflict):
+		return kindConflict, false
+	case errors.Is(err, client.ErrRateLimited):
+		return kindRateLimited, true
+	case errors.Is(err, client.ErrValidation):
+		return kindValidation, false
+	}
+
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode >= 500 {
+		return kindAPIError, true
+	}
+	return kindAPIError, false
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/jobs"
+)
+
+// JobTools reports on the asynchronous jobs the server has started
+type JobTools struct {
+	jobs *jobs.Tracker
+}
+
+// NewJobTools creates a new JobTools instance
+func NewJobTools(tracker *jobs.Tracker) *JobTools {
+	return &JobTools{jobs: tracker}
+}
+
+// ListJobs lists the tracked jobs the client may see, newest first
+func (t *JobTools) ListJobs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	kind, _ := arguments["kind"].(string)
+	state, _ := arguments["state"].(string)
+
+	list := t.jobs.List(ctx, kind, state)
+	return jsonResult(map[string]interface{}{"items": list, "total": len(list)}), nil
+}
+
+// GetJob gets a tracked job
+func (t *JobTools) GetJob(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	jobID, ok := arguments["job_id"].(string)
+	if !ok || jobID == "" {
+		return invalidArgument("job_id is required"), nil
+	}
+
+	job, err := t.jobs.Get(ctx, jobID)
+	if err != nil {
+		return jobErrorResult(err), nil
+	}
+
+	return jsonResult(job), nil
+}
+
+// WaitJob waits for a tracked job to finish, sending a progress
+// notification each time it is polled
+func (t *JobTools) WaitJob(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	jobID, ok := arguments["job_id"].(string)
+	if !ok || jobID == "" {
+		return invalidArgument("job_id is required"), nil
+	}
+
+	timeout, _ := WaitTimeout(request)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := progressReporter(ctx, request)
+	started := time.Now()
+	job, err := t.jobs.Wait(ctx, jobID, func(job jobs.Job) {
+		report(time.Since(started).Seconds(), timeout.Seconds(), fmt.Sprintf("%s %s is %s", job.Kind, job.ResourceID, job.Status))
+	})
+	if err != nil {
+		return jobErrorResult(err), nil
+	}
+	return waitResult("job", job, job.State, time.Since(started)), nil
+}
+
+// jobErrorResult reports a failure to find or wait for a job
+func jobErrorResult(err error) *mcp.CallToolResult {
+	if errors.Is(err, jobs.ErrUnknownJob) {
+		return newErrorResult(toolError{Kind: kindNotFound, Message: err.Error()})
+	}
+	return apiErrorResult("failed to wait for job", err)
+}
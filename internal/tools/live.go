@@ -1,25 +1,38 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/confirm"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
 )
 
 // LiveTools handles M2A Live API operations
 type LiveTools struct {
-	client *client.M2AClient
+	client        *client.M2AClient
+	live          *m2a.LiveService
+	capture       *m2a.CaptureService
+	confirmations *confirm.Store
 }
 
 // NewLiveTools creates a new LiveTools instance
-func NewLiveTools(client *client.M2AClient) *LiveTools {
-	return &LiveTools{client: client}
+func NewLiveTools(client *client.M2AClient, confirmations *confirm.Store) *LiveTools {
+	return &LiveTools{
+		client:        client,
+		live:          m2a.NewLiveService(client),
+		capture:       m2a.NewCaptureService(client),
+		confirmations: confirmations,
+	}
 }
 
 // ListChannels lists all MediaLive channels
-func (t *LiveTools) ListChannels(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *LiveTools) ListChannels(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v3/live/channels"
 
 	state, _ := arguments["state"].(string)
@@ -27,104 +40,137 @@ func (t *LiveTools) ListChannels(arguments map[string]interface{}) (*mcp.CallToo
 		endpoint += "?state=" + state
 	}
 
-	data, err := t.client.Get(endpoint)
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list channels"), nil
+	}
+
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list channels: %v", err)), nil
+		return apiErrorResult("failed to list channels", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetChannel gets details of a specific channel
-func (t *LiveTools) GetChannel(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *LiveTools) GetChannel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	channelID, ok := arguments["channel_id"].(string)
 	if !ok || channelID == "" {
-		return mcp.NewToolResultError("channel_id is required"), nil
+		return invalidArgument("channel_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v3/live/channels/%s", channelID)
-	data, err := t.client.Get(endpoint)
+	channel, err := t.live.GetChannel(ctx, channelID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get channel: %v", err)), nil
+		return apiErrorResult("failed to get channel", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(channel), nil
 }
 
 // CreateChannel creates a new MediaLive channel
-func (t *LiveTools) CreateChannel(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	name, ok := arguments["name"].(string)
-	if !ok || name == "" {
-		return mcp.NewToolResultError("name is required"), nil
-	}
-
-	inputType, ok := arguments["input_type"].(string)
-	if !ok || inputType == "" {
-		return mcp.NewToolResultError("input_type is required"), nil
-	}
-
-	body := map[string]interface{}{
-		"name":       name,
-		"input_type": inputType,
-	}
-
-	encoderConfigID, _ := arguments["encoder_config_id"].(string)
-	if encoderConfigID != "" {
-		body["encoder_config_id"] = encoderConfigID
-	}
-
-	endpoint := "/api/v3/live/channels"
-	data, err := t.client.Post(endpoint, body)
+func (t *LiveTools) CreateChannel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateChannelRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.InputType, _ = arguments["input_type"].(string)
+	req.EncoderConfigID, _ = arguments["encoder_config_id"].(string)
+
+	channel, err := t.live.CreateChannel(ctx, req)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create channel: %v", err)), nil
+		return apiErrorResult("failed to create channel", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(channel), nil
 }
 
 // StartChannel starts a MediaLive channel
-func (t *LiveTools) StartChannel(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *LiveTools) StartChannel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	channelID, ok := arguments["channel_id"].(string)
 	if !ok || channelID == "" {
-		return mcp.NewToolResultError("channel_id is required"), nil
+		return invalidArgument("channel_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v3/live/channels/%s/start", channelID)
-	data, err := t.client.Post(endpoint, nil)
+	channel, err := t.live.StartChannel(ctx, channelID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to start channel: %v", err)), nil
+		return apiErrorResult("failed to start channel", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	if timeout, ok := WaitTimeout(request); ok {
+		return t.waitForChannel(ctx, request, channelID, m2a.ChannelRunning, timeout), nil
+	}
+	return jsonResult(channel), nil
 }
 
-// StopChannel stops a MediaLive channel
-func (t *LiveTools) StopChannel(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+// StopChannel stops a MediaLive channel. Without a confirm_token it only
+// previews the channel and the captures that are recording from it.
+func (t *LiveTools) StopChannel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	channelID, ok := arguments["channel_id"].(string)
 	if !ok || channelID == "" {
-		return mcp.NewToolResultError("channel_id is required"), nil
+		return invalidArgument("channel_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v3/live/channels/%s/stop", channelID)
-	data, err := t.client.Post(endpoint, nil)
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		return t.previewChannelAction(ctx, "stop_channel", channelID, true), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "stop_channel", channelID, token); result != nil {
+		return result, nil
+	}
+
+	channel, err := t.live.StopChannel(ctx, channelID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to stop channel: %v", err)), nil
+		return apiErrorResult("failed to stop channel", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	if timeout, ok := WaitTimeout(request); ok {
+		return t.waitForChannel(ctx, request, channelID, m2a.ChannelIdle, timeout), nil
+	}
+	return jsonResult(channel), nil
+}
+
+// waitForChannel polls a channel until it reaches target, sending a
+// progress notification after each poll
+func (t *LiveTools) waitForChannel(ctx context.Context, request mcp.CallToolRequest, channelID, target string, timeout time.Duration) *mcp.CallToolResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := progressReporter(ctx, request)
+	started := time.Now()
+	channel, err := t.live.WaitForChannel(ctx, channelID, m2a.ChannelWait{
+		Target:   target,
+		Interval: t.client.GetConfig().ChannelPollInterval,
+		OnPoll: func(channel *m2a.Channel, elapsed time.Duration) {
+			report(elapsed.Seconds(), timeout.Seconds(), fmt.Sprintf("channel %s is %s", channelID, channel.State))
+		},
+	})
+	if err != nil {
+		return apiErrorResult(fmt.Sprintf("channel did not reach %s", target), err)
+	}
+	return waitResult("channel", channel, channel.State, time.Since(started))
 }
 
-// DeleteChannel deletes a MediaLive channel
-func (t *LiveTools) DeleteChannel(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+// DeleteChannel deletes a MediaLive channel. Without a confirm_token it only
+// previews the channel and the captures that reference it.
+func (t *LiveTools) DeleteChannel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	channelID, ok := arguments["channel_id"].(string)
 	if !ok || channelID == "" {
-		return mcp.NewToolResultError("channel_id is required"), nil
+		return invalidArgument("channel_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v3/live/channels/%s", channelID)
-	_, err := t.client.Delete(endpoint)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to delete channel: %v", err)), nil
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		return t.previewChannelAction(ctx, "delete_channel", channelID, false), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_channel", channelID, token); result != nil {
+		return result, nil
+	}
+
+	if err := t.live.DeleteChannel(ctx, channelID); err != nil {
+		return apiErrorResult("failed to delete channel", err), nil
 	}
 
 	result := map[string]interface{}{
@@ -135,81 +181,336 @@ func (t *LiveTools) DeleteChannel(arguments map[string]interface{}) (*mcp.CallTo
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
+// previewChannelAction describes a channel and the captures that depend on
+// it, and issues a confirmation token for action. With activeOnly, only
+// captures that are pending or in progress are listed.
+func (t *LiveTools) previewChannelAction(ctx context.Context, action, channelID string, activeOnly bool) *mcp.CallToolResult {
+	channel, err := t.live.GetChannel(ctx, channelID)
+	if err != nil {
+		return apiErrorResult("failed to get channel", err)
+	}
+
+	captures, err := t.capture.CapturesForChannel(ctx, channelID, activeOnly)
+	if err != nil {
+		return apiErrorResult("failed to list captures", err)
+	}
+
+	return previewResult(ctx, t.confirmations, action, channelID, channel, map[string]interface{}{
+		"captures": captures,
+	})
+}
+
 // ListEncoderConfigs lists encoder configuration fragments
-func (t *LiveTools) ListEncoderConfigs(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *LiveTools) ListEncoderConfigs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v1/live/encoder-configs"
-	data, err := t.client.Get(endpoint)
+
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list encoder configs"), nil
+	}
+
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list encoder configs: %v", err)), nil
+		return apiErrorResult("failed to list encoder configs", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetEncoderConfig gets details of a specific encoder configuration
-func (t *LiveTools) GetEncoderConfig(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *LiveTools) GetEncoderConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	configID, ok := arguments["config_id"].(string)
 	if !ok || configID == "" {
-		return mcp.NewToolResultError("config_id is required"), nil
+		return invalidArgument("config_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v1/live/encoder-configs/%s", configID)
-	data, err := t.client.Get(endpoint)
+	config, err := t.live.GetEncoderConfig(ctx, configID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get encoder config: %v", err)), nil
+		return apiErrorResult("failed to get encoder config", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(config), nil
+}
+
+// CreateEncoderConfig creates a new encoder configuration fragment
+func (t *LiveTools) CreateEncoderConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateEncoderConfigRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.Description, _ = arguments["description"].(string)
+	settings, result := objectArgument(arguments, "settings")
+	if result != nil {
+		return result, nil
+	}
+	req.Settings = settings
+
+	config, err := t.live.CreateEncoderConfig(ctx, req)
+	if err != nil {
+		return apiErrorResult("failed to create encoder config", err), nil
+	}
+
+	return jsonResult(config), nil
+}
+
+// UpdateEncoderConfig updates an existing encoder configuration fragment
+func (t *LiveTools) UpdateEncoderConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	configID, ok := arguments["config_id"].(string)
+	if !ok || configID == "" {
+		return invalidArgument("config_id is required"), nil
+	}
+
+	req := m2a.UpdateEncoderConfigRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.Description, _ = arguments["description"].(string)
+	settings, result := objectArgument(arguments, "settings")
+	if result != nil {
+		return result, nil
+	}
+	req.Settings = settings
+
+	config, err := t.live.UpdateEncoderConfig(ctx, configID, req)
+	if err != nil {
+		return apiErrorResult("failed to update encoder config", err), nil
+	}
+
+	return jsonResult(config), nil
+}
+
+// DeleteEncoderConfig deletes an encoder configuration fragment. Without a
+// confirm_token it only previews the configuration and the channels that
+// use it.
+func (t *LiveTools) DeleteEncoderConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	configID, ok := arguments["config_id"].(string)
+	if !ok || configID == "" {
+		return invalidArgument("config_id is required"), nil
+	}
+
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		config, err := t.live.GetEncoderConfig(ctx, configID)
+		if err != nil {
+			return apiErrorResult("failed to get encoder config", err), nil
+		}
+
+		channels, err := t.live.ChannelsForEncoderConfig(ctx, configID)
+		if err != nil {
+			return apiErrorResult("failed to list channels", err), nil
+		}
+
+		return previewResult(ctx, t.confirmations, "delete_encoder_config", configID, config, map[string]interface{}{
+			"channels": channels,
+		}), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_encoder_config", configID, token); result != nil {
+		return result, nil
+	}
+
+	if err := t.live.DeleteEncoderConfig(ctx, configID); err != nil {
+		return apiErrorResult("failed to delete encoder config", err), nil
+	}
+
+	result := map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Encoder config %s deleted successfully", configID),
+	}
+	jsonData, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// CloneEncoderConfig creates a copy of an encoder configuration under a new
+// name, with overrides merged in
+func (t *LiveTools) CloneEncoderConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	configID, ok := arguments["config_id"].(string)
+	if !ok || configID == "" {
+		return invalidArgument("config_id is required"), nil
+	}
+	name, ok := arguments["name"].(string)
+	if !ok || name == "" {
+		return invalidArgument("name is required"), nil
+	}
+	overrides, result := objectArgument(arguments, "overrides")
+	if result != nil {
+		return result, nil
+	}
+
+	source, err := t.live.GetEncoderConfig(ctx, configID)
+	if err != nil {
+		return apiErrorResult("failed to get encoder config", err), nil
+	}
+	req, err := source.Clone(name, overrides)
+	if err != nil {
+		return apiErrorResult("failed to clone encoder config", err), nil
+	}
+
+	config, err := t.live.CreateEncoderConfig(ctx, req)
+	if err != nil {
+		return apiErrorResult("failed to create encoder config", err), nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"cloned_from":    configID,
+		"encoder_config": config,
+	}), nil
+}
+
+// DiffEncoderConfigs compares two encoder configurations field by field
+func (t *LiveTools) DiffEncoderConfigs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	idA, ok := arguments["config_id_a"].(string)
+	if !ok || idA == "" {
+		return invalidArgument("config_id_a is required"), nil
+	}
+	idB, ok := arguments["config_id_b"].(string)
+	if !ok || idB == "" {
+		return invalidArgument("config_id_b is required"), nil
+	}
+
+	a, err := t.live.GetEncoderConfig(ctx, idA)
+	if err != nil {
+		return apiErrorResult("failed to get encoder config", err), nil
+	}
+	b, err := t.live.GetEncoderConfig(ctx, idB)
+	if err != nil {
+		return apiErrorResult("failed to get encoder config", err), nil
+	}
+
+	changes, err := m2a.DiffEncoderConfigs(a, b)
+	if err != nil {
+		return apiErrorResult("failed to compare encoder configs", err), nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"a":         map[string]string{"id": a.ID, "name": a.Name},
+		"b":         map[string]string{"id": b.ID, "name": b.Name},
+		"identical": len(changes) == 0,
+		"changes":   changes,
+	}), nil
 }
 
 // ListWorkflows lists all live streaming workflows
-func (t *LiveTools) ListWorkflows(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *LiveTools) ListWorkflows(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v1/live/workflows"
-	data, err := t.client.Get(endpoint)
+
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list workflows"), nil
+	}
+
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list workflows: %v", err)), nil
+		return apiErrorResult("failed to list workflows", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetWorkflow gets details of a specific workflow
-func (t *LiveTools) GetWorkflow(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *LiveTools) GetWorkflow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	workflowID, ok := arguments["workflow_id"].(string)
 	if !ok || workflowID == "" {
-		return mcp.NewToolResultError("workflow_id is required"), nil
+		return invalidArgument("workflow_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v1/live/workflows/%s", workflowID)
-	data, err := t.client.Get(endpoint)
+	workflow, err := t.live.GetWorkflow(ctx, workflowID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get workflow: %v", err)), nil
+		return apiErrorResult("failed to get workflow", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(workflow), nil
 }
 
 // CreateWorkflow creates a new live streaming workflow
-func (t *LiveTools) CreateWorkflow(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	name, ok := arguments["name"].(string)
-	if !ok || name == "" {
-		return mcp.NewToolResultError("name is required"), nil
-	}
+func (t *LiveTools) CreateWorkflow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	req := m2a.CreateWorkflowRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.Description, _ = arguments["description"].(string)
 
-	body := map[string]interface{}{
-		"name": name,
+	workflow, err := t.live.CreateWorkflow(ctx, req)
+	if err != nil {
+		return apiErrorResult("failed to create workflow", err), nil
 	}
 
-	desc, _ := arguments["description"].(string)
-	if desc != "" {
-		body["description"] = desc
+	return jsonResult(workflow), nil
+}
+
+// UpdateWorkflow updates an existing live streaming workflow
+func (t *LiveTools) UpdateWorkflow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	workflowID, ok := arguments["workflow_id"].(string)
+	if !ok || workflowID == "" {
+		return invalidArgument("workflow_id is required"), nil
 	}
 
-	endpoint := "/api/v1/live/workflows"
-	data, err := t.client.Post(endpoint, body)
+	req := m2a.UpdateWorkflowRequest{}
+	req.Name, _ = arguments["name"].(string)
+	req.Description, _ = arguments["description"].(string)
+
+	workflow, err := t.live.UpdateWorkflow(ctx, workflowID, req)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create workflow: %v", err)), nil
+		return apiErrorResult("failed to update workflow", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(workflow), nil
+}
+
+// DeleteWorkflow deletes a live streaming workflow. Without a confirm_token
+// it only previews the workflow.
+func (t *LiveTools) DeleteWorkflow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
+	workflowID, ok := arguments["workflow_id"].(string)
+	if !ok || workflowID == "" {
+		return invalidArgument("workflow_id is required"), nil
+	}
+
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		workflow, err := t.live.GetWorkflow(ctx, workflowID)
+		if err != nil {
+			return apiErrorResult("failed to get workflow", err), nil
+		}
+
+		return previewResult(ctx, t.confirmations, "delete_workflow", workflowID, workflow, nil), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_workflow", workflowID, token); result != nil {
+		return result, nil
+	}
+
+	if err := t.live.DeleteWorkflow(ctx, workflowID); err != nil {
+		return apiErrorResult("failed to delete workflow", err), nil
+	}
+
+	result := map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Workflow %s deleted successfully", workflowID),
+	}
+	jsonData, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// objectArgument reads an optional JSON object argument. Clients that can't
+// send objects may pass it as a JSON string instead.
+func objectArgument(arguments map[string]interface{}, name string) (map[string]interface{}, *mcp.CallToolResult) {
+	switch v := arguments[name].(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return v, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &object); err != nil {
+			return nil, invalidArgument(fmt.Sprintf("%s must be a JSON object: %v", name, err))
+		}
+		return object, nil
+	default:
+		return nil, invalidArgument(name + " must be a JSON object")
+	}
 }
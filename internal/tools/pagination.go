@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+)
+
+// wantsAll reports whether a list tool was called with all=true
+func wantsAll(arguments map[string]interface{}) bool {
+	all, _ := arguments["all"].(bool)
+	return all
+}
+
+// listAll follows pagination on endpoint until the collection is exhausted
+// or max_items is reached, and returns the merged items with their count
+func listAll(ctx context.Context, c *client.M2AClient, endpoint string, arguments map[string]interface{}, action string) *mcp.CallToolResult {
+	opts := client.PaginateOptions{}
+	if maxItems, _ := arguments["max_items"].(float64); maxItems > 0 {
+		opts.MaxItems = int(maxItems)
+	}
+
+	items, err := client.ListAll[json.RawMessage](ctx, c, endpoint, opts)
+	if err != nil {
+		return apiErrorResult(action, err)
+	}
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+
+	result := map[string]interface{}{
+		"items": items,
+		"total": len(items),
+	}
+	if opts.MaxItems > 0 && len(items) >= opts.MaxItems {
+		result["truncated"] = true
+	}
+	jsonData, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(jsonData))
+}
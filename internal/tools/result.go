@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/jobs"
+)
+
+// jsonResult renders a typed API response as tool result text
+func jsonResult(v interface{}) *mcp.CallToolResult {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return newErrorResult(toolError{Kind: kindRequestFailed, Message: "failed to encode response: " + err.Error()})
+	}
+	return mcp.NewToolResultText(string(jsonData))
+}
+
+// jobResult renders a resource that started an asynchronous job, adding
+// the job's ID and state so the agent can follow it with get_job or wait_job
+func jobResult(v interface{}, job jobs.Job) *mcp.CallToolResult {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return newErrorResult(toolError{Kind: kindRequestFailed, Message: "failed to encode response: " + err.Error()})
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(jsonData, &fields); err != nil {
+		return newErrorResult(toolError{Kind: kindRequestFailed, Message: "failed to encode response: " + err.Error()})
+	}
+	fields["job_id"] = job.ID
+	fields["job_state"] = job.State
+	return jsonResult(fields)
+}
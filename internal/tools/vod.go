@@ -1,27 +1,37 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/confirm"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
 )
 
 // VODTools handles M2A VOD API operations
 type VODTools struct {
-	client *client.M2AClient
+	client        *client.M2AClient
+	vod           *m2a.VODService
+	confirmations *confirm.Store
 }
 
 // NewVODTools creates a new VODTools instance
-func NewVODTools(client *client.M2AClient) *VODTools {
-	return &VODTools{client: client}
+func NewVODTools(client *client.M2AClient, confirmations *confirm.Store) *VODTools {
+	return &VODTools{client: client, vod: m2a.NewVODService(client), confirmations: confirmations}
 }
 
 // ListVODAssets lists all VOD assets
-func (t *VODTools) ListVODAssets(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *VODTools) ListVODAssets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	endpoint := "/api/v1/vod/assets"
 
+	if wantsAll(arguments) {
+		return listAll(ctx, t.client, endpoint, arguments, "failed to list VOD assets"), nil
+	}
+
 	queryParams := ""
 	if limit, _ := arguments["limit"].(float64); limit > 0 {
 		queryParams += fmt.Sprintf("?limit=%d", int(limit))
@@ -36,72 +46,74 @@ func (t *VODTools) ListVODAssets(arguments map[string]interface{}) (*mcp.CallToo
 	}
 	endpoint += queryParams
 
-	data, err := t.client.Get(endpoint)
+	data, err := t.client.Get(ctx, endpoint)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list VOD assets: %v", err)), nil
+		return apiErrorResult("failed to list VOD assets", err), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 // GetVODAsset gets details of a specific VOD asset
-func (t *VODTools) GetVODAsset(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *VODTools) GetVODAsset(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	assetID, ok := arguments["asset_id"].(string)
 	if !ok || assetID == "" {
-		return mcp.NewToolResultError("asset_id is required"), nil
+		return invalidArgument("asset_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v1/vod/assets/%s", assetID)
-	data, err := t.client.Get(endpoint)
+	asset, err := t.vod.GetAsset(ctx, assetID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get VOD asset: %v", err)), nil
+		return apiErrorResult("failed to get VOD asset", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(asset), nil
 }
 
 // UpdateVODMetadata updates metadata for a VOD asset
-func (t *VODTools) UpdateVODMetadata(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *VODTools) UpdateVODMetadata(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	assetID, ok := arguments["asset_id"].(string)
 	if !ok || assetID == "" {
-		return mcp.NewToolResultError("asset_id is required"), nil
-	}
-
-	body := make(map[string]interface{})
-	if title, _ := arguments["title"].(string); title != "" {
-		body["title"] = title
-	}
-	if desc, _ := arguments["description"].(string); desc != "" {
-		body["description"] = desc
-	}
-	if tags, _ := arguments["tags"].(string); tags != "" {
-		body["tags"] = tags
+		return invalidArgument("asset_id is required"), nil
 	}
 
-	if len(body) == 0 {
-		return mcp.NewToolResultError("at least one metadata field is required"), nil
-	}
+	req := m2a.UpdateVODMetadataRequest{}
+	req.Title, _ = arguments["title"].(string)
+	req.Description, _ = arguments["description"].(string)
+	req.Tags, _ = arguments["tags"].(string)
 
-	endpoint := fmt.Sprintf("/api/v1/vod/assets/%s", assetID)
-	data, err := t.client.Put(endpoint, body)
+	asset, err := t.vod.UpdateMetadata(ctx, assetID, req)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to update VOD metadata: %v", err)), nil
+		return apiErrorResult("failed to update VOD metadata", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(asset), nil
 }
 
-// DeleteVODAsset deletes a VOD asset
-func (t *VODTools) DeleteVODAsset(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+// DeleteVODAsset deletes a VOD asset. Without a confirm_token it only
+// previews the asset.
+func (t *VODTools) DeleteVODAsset(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	assetID, ok := arguments["asset_id"].(string)
 	if !ok || assetID == "" {
-		return mcp.NewToolResultError("asset_id is required"), nil
+		return invalidArgument("asset_id is required"), nil
 	}
 
-	endpoint := fmt.Sprintf("/api/v1/vod/assets/%s", assetID)
-	_, err := t.client.Delete(endpoint)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to delete VOD asset: %v", err)), nil
+	token, _ := arguments["confirm_token"].(string)
+	if token == "" {
+		asset, err := t.vod.GetAsset(ctx, assetID)
+		if err != nil {
+			return apiErrorResult("failed to get VOD asset", err), nil
+		}
+		return previewResult(ctx, t.confirmations, "delete_vod_asset", assetID, asset, nil), nil
+	}
+	if result := redeemConfirmation(ctx, t.confirmations, "delete_vod_asset", assetID, token); result != nil {
+		return result, nil
+	}
+
+	if err := t.vod.DeleteAsset(ctx, assetID); err != nil {
+		return apiErrorResult("failed to delete VOD asset", err), nil
 	}
 
 	result := map[string]interface{}{
@@ -113,10 +125,11 @@ func (t *VODTools) DeleteVODAsset(arguments map[string]interface{}) (*mcp.CallTo
 }
 
 // GetPlaybackURL gets streaming playback URL for a VOD asset
-func (t *VODTools) GetPlaybackURL(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+func (t *VODTools) GetPlaybackURL(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	arguments := request.GetArguments()
 	assetID, ok := arguments["asset_id"].(string)
 	if !ok || assetID == "" {
-		return mcp.NewToolResultError("asset_id is required"), nil
+		return invalidArgument("asset_id is required"), nil
 	}
 
 	format, _ := arguments["format"].(string)
@@ -124,11 +137,10 @@ func (t *VODTools) GetPlaybackURL(arguments map[string]interface{}) (*mcp.CallTo
 		format = "hls" // default format
 	}
 
-	endpoint := fmt.Sprintf("/api/v1/vod/assets/%s/playback?format=%s", assetID, format)
-	data, err := t.client.Get(endpoint)
+	playback, err := t.vod.GetPlayback(ctx, assetID, format)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get playback URL: %v", err)), nil
+		return apiErrorResult("failed to get playback URL", err), nil
 	}
 
-	return mcp.NewToolResultText(string(data)), nil
+	return jsonResult(playback), nil
 }
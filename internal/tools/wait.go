@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// defaultWaitTimeout bounds a wait=true call that doesn't set timeout_seconds
+	defaultWaitTimeout = 5 * time.Minute
+
+	// maxWaitTimeout is the longest timeout_seconds a caller may ask for
+	maxWaitTimeout = 30 * time.Minute
+
+	// methodProgress is the MCP progress notification
+	methodProgress = "notifications/progress"
+)
+
+// waitingTools always wait, rather than only when called with wait=true
+var waitingTools = map[string]bool{"wait_job": true}
+
+// WaitTimeout reports whether a tool call will wait for an operation to
+// finish, and if so for how long. Tool deadlines are extended by this much
+// so the wait isn't cut short.
+func WaitTimeout(request mcp.CallToolRequest) (time.Duration, bool) {
+	arguments := request.GetArguments()
+	if wait, _ := arguments["wait"].(bool); !wait && !waitingTools[request.Params.Name] {
+		return 0, false
+	}
+
+	seconds, ok := arguments["timeout_seconds"].(float64)
+	if !ok || seconds <= 0 {
+		return defaultWaitTimeout, true
+	}
+	return min(time.Duration(seconds*float64(time.Second)), maxWaitTimeout), true
+}
+
+// progressReporter returns a function that sends MCP progress notifications
+// for a tool call. It does nothing if the client didn't ask for progress.
+func progressReporter(ctx context.Context, request mcp.CallToolRequest) func(progress, total float64, message string) {
+	mcpServer := server.ServerFromContext(ctx)
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil || mcpServer == nil {
+		return func(float64, float64, string) {}
+	}
+
+	token := request.Params.Meta.ProgressToken
+	return func(progress, total float64, message string) {
+		err := mcpServer.SendNotificationToClient(ctx, methodProgress, map[string]interface{}{
+			"progressToken": token,
+			"progress":      progress,
+			"total":         total,
+			"message":       message,
+		})
+		if err != nil {
+			log.Printf("Failed to send progress notification: %v", err)
+		}
+	}
+}
+
+// waitResult describes an operation the tool waited for
+func waitResult(resource string, v interface{}, state string, took time.Duration) *mcp.CallToolResult {
+	return jsonResult(map[string]interface{}{
+		resource:         v,
+		"final_state":    state,
+		"waited_seconds": took.Seconds(),
+		"message":        fmt.Sprintf("%s reached %s after %s", resource, state, took.Round(time.Second)),
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/auth"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/fake"
+)
+
+// testSession is an MCP session that collects its notifications
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// A finished job is reported only to the sessions of the client that
+// started it
+func TestJobNotifications(t *testing.T) {
+	_, httpServer := fake.NewTestServer(fake.Options{APIKey: "test-key", Seed: true})
+	defer httpServer.Close()
+	cfg := testConfig(httpServer.URL)
+	svc, err := newServer(cfg, client.NewM2AClient(cfg), nil)
+	if err != nil {
+		t.Fatalf("newServer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.run(ctx)
+
+	sessions := map[string]*testSession{}
+	for _, name := range []string{"alice", "alice-laptop", "bob"} {
+		sessions[name] = &testSession{id: name, notifications: make(chan mcp.JSONRPCNotification, 10)}
+		if err := svc.mcpServer.RegisterSession(ctx, sessions[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	call := func(session, client, tool string, args map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		message, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params":  map[string]interface{}{"name": tool, "arguments": args},
+		})
+		callCtx := auth.WithIdentity(svc.mcpServer.WithContext(ctx, sessions[session]), auth.Identity{Name: client, Transport: transportHTTP})
+		response, ok := svc.mcpServer.HandleMessage(callCtx, message).(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("%s: no result", tool)
+		}
+		result, ok := response.Result.(*mcp.CallToolResult)
+		if !ok || result.IsError {
+			t.Fatalf("%s failed: %+v", tool, response.Result)
+		}
+		var output map[string]interface{}
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &output); err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+
+	call("alice-laptop", "alice", "list_jobs", map[string]interface{}{})
+	call("bob", "bob", "list_jobs", map[string]interface{}{})
+	// The capture window has passed, so the job completes on the next poll
+	call("alice", "alice", "create_capture", map[string]interface{}{
+		"name": "Lunchtime News", "channel_id": "ch-0002",
+		"start_time": "2025-10-01T12:30:00Z", "end_time": "2025-10-01T13:00:00Z",
+	})
+
+	for _, name := range []string{"alice", "alice-laptop"} {
+		select {
+		case notification := <-sessions[name].notifications:
+			data := fmt.Sprint(notification.Params.AdditionalFields["data"])
+			if notification.Method != "notifications/message" || notification.Params.AdditionalFields["logger"] != "m2a.jobs" {
+				t.Errorf("%s got %s %s", name, notification.Method, data)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s wasn't told the job finished", name)
+		}
+	}
+	select {
+	case notification := <-sessions["bob"].notifications:
+		t.Errorf("bob was told about alice's job: %+v", notification.Params.AdditionalFields)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
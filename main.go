@@ -1,12 +1,27 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"net/http"
+	"sort"
+	"sync"
+	// Recurring schedules need time zones even on hosts without zoneinfo
+	_ "time/tzdata"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/andy-wilson/m2a-mcp/internal/audit"
+	"github.com/andy-wilson/m2a-mcp/internal/auth"
+	"github.com/andy-wilson/m2a-mcp/internal/cassette"
 	"github.com/andy-wilson/m2a-mcp/internal/client"
 	"github.com/andy-wilson/m2a-mcp/internal/config"
+	"github.com/andy-wilson/m2a-mcp/internal/confirm"
+	"github.com/andy-wilson/m2a-mcp/internal/jobs"
+	"github.com/andy-wilson/m2a-mcp/internal/m2a"
+	"github.com/andy-wilson/m2a-mcp/internal/prompts"
+	"github.com/andy-wilson/m2a-mcp/internal/resources"
 	"github.com/andy-wilson/m2a-mcp/internal/tools"
 )
 
@@ -16,46 +31,254 @@ const (
 )
 
 func main() {
+	transport := flag.String("transport", "", "MCP transport: stdio or http (default from M2A_TRANSPORT, else stdio)")
+	listenAddr := flag.String("listen", "", "Listen address for the http transport (default from M2A_LISTEN_ADDR, else :8080)")
+	readOnly := flag.Bool("read-only", false, "Only register list_* and get_* tools and refuse mutating API calls (also M2A_READ_ONLY)")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Command-line flags take precedence over the environment
+	if *transport != "" {
+		cfg.Transport = *transport
+	}
+	if *listenAddr != "" {
+		cfg.ListenAddr = *listenAddr
+	}
+	if *readOnly {
+		cfg.ReadOnly = true
+	}
+
+	// Open the audit log
+	auditLog, err := audit.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer auditLog.Close()
+
 	// Create M2A API client
 	m2aClient := client.NewM2AClient(cfg)
+	m2aClient.SetObserver(auditLog.ObserveRequest)
 
-	// Create MCP server
+	// Record responses to, or replay them from, a cassette file
+	cassetteTransport, err := cassette.FromConfig(cfg, http.DefaultTransport)
+	if err != nil {
+		log.Fatalf("Failed to set up cassette: %v", err)
+	}
+	if cassetteTransport != nil {
+		m2aClient.SetHTTPClient(&http.Client{Transport: cassetteTransport})
+		log.Printf("Cassette %s mode: %s", cfg.CassetteMode, cfg.CassettePath)
+	}
+
+	// Create MCP server with all tools, resources and prompts registered
+	svc, err := newServer(cfg, m2aClient, auditLog)
+	if err != nil {
+		log.Fatalf("Failed to create MCP server: %v", err)
+	}
+
+	// Start server with the configured transport
+	switch cfg.Transport {
+	case transportStdio:
+		err = serveStdio(svc)
+	case transportHTTP:
+		err = serveHTTP(svc, cfg)
+	default:
+		log.Fatalf("Unknown transport %q (expected %s or %s)", cfg.Transport, transportStdio, transportHTTP)
+	}
+	if err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+}
+
+// service is the MCP server together with the background work it relies on
+type service struct {
+	mcpServer *server.MCPServer
+	// resources answers resource subscriptions, which the transports route to it
+	resources *resources.Resources
+	jobs      *jobs.Tracker
+}
+
+// run polls subscribed resources and running jobs until ctx is cancelled
+func (s *service) run(ctx context.Context) {
+	go s.resources.Run(ctx)
+	go s.jobs.Run(ctx)
+}
+
+// newServer creates the MCP server with its middleware and registers every
+// tool, resource and prompt
+func newServer(cfg *config.Config, m2aClient *client.M2AClient, auditLog *audit.Logger) (*service, error) {
+	tracker, err := jobs.New(m2aClient, cfg.JobsFile, cfg.JobPollInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	m2aResources := resources.New(m2aClient, cfg.ResourcePollInterval)
+	sessions := &clientSessions{names: make(map[string]string)}
+	hooks := m2aResources.Hooks()
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		sessions.forget(session.SessionID())
+	})
 	mcpServer := server.NewMCPServer(
 		serverName,
 		serverVersion,
+		server.WithResourceCapabilities(true, false),
+		server.WithLogging(),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(identityMiddleware(cfg, sessions)),
+		server.WithToolHandlerMiddleware(auditLog.ToolMiddleware()),
+		server.WithToolHandlerMiddleware(toolTimeoutMiddleware(cfg)),
 	)
 
-	// Register all tools
-	if err := registerTools(mcpServer, m2aClient); err != nil {
-		log.Fatalf("Failed to register tools: %v", err)
+	// Tell the client that started a job when it finishes. Other clients
+	// aren't told, as the job is none of their business.
+	tracker.OnFinish(func(job jobs.Job) {
+		level := mcp.LoggingLevelInfo
+		if job.State != jobs.StateSucceeded {
+			level = mcp.LoggingLevelError
+		}
+		params := map[string]any{
+			"level":  level,
+			"logger": "m2a.jobs",
+			"data":   job,
+		}
+		for _, sessionID := range sessions.of(job.StartedBy) {
+			if err := mcpServer.SendNotificationToSpecificClient(sessionID, "notifications/message", params); err != nil {
+				log.Printf("Failed to notify session %s that job %s finished: %v", sessionID, job.ID, err)
+			}
+		}
+	})
+
+	if err := registerTools(mcpServer, m2aClient, tracker, cfg); err != nil {
+		return nil, err
 	}
+	m2aResources.Register(mcpServer)
 
-	// Start server with stdio transport
-	if err := server.ServeStdio(mcpServer); err != nil {
-		log.Fatalf("Server error: %v", err)
+	// Prompts are registered last, as they're skipped if their tools aren't
+	runbooks, err := prompts.Load(cfg.PromptsDir)
+	if err != nil {
+		return nil, err
+	}
+	prompts.Register(mcpServer, runbooks)
+	return &service{mcpServer: mcpServer, resources: m2aResources, jobs: tracker}, nil
+}
+
+// identityMiddleware makes sure every tool call carries a client identity.
+// The http transport attaches the authenticated caller; anything else is the
+// local stdio client named by cfg.ClientName. The calling session is
+// recorded as belonging to that client.
+func identityMiddleware(cfg *config.Config, sessions *clientSessions) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			identity, ok := auth.FromContext(ctx)
+			if !ok {
+				identity = auth.Identity{Name: cfg.ClientName, Transport: transportStdio}
+				ctx = auth.WithIdentity(ctx, identity)
+			}
+			if session := server.ClientSessionFromContext(ctx); session != nil {
+				sessions.record(session.SessionID(), identity.Name)
+			}
+			return next(ctx, request)
+		}
 	}
 }
 
-func registerTools(s *server.MCPServer, client *client.M2AClient) error {
+// clientSessions remembers which client each session belongs to, learnt
+// from its tool calls, so notifications about a client's jobs go only to
+// that client's sessions
+type clientSessions struct {
+	mu    sync.Mutex
+	names map[string]string
+}
+
+// record notes that sessionID belongs to the named client
+func (c *clientSessions) record(sessionID, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.names[sessionID] = name
+}
+
+// forget drops a session that has gone away
+func (c *clientSessions) forget(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.names, sessionID)
+}
+
+// of lists the sessions belonging to the named client
+func (c *clientSessions) of(name string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var sessionIDs []string
+	for sessionID, client := range c.names {
+		if client == name {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	sort.Strings(sessionIDs)
+	return sessionIDs
+}
+
+// toolTimeoutMiddleware bounds each tool call by its configured deadline,
+// extended by the wait for calls made with wait=true. The context also
+// carries cancellation from the MCP client, so either one aborts any
+// in-flight API request.
+func toolTimeoutMiddleware(cfg *config.Config) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if timeout := cfg.TimeoutFor(request.Params.Name); timeout > 0 {
+				if wait, ok := tools.WaitTimeout(request); ok {
+					timeout += wait
+				}
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// isReadOnlyTool reports whether a tool is annotated as not modifying anything
+func isReadOnlyTool(tool mcp.Tool) bool {
+	return tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+}
+
+func registerTools(s *server.MCPServer, client *client.M2AClient, tracker *jobs.Tracker, cfg *config.Config) error {
+	// In read-only mode only tools annotated as read-only are registered, so
+	// mutating tools are neither listed nor callable
+	addTool := func(tool mcp.Tool, handler server.ToolHandlerFunc) {
+		if cfg.ReadOnly && !isReadOnlyTool(tool) {
+			return
+		}
+		s.AddTool(tool, handler)
+	}
+
+	// Destructive tools preview first and act only when called again with
+	// the confirmation token from the preview
+	confirmations := confirm.NewStore(cfg.ConfirmTTL)
+
 	// M2A Connect tools
-	connectTools := tools.NewConnectTools(client)
-	s.AddTool(mcp.NewTool("list_sources",
+	connectTools := tools.NewConnectTools(client, confirmations)
+	addTool(mcp.NewTool("list_sources",
 		mcp.WithDescription("List all video sources in M2A Connect"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("status", mcp.Description("Filter by status (active, inactive, all)"), mcp.Enum("active", "inactive", "all")),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), connectTools.ListSources)
 
-	s.AddTool(mcp.NewTool("get_source",
+	addTool(mcp.NewTool("get_source",
 		mcp.WithDescription("Get details of a specific video source"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("source_id", mcp.Required(), mcp.Description("The ID of the source")),
 	), connectTools.GetSource)
 
-	s.AddTool(mcp.NewTool("create_source",
+	addTool(mcp.NewTool("create_source",
 		mcp.WithDescription("Create a new video source in M2A Connect"),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Name of the source")),
 		mcp.WithString("type", mcp.Required(), mcp.Description("Source type (rtmp, srt, udp, etc.)"), mcp.Enum("rtmp", "srt", "udp", "rtp")),
@@ -63,7 +286,7 @@ func registerTools(s *server.MCPServer, client *client.M2AClient) error {
 		mcp.WithString("description", mcp.Description("Optional description")),
 	), connectTools.CreateSource)
 
-	s.AddTool(mcp.NewTool("update_source",
+	addTool(mcp.NewTool("update_source",
 		mcp.WithDescription("Update an existing video source"),
 		mcp.WithString("source_id", mcp.Required(), mcp.Description("The ID of the source")),
 		mcp.WithString("name", mcp.Description("New name for the source")),
@@ -71,178 +294,384 @@ func registerTools(s *server.MCPServer, client *client.M2AClient) error {
 		mcp.WithString("description", mcp.Description("New description")),
 	), connectTools.UpdateSource)
 
-	s.AddTool(mcp.NewTool("delete_source",
-		mcp.WithDescription("Delete a video source"),
+	addTool(mcp.NewTool("delete_source",
+		mcp.WithDescription("Delete a video source. The first call returns a preview (the source and the subscriptions and schedules that reference it) and a confirm_token; call again with the token to delete."),
 		mcp.WithString("source_id", mcp.Required(), mcp.Description("The ID of the source to delete")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
 	), connectTools.DeleteSource)
 
-	s.AddTool(mcp.NewTool("list_subscribers",
+	addTool(mcp.NewTool("list_subscribers",
 		mcp.WithDescription("List all subscribers in M2A Connect"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithNumber("limit", mcp.Description("Maximum number of results to return")),
 		mcp.WithNumber("offset", mcp.Description("Offset for pagination")),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), connectTools.ListSubscribers)
 
-	s.AddTool(mcp.NewTool("get_subscriber",
+	addTool(mcp.NewTool("get_subscriber",
 		mcp.WithDescription("Get details of a specific subscriber"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("subscriber_id", mcp.Required(), mcp.Description("The ID of the subscriber")),
 	), connectTools.GetSubscriber)
 
-	s.AddTool(mcp.NewTool("create_subscriber",
+	addTool(mcp.NewTool("create_subscriber",
 		mcp.WithDescription("Create a new subscriber"),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Subscriber name")),
 		mcp.WithString("email", mcp.Required(), mcp.Description("Subscriber email")),
 		mcp.WithString("organization", mcp.Description("Organization name")),
 	), connectTools.CreateSubscriber)
 
-	s.AddTool(mcp.NewTool("list_subscriptions",
+	addTool(mcp.NewTool("update_subscriber",
+		mcp.WithDescription("Update an existing subscriber"),
+		mcp.WithString("subscriber_id", mcp.Required(), mcp.Description("The ID of the subscriber")),
+		mcp.WithString("name", mcp.Description("New subscriber name")),
+		mcp.WithString("email", mcp.Description("New subscriber email")),
+		mcp.WithString("organization", mcp.Description("New organization name")),
+	), connectTools.UpdateSubscriber)
+
+	addTool(mcp.NewTool("delete_subscriber",
+		mcp.WithDescription("Delete a subscriber. The first call returns a preview (the subscriber and its subscriptions) and a confirm_token; call again with the token to delete."),
+		mcp.WithString("subscriber_id", mcp.Required(), mcp.Description("The ID of the subscriber to delete")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
+	), connectTools.DeleteSubscriber)
+
+	addTool(mcp.NewTool("list_subscriptions",
 		mcp.WithDescription("List all subscription packages"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), connectTools.ListSubscriptions)
 
-	s.AddTool(mcp.NewTool("get_subscription",
+	addTool(mcp.NewTool("get_subscription",
 		mcp.WithDescription("Get details of a specific subscription package"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("subscription_id", mcp.Required(), mcp.Description("The ID of the subscription")),
 	), connectTools.GetSubscription)
 
-	s.AddTool(mcp.NewTool("create_subscription",
+	addTool(mcp.NewTool("create_subscription",
 		mcp.WithDescription("Create a new subscription package"),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Subscription name")),
 		mcp.WithString("subscriber_id", mcp.Required(), mcp.Description("Subscriber ID")),
 		mcp.WithString("source_ids", mcp.Required(), mcp.Description("Comma-separated list of source IDs")),
 	), connectTools.CreateSubscription)
 
-	s.AddTool(mcp.NewTool("list_schedules",
+	addTool(mcp.NewTool("update_subscription",
+		mcp.WithDescription("Update an existing subscription package"),
+		mcp.WithString("subscription_id", mcp.Required(), mcp.Description("The ID of the subscription")),
+		mcp.WithString("name", mcp.Description("New subscription name")),
+		mcp.WithString("source_ids", mcp.Description("Comma-separated list of source IDs, replacing the current ones")),
+	), connectTools.UpdateSubscription)
+
+	addTool(mcp.NewTool("delete_subscription",
+		mcp.WithDescription("Delete a subscription package. The first call returns a preview of the subscription and a confirm_token; call again with the token to delete."),
+		mcp.WithString("subscription_id", mcp.Required(), mcp.Description("The ID of the subscription to delete")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
+	), connectTools.DeleteSubscription)
+
+	addTool(mcp.NewTool("list_schedules",
 		mcp.WithDescription("List all scheduled events"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("start_date", mcp.Description("Filter by start date (ISO 8601 format)")),
 		mcp.WithString("end_date", mcp.Description("Filter by end date (ISO 8601 format)")),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), connectTools.ListSchedules)
 
-	s.AddTool(mcp.NewTool("get_schedule",
+	addTool(mcp.NewTool("get_schedule",
 		mcp.WithDescription("Get details of a specific schedule"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("schedule_id", mcp.Required(), mcp.Description("The ID of the schedule")),
 	), connectTools.GetSchedule)
 
-	s.AddTool(mcp.NewTool("create_schedule",
-		mcp.WithDescription("Create a new scheduled event"),
+	addTool(mcp.NewTool("create_schedule",
+		mcp.WithDescription("Create a new scheduled event. Fails with a conflict listing the clashing schedule IDs if the source is already booked for any of the window, unless allow_overlap is set."),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Schedule name")),
 		mcp.WithString("source_id", mcp.Required(), mcp.Description("Source ID")),
 		mcp.WithString("start_time", mcp.Required(), mcp.Description("Start time (ISO 8601 format)")),
-		mcp.WithString("end_time", mcp.Required(), mcp.Description("End time (ISO 8601 format)")),
+		mcp.WithString("end_time", mcp.Required(), mcp.Description("End time (ISO 8601 format), after start_time")),
+		mcp.WithBoolean("allow_overlap", mcp.Description("Create the schedule even if it overlaps others for the same source; they are listed in overlaps_with")),
 	), connectTools.CreateSchedule)
 
+	addTool(mcp.NewTool("update_schedule",
+		mcp.WithDescription("Update an existing scheduled event. A new source or window is checked for clashes with the source's other schedules like create_schedule."),
+		mcp.WithString("schedule_id", mcp.Required(), mcp.Description("The ID of the schedule")),
+		mcp.WithString("name", mcp.Description("New schedule name")),
+		mcp.WithString("source_id", mcp.Description("New source ID")),
+		mcp.WithString("start_time", mcp.Description("New start time (ISO 8601 format)")),
+		mcp.WithString("end_time", mcp.Description("New end time (ISO 8601 format)")),
+		mcp.WithBoolean("allow_overlap", mcp.Description("Save the change even if it overlaps other schedules for the source; they are listed in overlaps_with")),
+	), connectTools.UpdateSchedule)
+
+	addTool(mcp.NewTool("delete_schedule",
+		mcp.WithDescription("Delete a scheduled event. The first call returns a preview of the schedule and a confirm_token; call again with the token to delete."),
+		mcp.WithString("schedule_id", mcp.Required(), mcp.Description("The ID of the schedule to delete")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
+	), connectTools.DeleteSchedule)
+
+	addTool(mcp.NewTool("create_recurring_schedule",
+		mcp.WithDescription("Create a series of scheduled events from an iCalendar RRULE, e.g. a weekly fixture or daily bulletins. The rule is expanded into occurrences up to horizon_days ahead, each is checked for clashes like create_schedule, and one schedule is created per occurrence, all tagged with a series_id for the *_schedule_series tools. Use dry_run to preview the occurrences first."),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Name for every schedule in the series")),
+		mcp.WithString("source_id", mcp.Required(), mcp.Description("Source ID")),
+		mcp.WithString("start_time", mcp.Required(), mcp.Description("Start of the first occurrence (DTSTART), as local time in timezone (2025-10-04T15:00:00) or with an offset")),
+		mcp.WithString("duration", mcp.Required(), mcp.Description("Length of each occurrence, e.g. 2h or 01:30:00")),
+		mcp.WithString("rrule", mcp.Required(), mcp.Description("iCalendar recurrence rule, e.g. FREQ=WEEKLY;BYDAY=SA;COUNT=10 or FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;BYHOUR=9,18")),
+		mcp.WithString("timezone", mcp.Description("IANA time zone the rule repeats in, e.g. Europe/London (default UTC). Occurrences keep their local time across daylight saving changes.")),
+		mcp.WithString("exdates", mcp.Description("Comma-separated exclusions (EXDATE): a time skips that occurrence, a date (2025-12-25) skips every occurrence that day")),
+		mcp.WithNumber("horizon_days", mcp.Description("How far ahead to expand an open-ended rule (default 90, at most 366)")),
+		mcp.WithBoolean("dry_run", mcp.Description("Only list the occurrences and any clashes, without creating anything")),
+		mcp.WithBoolean("allow_overlap", mcp.Description("Create occurrences even if they overlap other schedules for the source; they are listed in overlaps_with")),
+	), connectTools.CreateRecurringSchedule)
+
+	addTool(mcp.NewTool("list_schedule_series",
+		mcp.WithDescription("List the scheduled events in a series created by create_recurring_schedule, earliest first"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("series_id", mcp.Required(), mcp.Description("The ID of the series")),
+		mcp.WithString("from", mcp.Description("Only list occurrences starting at or after this time (ISO 8601 format)")),
+	), connectTools.ListScheduleSeries)
+
+	addTool(mcp.NewTool("update_schedule_series",
+		mcp.WithDescription("Update every scheduled event in a series alike: rename it, move it to another source, shift it or change its length. Changed windows are checked for clashes like create_schedule, and a clash fails the whole update."),
+		mcp.WithString("series_id", mcp.Required(), mcp.Description("The ID of the series")),
+		mcp.WithString("name", mcp.Description("New name for every schedule")),
+		mcp.WithString("source_id", mcp.Description("New source ID")),
+		mcp.WithString("shift", mcp.Description("Move every occurrence by this much, e.g. 30m, -15m or 01:00:00")),
+		mcp.WithString("duration", mcp.Description("New length of every occurrence, keeping its start, e.g. 2h30m")),
+		mcp.WithString("from", mcp.Description("Only change occurrences starting at or after this time (ISO 8601 format)")),
+		mcp.WithBoolean("dry_run", mcp.Description("Only report what each occurrence would become and any clashes")),
+		mcp.WithBoolean("allow_overlap", mcp.Description("Save the changes even if they overlap other schedules for the source; they are listed in overlaps_with")),
+	), connectTools.UpdateScheduleSeries)
+
+	addTool(mcp.NewTool("delete_schedule_series",
+		mcp.WithDescription("Cancel a series by deleting its scheduled events. The first call returns a preview of the schedules and a confirm_token; call again with the same arguments and the token to delete."),
+		mcp.WithString("series_id", mcp.Required(), mcp.Description("The ID of the series")),
+		mcp.WithString("from", mcp.Description("Only delete occurrences starting at or after this time (ISO 8601 format), leaving earlier ones")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
+	), connectTools.DeleteScheduleSeries)
+
 	// M2A Live tools
-	liveTools := tools.NewLiveTools(client)
-	s.AddTool(mcp.NewTool("list_channels",
+	liveTools := tools.NewLiveTools(client, confirmations)
+	addTool(mcp.NewTool("list_channels",
 		mcp.WithDescription("List all MediaLive channels"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("state", mcp.Description("Filter by channel state"), mcp.Enum("IDLE", "CREATING", "STARTING", "RUNNING", "STOPPING", "DELETING")),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), liveTools.ListChannels)
 
-	s.AddTool(mcp.NewTool("get_channel",
+	addTool(mcp.NewTool("get_channel",
 		mcp.WithDescription("Get details of a specific MediaLive channel"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The ID of the channel")),
 	), liveTools.GetChannel)
 
-	s.AddTool(mcp.NewTool("create_channel",
+	addTool(mcp.NewTool("create_channel",
 		mcp.WithDescription("Create a new MediaLive channel"),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Channel name")),
 		mcp.WithString("input_type", mcp.Required(), mcp.Description("Input type"), mcp.Enum("RTMP_PUSH", "RTP_PUSH", "UDP_PUSH", "MEDIACONNECT")),
 		mcp.WithString("encoder_config_id", mcp.Description("Encoder configuration ID to use")),
 	), liveTools.CreateChannel)
 
-	s.AddTool(mcp.NewTool("start_channel",
-		mcp.WithDescription("Start a MediaLive channel"),
+	addTool(mcp.NewTool("start_channel",
+		mcp.WithDescription("Start a MediaLive channel. With wait=true, polls until the channel is RUNNING, sending progress notifications, and returns the final state and how long it took."),
 		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The ID of the channel to start")),
+		mcp.WithBoolean("wait", mcp.Description("Wait until the channel is RUNNING or the transition fails")),
+		mcp.WithNumber("timeout_seconds", mcp.Description("How long to wait when wait=true (default 300, at most 1800)")),
 	), liveTools.StartChannel)
 
-	s.AddTool(mcp.NewTool("stop_channel",
-		mcp.WithDescription("Stop a MediaLive channel"),
+	addTool(mcp.NewTool("stop_channel",
+		mcp.WithDescription("Stop a MediaLive channel. The first call returns a preview (the channel's state and captures recording from it) and a confirm_token; call again with the token to stop. With wait=true, the confirmed call polls until the channel is IDLE, sending progress notifications."),
 		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The ID of the channel to stop")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
+		mcp.WithBoolean("wait", mcp.Description("Wait until the channel is IDLE or the transition fails")),
+		mcp.WithNumber("timeout_seconds", mcp.Description("How long to wait when wait=true (default 300, at most 1800)")),
 	), liveTools.StopChannel)
 
-	s.AddTool(mcp.NewTool("delete_channel",
-		mcp.WithDescription("Delete a MediaLive channel"),
+	addTool(mcp.NewTool("delete_channel",
+		mcp.WithDescription("Delete a MediaLive channel. The first call returns a preview (the channel's state and captures that reference it) and a confirm_token; call again with the token to delete."),
 		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The ID of the channel to delete")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
 	), liveTools.DeleteChannel)
 
-	s.AddTool(mcp.NewTool("list_encoder_configs",
+	addTool(mcp.NewTool("list_encoder_configs",
 		mcp.WithDescription("List encoder configuration fragments"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), liveTools.ListEncoderConfigs)
 
-	s.AddTool(mcp.NewTool("get_encoder_config",
+	addTool(mcp.NewTool("get_encoder_config",
 		mcp.WithDescription("Get details of a specific encoder configuration"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("config_id", mcp.Required(), mcp.Description("The ID of the encoder configuration")),
 	), liveTools.GetEncoderConfig)
 
-	s.AddTool(mcp.NewTool("list_workflows",
+	addTool(mcp.NewTool("create_encoder_config",
+		mcp.WithDescription("Create an encoder configuration fragment"),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Encoder configuration name")),
+		mcp.WithString("description", mcp.Description("Encoder configuration description")),
+		mcp.WithObject("settings", mcp.Description("The configuration itself, e.g. {\"video\": {\"codec\": \"H.264\", \"ladder\": [...]}}; sent as top-level fields")),
+	), liveTools.CreateEncoderConfig)
+
+	addTool(mcp.NewTool("update_encoder_config",
+		mcp.WithDescription("Update an encoder configuration fragment. Each top-level field in settings replaces the existing field of that name."),
+		mcp.WithString("config_id", mcp.Required(), mcp.Description("The ID of the encoder configuration")),
+		mcp.WithString("name", mcp.Description("New name")),
+		mcp.WithString("description", mcp.Description("New description")),
+		mcp.WithObject("settings", mcp.Description("Top-level fields to replace, e.g. {\"audio\": {\"codec\": \"AAC\", \"bitrate_kbps\": 192}}")),
+	), liveTools.UpdateEncoderConfig)
+
+	addTool(mcp.NewTool("delete_encoder_config",
+		mcp.WithDescription("Delete an encoder configuration fragment. The first call returns a preview (the configuration and the channels that use it) and a confirm_token; call again with the token to delete."),
+		mcp.WithString("config_id", mcp.Required(), mcp.Description("The ID of the encoder configuration to delete")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
+	), liveTools.DeleteEncoderConfig)
+
+	addTool(mcp.NewTool("clone_encoder_config",
+		mcp.WithDescription("Create a copy of an encoder configuration under a new name, optionally changing some of it"),
+		mcp.WithString("config_id", mcp.Required(), mcp.Description("The ID of the encoder configuration to copy")),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Name for the copy")),
+		mcp.WithObject("overrides", mcp.Description("Changes applied as a JSON merge patch: objects are merged field by field, other values (including arrays) are replaced, and null removes a field. May change description, but not name, id or timestamps")),
+	), liveTools.CloneEncoderConfig)
+
+	addTool(mcp.NewTool("diff_encoder_configs",
+		mcp.WithDescription("Compare two encoder configurations and list every field that was added, removed or changed, with both values"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("config_id_a", mcp.Required(), mcp.Description("The ID of the first encoder configuration")),
+		mcp.WithString("config_id_b", mcp.Required(), mcp.Description("The ID of the second encoder configuration")),
+	), liveTools.DiffEncoderConfigs)
+
+	addTool(mcp.NewTool("list_workflows",
 		mcp.WithDescription("List all live streaming workflows"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), liveTools.ListWorkflows)
 
-	s.AddTool(mcp.NewTool("get_workflow",
+	addTool(mcp.NewTool("get_workflow",
 		mcp.WithDescription("Get details of a specific workflow"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow")),
 	), liveTools.GetWorkflow)
 
-	s.AddTool(mcp.NewTool("create_workflow",
+	addTool(mcp.NewTool("create_workflow",
 		mcp.WithDescription("Create a new live streaming workflow"),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Workflow name")),
 		mcp.WithString("description", mcp.Description("Workflow description")),
 	), liveTools.CreateWorkflow)
 
+	addTool(mcp.NewTool("update_workflow",
+		mcp.WithDescription("Update an existing live streaming workflow"),
+		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow")),
+		mcp.WithString("name", mcp.Description("New workflow name")),
+		mcp.WithString("description", mcp.Description("New workflow description")),
+	), liveTools.UpdateWorkflow)
+
+	addTool(mcp.NewTool("delete_workflow",
+		mcp.WithDescription("Delete a live streaming workflow. The first call returns a preview of the workflow and a confirm_token; call again with the token to delete."),
+		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID of the workflow to delete")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
+	), liveTools.DeleteWorkflow)
+
 	// M2A Capture tools
-	captureTools := tools.NewCaptureTools(client)
-	s.AddTool(mcp.NewTool("list_captures",
+	captureTools := tools.NewCaptureTools(client, tracker)
+	addTool(mcp.NewTool("list_captures",
 		mcp.WithDescription("List all capture jobs (live-to-VOD)"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("status", mcp.Description("Filter by status"), mcp.Enum("PENDING", "IN_PROGRESS", "COMPLETED", "FAILED", "CANCELLED")),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), captureTools.ListCaptures)
 
-	s.AddTool(mcp.NewTool("get_capture",
+	addTool(mcp.NewTool("get_capture",
 		mcp.WithDescription("Get details of a specific capture job"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("capture_id", mcp.Required(), mcp.Description("The ID of the capture job")),
 	), captureTools.GetCapture)
 
-	s.AddTool(mcp.NewTool("create_capture",
-		mcp.WithDescription("Create a new live-to-VOD capture job"),
+	addTool(mcp.NewTool("create_capture",
+		mcp.WithDescription("Create a new live-to-VOD capture job. The capture is tracked as a job (see job_id in the result)."),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Capture job name")),
 		mcp.WithString("channel_id", mcp.Required(), mcp.Description("Source channel ID")),
 		mcp.WithString("start_time", mcp.Required(), mcp.Description("Capture start time (ISO 8601)")),
 		mcp.WithString("end_time", mcp.Required(), mcp.Description("Capture end time (ISO 8601)")),
 	), captureTools.CreateCapture)
 
-	s.AddTool(mcp.NewTool("cancel_capture",
+	addTool(mcp.NewTool("cancel_capture",
 		mcp.WithDescription("Cancel an in-progress capture job"),
 		mcp.WithString("capture_id", mcp.Required(), mcp.Description("The ID of the capture job to cancel")),
 	), captureTools.CancelCapture)
 
-	s.AddTool(mcp.NewTool("list_capture_exports",
+	addTool(mcp.NewTool("list_capture_exports",
 		mcp.WithDescription("List all completed VOD exports from captures"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), captureTools.ListCaptureExports)
 
-	s.AddTool(mcp.NewTool("get_capture_export",
+	addTool(mcp.NewTool("get_capture_export",
 		mcp.WithDescription("Get details of a specific capture export"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("export_id", mcp.Required(), mcp.Description("The ID of the export")),
 	), captureTools.GetCaptureExport)
 
-	s.AddTool(mcp.NewTool("create_clip",
-		mcp.WithDescription("Create a frame-accurate clip from a capture"),
+	addTool(mcp.NewTool("create_clip",
+		mcp.WithDescription("Create a frame-accurate clip from a capture. Times and offsets are resolved to timecodes, reported under resolved, and all timecodes are checked against the capture's frame rate and length before the clip is submitted. The clip is tracked as a job (see job_id in the result)."),
 		mcp.WithString("capture_id", mcp.Required(), mcp.Description("Source capture ID")),
-		mcp.WithString("start_timecode", mcp.Required(), mcp.Description("Start timecode (HH:MM:SS:FF)")),
-		mcp.WithString("end_timecode", mcp.Required(), mcp.Description("End timecode (HH:MM:SS:FF)")),
+		mcp.WithString("start_timecode", mcp.Required(), mcp.Description("Start of the clip: a timecode from the start of the capture (HH:MM:SS:FF, or HH:MM:SS;FF for drop-frame), an ISO 8601 time, or an offset from the start (+00:12:30) or end (-90s) of the capture")),
+		mcp.WithString("end_timecode", mcp.Required(), mcp.Description("End of the clip, in any of the forms start_timecode accepts")),
 		mcp.WithString("name", mcp.Required(), mcp.Description("Clip name")),
 	), captureTools.CreateClip)
 
+	addTool(mcp.NewTool("create_clips_batch",
+		mcp.WithDescription("Create clips from a cut list (CMX3600 EDL, CSV or JSON). Every event is checked against the capture first; invalid events are reported and skipped. Returns a row per event with its clip ID and job ID, or the error. Use dry_run to only validate."),
+		mcp.WithString("capture_id", mcp.Required(), mcp.Description("Source capture ID")),
+		mcp.WithString("cut_list", mcp.Required(), mcp.Description("The cut list itself. EDL events are cut at their source in and out points, which must be timecodes relative to the start of the capture (00:00:00:00 is its first frame), not record-run or time-of-day timecodes. CSV needs a header with start and end (or in and out) columns and optionally name. JSON is an array of {name, start, end}. Start and end take anything create_clip accepts.")),
+		mcp.WithString("format", mcp.Description("Cut list format (guessed from the content by default)"), mcp.Enum(m2a.CutListFormats...)),
+		mcp.WithBoolean("dry_run", mcp.Description("Only validate the events and report the timecodes they resolve to")),
+		mcp.WithNumber("concurrency", mcp.Description("How many clips to submit at once (default 4, at most 10)")),
+	), captureTools.CreateClipsBatch)
+
+	// Job tools
+	jobTools := tools.NewJobTools(tracker)
+	addTool(mcp.NewTool("list_jobs",
+		mcp.WithDescription("List the asynchronous jobs (captures, their exports and clips) this server has started, newest first"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("kind", mcp.Description("Filter by kind of job"), mcp.Enum(jobs.KindCapture, jobs.KindExport, jobs.KindClip)),
+		mcp.WithString("state", mcp.Description("Filter by job state"), mcp.Enum(jobs.StateRunning, jobs.StateSucceeded, jobs.StateFailed, jobs.StateCancelled)),
+	), jobTools.ListJobs)
+
+	addTool(mcp.NewTool("get_job",
+		mcp.WithDescription("Get the latest known state of a job"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("job_id", mcp.Required(), mcp.Description("The job ID, e.g. capture:cap-0003")),
+	), jobTools.GetJob)
+
+	addTool(mcp.NewTool("wait_job",
+		mcp.WithDescription("Wait for a job to finish, sending progress notifications, and return its final state and how long the wait took"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("job_id", mcp.Required(), mcp.Description("The job ID, e.g. capture:cap-0003")),
+		mcp.WithNumber("timeout_seconds", mcp.Description("How long to wait (default 300, at most 1800)")),
+	), jobTools.WaitJob)
+
 	// VOD tools
-	vodTools := tools.NewVODTools(client)
-	s.AddTool(mcp.NewTool("list_vod_assets",
+	vodTools := tools.NewVODTools(client, confirmations)
+	addTool(mcp.NewTool("list_vod_assets",
 		mcp.WithDescription("List all VOD assets"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithNumber("limit", mcp.Description("Maximum number of results")),
 		mcp.WithNumber("offset", mcp.Description("Offset for pagination")),
+		mcp.WithBoolean("all", mcp.Description("Follow pagination and return every item, merged, with a total count")),
+		mcp.WithNumber("max_items", mcp.Description("Stop after this many items when all=true")),
 	), vodTools.ListVODAssets)
 
-	s.AddTool(mcp.NewTool("get_vod_asset",
+	addTool(mcp.NewTool("get_vod_asset",
 		mcp.WithDescription("Get details of a specific VOD asset"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("asset_id", mcp.Required(), mcp.Description("The ID of the VOD asset")),
 	), vodTools.GetVODAsset)
 
-	s.AddTool(mcp.NewTool("update_vod_metadata",
+	addTool(mcp.NewTool("update_vod_metadata",
 		mcp.WithDescription("Update metadata for a VOD asset"),
 		mcp.WithString("asset_id", mcp.Required(), mcp.Description("The ID of the VOD asset")),
 		mcp.WithString("title", mcp.Description("Asset title")),
@@ -250,13 +679,15 @@ func registerTools(s *server.MCPServer, client *client.M2AClient) error {
 		mcp.WithString("tags", mcp.Description("Comma-separated tags")),
 	), vodTools.UpdateVODMetadata)
 
-	s.AddTool(mcp.NewTool("delete_vod_asset",
-		mcp.WithDescription("Delete a VOD asset"),
+	addTool(mcp.NewTool("delete_vod_asset",
+		mcp.WithDescription("Delete a VOD asset. The first call returns a preview of the asset and a confirm_token; call again with the token to delete."),
 		mcp.WithString("asset_id", mcp.Required(), mcp.Description("The ID of the asset to delete")),
+		mcp.WithString("confirm_token", mcp.Description("Confirmation token from the preview; omit to get a preview")),
 	), vodTools.DeleteVODAsset)
 
-	s.AddTool(mcp.NewTool("get_playback_url",
+	addTool(mcp.NewTool("get_playback_url",
 		mcp.WithDescription("Get streaming playback URL for a VOD asset"),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("asset_id", mcp.Required(), mcp.Description("The ID of the VOD asset")),
 		mcp.WithString("format", mcp.Description("Playback format"), mcp.Enum("hls", "dash", "mp4")),
 	), vodTools.GetPlaybackURL)
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+	"github.com/andy-wilson/m2a-mcp/internal/prompts"
+)
+
+// toolReference matches a tool name quoted in a prompt, e.g. `get_channel`
+var toolReference = regexp.MustCompile("`([a-z]+_[a-z_]+)`")
+
+// Built-in runbooks must only call tools that exist, and declare every tool
+// they call so they're dropped when one is unavailable
+func TestBuiltinPromptTools(t *testing.T) {
+	h := newHarness(t)
+	registered := make(map[string]bool)
+	for _, tool := range h.tools() {
+		registered[tool.Name] = true
+	}
+
+	runbooks, err := prompts.Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, p := range runbooks {
+		for _, tool := range p.Tools {
+			if !registered[tool] {
+				t.Errorf("prompt %s declares unknown tool %s", p.Name, tool)
+			}
+		}
+
+		data, err := os.ReadFile(filepath.Join("internal", "prompts", p.Source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range toolReference.FindAllStringSubmatch(string(data), -1) {
+			if !slices.Contains(p.Tools, match[1]) {
+				t.Errorf("prompt %s calls %s without declaring it in tools", p.Name, match[1])
+			}
+		}
+	}
+}
+
+func TestGetPrompt(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	listed, err := h.client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		t.Fatalf("ListPrompts: %v", err)
+	}
+	var names []string
+	for _, p := range listed.Prompts {
+		names = append(names, p.Name)
+	}
+	slices.Sort(names)
+	if want := []string{"event_channel_setup", "match_highlights", "onboard_subscriber"}; !slices.Equal(names, want) {
+		t.Errorf("prompts = %v, want %v", names, want)
+	}
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Name = "onboard_subscriber"
+	request.Params.Arguments = map[string]string{"name": "Acme", "email": "ops@acme.example", "source_ids": "src-0001"}
+	result, err := h.client.GetPrompt(ctx, request)
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	for _, want := range []string{"Acme <ops@acme.example>", `name "Acme package"`, "`create_subscription`"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt text does not contain %q:\n%s", want, text)
+		}
+	}
+
+	delete(request.Params.Arguments, "email")
+	if _, err := h.client.GetPrompt(ctx, request); err == nil || !strings.Contains(err.Error(), "email") {
+		t.Errorf("GetPrompt without email: err = %v, want it to name the argument", err)
+	}
+}
+
+func TestCustomPrompts(t *testing.T) {
+	dir := t.TempDir()
+	custom := "---\ndescription: Check a channel\nargument: channel_id (required) Channel\ntools: get_channel\n---\nCall `get_channel` for {{.channel_id}}.\n"
+	if err := os.WriteFile(filepath.Join(dir, "check_channel.md"), []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Needs a tool hidden in read-only mode, so it's skipped
+	mutating := "---\ndescription: Start a channel\ntools: start_channel\n---\nCall `start_channel`.\n"
+	if err := os.WriteFile(filepath.Join(dir, "start.md"), []byte(mutating), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.PromptsDir = dir
+		cfg.ReadOnly = true
+	})
+	ctx := context.Background()
+
+	listed, err := h.client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		t.Fatalf("ListPrompts: %v", err)
+	}
+	if len(listed.Prompts) != 1 || listed.Prompts[0].Name != "check_channel" {
+		t.Fatalf("prompts = %+v, want only check_channel", listed.Prompts)
+	}
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Name = "check_channel"
+	request.Params.Arguments = map[string]string{"channel_id": "ch-0001"}
+	result, err := h.client.GetPrompt(ctx, request)
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	if text := result.Messages[0].Content.(mcp.TextContent).Text; text != "Call `get_channel` for ch-0001." {
+		t.Errorf("text = %q", text)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/fake"
+)
+
+// newStdioClient serves MCP over in-memory pipes, so notifications and
+// resource subscriptions go through the same stdio plumbing as in
+// production. The fake API runs on the real clock.
+func newStdioClient(t *testing.T, opts fake.Options) (*mcpclient.Client, *fake.Server) {
+	t.Helper()
+
+	opts.APIKey = "test-key"
+	opts.Seed = true
+	fakeServer, httpServer := fake.NewTestServer(opts)
+	t.Cleanup(httpServer.Close)
+
+	cfg := testConfig(httpServer.URL)
+	cfg.ResourcePollInterval = 20 * time.Millisecond
+	svc, err := newServer(cfg, client.NewM2AClient(cfg), nil)
+	if err != nil {
+		t.Fatalf("newServer: %v", err)
+	}
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveStdioStreams(ctx, svc, serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	c := mcpclient.NewClient(transport.NewIO(clientIn, clientOut, io.NopCloser(strings.NewReader(""))))
+	t.Cleanup(func() {
+		c.Close()
+		cancel()
+		<-done
+	})
+
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "resources", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	return c, fakeServer
+}
+
+func TestResourceListing(t *testing.T) {
+	c, _ := newStdioClient(t, fake.Options{})
+	ctx := context.Background()
+
+	listed, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	templates, err := c.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+	if err != nil {
+		t.Fatalf("ListResourceTemplates: %v", err)
+	}
+	if len(listed.Resources) != 10 || len(templates.ResourceTemplates) != 10 {
+		t.Fatalf("got %d resources and %d templates, want 10 of each", len(listed.Resources), len(templates.ResourceTemplates))
+	}
+
+	for uri, want := range map[string]string{
+		"m2a://live/channels":         `"total":2`,
+		"m2a://live/channels/ch-0001": `"name":"News Channel"`,
+		"m2a://vod/assets/asset-0001": `"id":"asset-0001"`,
+	} {
+		request := mcp.ReadResourceRequest{}
+		request.Params.URI = uri
+		result, err := c.ReadResource(ctx, request)
+		if err != nil {
+			t.Fatalf("ReadResource(%s): %v", uri, err)
+		}
+		text := result.Contents[0].(mcp.TextResourceContents).Text
+		if !strings.Contains(text, want) {
+			t.Errorf("ReadResource(%s) = %s, want it to contain %s", uri, text, want)
+		}
+	}
+
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = "m2a://live/channels/ch-9999"
+	if _, err := c.ReadResource(ctx, request); err == nil {
+		t.Errorf("ReadResource of a missing channel succeeded")
+	}
+}
+
+func TestResourceSubscription(t *testing.T) {
+	c, _ := newStdioClient(t, fake.Options{})
+	ctx := context.Background()
+
+	updated := make(chan string, 10)
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationResourceUpdated {
+			uri, _ := notification.Params.AdditionalFields["uri"].(string)
+			updated <- uri
+		}
+	})
+
+	bad := mcp.SubscribeRequest{}
+	bad.Params.URI = "m2a://live/nonsense/1"
+	if err := c.Subscribe(ctx, bad); err == nil {
+		t.Errorf("subscribing to an unknown resource succeeded")
+	}
+
+	subscribe := mcp.SubscribeRequest{}
+	subscribe.Params.URI = "m2a://live/channels/ch-0001"
+	if err := c.Subscribe(ctx, subscribe); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// Nothing has changed yet
+	select {
+	case uri := <-updated:
+		t.Fatalf("unexpected update for %s", uri)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	start := mcp.CallToolRequest{}
+	start.Params.Name = "start_channel"
+	start.Params.Arguments = map[string]interface{}{"channel_id": "ch-0001"}
+	if result, err := c.CallTool(ctx, start); err != nil || result.IsError {
+		t.Fatalf("start_channel: %v %+v", err, result)
+	}
+
+	select {
+	case uri := <-updated:
+		if uri != subscribe.Params.URI {
+			t.Errorf("update for %s, want %s", uri, subscribe.Params.URI)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no update after the channel started")
+	}
+
+	unsubscribe := mcp.UnsubscribeRequest{}
+	unsubscribe.Params.URI = subscribe.Params.URI
+	if err := c.Unsubscribe(ctx, unsubscribe); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+}
+
+// A resource that can't be read when it's subscribed to is still polled, and
+// changes once it can be read are reported
+func TestResourceSubscriptionReadFailure(t *testing.T) {
+	c, fakeServer := newStdioClient(t, fake.Options{})
+	ctx := context.Background()
+
+	updated := make(chan string, 10)
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationResourceUpdated {
+			uri, _ := notification.Params.AdditionalFields["uri"].(string)
+			updated <- uri
+		}
+	})
+
+	// Enough failures to outlast the retries of the read at subscribe time
+	fakeServer.InjectFault(fake.Fault{Method: "GET", Path: "/api/v3/live/channels/ch-0001", Status: 503, Times: 3})
+	subscribe := mcp.SubscribeRequest{}
+	subscribe.Params.URI = "m2a://live/channels/ch-0001"
+	if err := c.Subscribe(ctx, subscribe); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// The first successful poll records the content without reporting it
+	select {
+	case uri := <-updated:
+		t.Fatalf("unexpected update for %s", uri)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	start := mcp.CallToolRequest{}
+	start.Params.Name = "start_channel"
+	start.Params.Arguments = map[string]interface{}{"channel_id": "ch-0001"}
+	if result, err := c.CallTool(ctx, start); err != nil || result.IsError {
+		t.Fatalf("start_channel: %v %+v", err, result)
+	}
+
+	select {
+	case uri := <-updated:
+		if uri != subscribe.Params.URI {
+			t.Errorf("update for %s, want %s", uri, subscribe.Params.URI)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no update after the channel started")
+	}
+}
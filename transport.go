@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/andy-wilson/m2a-mcp/internal/auth"
+	"github.com/andy-wilson/m2a-mcp/internal/config"
+	"github.com/andy-wilson/m2a-mcp/internal/resources"
+)
+
+const (
+	transportStdio = "stdio"
+	transportHTTP  = "http"
+
+	// shutdownGracePeriod bounds how long in-flight requests get to finish on SIGTERM
+	shutdownGracePeriod = 15 * time.Second
+
+	// stdioSessionID is the session ID the MCP library gives the stdio client
+	stdioSessionID = "stdio"
+
+	// maxMessageSize bounds a request body read to look for subscriptions
+	maxMessageSize = 4 << 20
+)
+
+// serveStdio serves MCP over stdin and stdout until SIGINT, SIGTERM or the
+// end of input
+func serveStdio(svc *service) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return serveStdioStreams(ctx, svc, os.Stdin, os.Stdout)
+}
+
+// serveStdioStreams serves MCP over a pair of streams. Resource subscription
+// requests are answered here and every other message is passed on to the
+// MCP library's stdio server.
+func serveStdioStreams(ctx context.Context, svc *service, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	svc.run(ctx)
+
+	// Both writers emit whole lines in a single Write, so a lock per Write
+	// keeps responses from interleaving
+	stdout := &lockedWriter{w: out}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				if response, handled := svc.resources.HandleMessage(ctx, stdioSessionID, line); handled {
+					stdout.Write(append(response, '\n'))
+				} else if _, werr := pipeWriter.Write(line); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+		}
+	}()
+
+	stdioServer := server.NewStdioServer(svc.mcpServer)
+	stdioServer.SetErrorLogger(log.New(os.Stderr, "", log.LstdFlags))
+	return stdioServer.Listen(ctx, pipeReader, stdout)
+}
+
+// lockedWriter serialises writes to an underlying writer
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// subscriptionMiddleware answers resource subscription requests sent to the
+// streamable HTTP endpoint, using the Mcp-Session-Id header to identify the
+// subscriber, and passes everything else through
+func subscriptionMiddleware(m2aResources *resources.Resources, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+
+		sessionID := r.Header.Get(server.HeaderKeySessionID)
+		if sessionID != "" {
+			if response, handled := m2aResources.HandleMessage(r.Context(), sessionID, body); handled {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(server.HeaderKeySessionID, sessionID)
+				w.Write(response)
+				return
+			}
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveHTTP serves MCP over the network until SIGINT or SIGTERM:
+//
+//	/mcp      streamable HTTP transport
+//	/sse      SSE transport event stream (with /message for client requests)
+//	/healthz  liveness probe
+//
+// The MCP endpoints require a bearer token from cfg.AuthTokensFile unless
+// authentication has been explicitly disabled.
+func serveHTTP(svc *service, cfg *config.Config) error {
+	authenticate, err := httpAuthenticator(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("HTTP server failed: %w", err)
+	}
+	return serveHTTPListener(ctx, svc, authenticate, listener)
+}
+
+// serveHTTPListener serves MCP on listener until ctx ends, then gives
+// in-flight requests up to shutdownGracePeriod to finish
+func serveHTTPListener(ctx context.Context, svc *service, authenticate func(http.Handler) http.Handler, listener net.Listener) error {
+	svc.run(ctx)
+
+	httpServer := &http.Server{ReadHeaderTimeout: 10 * time.Second}
+	transports := newHTTPTransports(svc, authenticate, httpServer)
+	httpServer.Handler = transports.handler
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("Serving MCP over HTTP on %s (streamable HTTP at /mcp, SSE at /sse)", listener.Addr())
+		errCh <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("HTTP server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+	defer cancel()
+	if err := transports.shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// httpTransports holds the MCP transports served over HTTP and the routes
+// to them
+type httpTransports struct {
+	handler    http.Handler
+	streamable *server.StreamableHTTPServer
+	sse        *server.SSEServer
+}
+
+// newHTTPTransports routes the MCP endpoints, guarded by authenticate, and
+// the health check. httpServer is the server they will run on, which the
+// SSE transport shuts down.
+func newHTTPTransports(svc *service, authenticate func(http.Handler) http.Handler, httpServer *http.Server) *httpTransports {
+	streamableServer := server.NewStreamableHTTPServer(svc.mcpServer)
+	sseServer := server.NewSSEServer(svc.mcpServer,
+		server.WithHTTPServer(httpServer),
+		server.WithUseFullURLForMessageEndpoint(false),
+	)
+
+	// Resource subscriptions need a session to notify, so they're only
+	// supported on the streamable HTTP transport, not the legacy SSE one
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", authenticate(subscriptionMiddleware(svc.resources, streamableServer)))
+	mux.Handle("/sse", authenticate(sseServer.SSEHandler()))
+	mux.Handle("/message", authenticate(sseServer.MessageHandler()))
+	mux.HandleFunc("/healthz", handleHealthz)
+
+	return &httpTransports{handler: mux, streamable: streamableServer, sse: sseServer}
+}
+
+// shutdown closes the transports' sessions and drains the HTTP server
+func (h *httpTransports) shutdown(ctx context.Context) error {
+	if err := h.streamable.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down streamable HTTP transport: %w", err)
+	}
+	// Closes open SSE sessions, then drains the shared HTTP server
+	if err := h.sse.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+	return nil
+}
+
+// httpAuthenticator returns the middleware guarding the MCP endpoints
+func httpAuthenticator(cfg *config.Config) (func(http.Handler) http.Handler, error) {
+	if cfg.AuthDisabled {
+		log.Printf("WARNING: authentication is disabled; anyone who can reach %s can call every tool", cfg.ListenAddr)
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := auth.WithIdentity(r.Context(), auth.Identity{Name: auth.Anonymous, Transport: transportHTTP})
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		}, nil
+	}
+
+	if cfg.AuthTokensFile == "" {
+		return nil, fmt.Errorf("the http transport requires M2A_AUTH_TOKENS_FILE (or M2A_AUTH_DISABLED=true for local testing)")
+	}
+
+	store, err := auth.LoadTokens(cfg.AuthTokensFile)
+	if err != nil {
+		return nil, err
+	}
+	return func(next http.Handler) http.Handler {
+		return auth.Middleware(store, next)
+	}, nil
+}
+
+// handleHealthz reports that the server is up
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "ok",
+		"server":  serverName,
+		"version": serverVersion,
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/client"
+	"github.com/andy-wilson/m2a-mcp/internal/fake"
+)
+
+const httpToken = "alice-token-0123456789"
+
+// newHTTPService creates a service backed by the fake API and the HTTP
+// authenticator for a token file granting httpToken to alice
+func newHTTPService(t *testing.T) (*service, func(http.Handler) http.Handler) {
+	t.Helper()
+
+	_, apiServer := fake.NewTestServer(fake.Options{APIKey: "test-key", Seed: true})
+	t.Cleanup(apiServer.Close)
+
+	cfg := testConfig(apiServer.URL)
+	cfg.AuthTokensFile = filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(cfg.AuthTokensFile, []byte("alice "+httpToken+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	authenticate, err := httpAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("httpAuthenticator: %v", err)
+	}
+
+	svc, err := newServer(cfg, client.NewM2AClient(cfg), nil)
+	if err != nil {
+		t.Fatalf("newServer: %v", err)
+	}
+	return svc, authenticate
+}
+
+// newHTTPClient connects a streamable HTTP client to url, initialised
+func newHTTPClient(t *testing.T, url string) *mcpclient.Client {
+	t.Helper()
+
+	c, err := mcpclient.NewStreamableHttpClient(url, transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer " + httpToken}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "http", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	return c
+}
+
+func TestHTTPHealthz(t *testing.T) {
+	svc, authenticate := newHTTPService(t)
+	httpServer := httptest.NewServer(nil)
+	httpServer.Config.Handler = newHTTPTransports(svc, authenticate, httpServer.Config).handler
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var health map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || health["status"] != "ok" || health["server"] != serverName {
+		t.Errorf("healthz = %d %v, want 200 and status ok", resp.StatusCode, health)
+	}
+}
+
+// The MCP endpoints refuse requests without a valid token before they reach
+// the MCP server
+func TestHTTPRequiresToken(t *testing.T) {
+	svc, authenticate := newHTTPService(t)
+	httpServer := httptest.NewServer(nil)
+	httpServer.Config.Handler = newHTTPTransports(svc, authenticate, httpServer.Config).handler
+	defer httpServer.Close()
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"x","version":"1"}}}`
+	for _, tt := range []struct {
+		method, path, authorization string
+	}{
+		{http.MethodPost, "/mcp", ""},
+		{http.MethodPost, "/mcp", "Bearer wrong-token"},
+		{http.MethodPost, "/mcp", httpToken},
+		{http.MethodGet, "/sse", ""},
+		{http.MethodGet, "/sse", "Bearer wrong-token"},
+		{http.MethodPost, "/message?sessionId=x", ""},
+	} {
+		req, err := http.NewRequest(tt.method, httpServer.URL+tt.path, strings.NewReader(initialize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s %s with Authorization %q = %d, want 401", tt.method, tt.path, tt.authorization, resp.StatusCode)
+		}
+	}
+}
+
+// Tool calls and resource subscriptions work over streamable HTTP
+func TestHTTPStreamable(t *testing.T) {
+	svc, authenticate := newHTTPService(t)
+	httpServer := httptest.NewServer(nil)
+	httpServer.Config.Handler = newHTTPTransports(svc, authenticate, httpServer.Config).handler
+	defer httpServer.Close()
+
+	c := newHTTPClient(t, httpServer.URL+"/mcp")
+	ctx := context.Background()
+
+	call := mcp.CallToolRequest{}
+	call.Params.Name = "list_channels"
+	result, err := c.CallTool(ctx, call)
+	if err != nil || result.IsError {
+		t.Fatalf("list_channels: %v %+v", err, result)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "News Channel") {
+		t.Errorf("list_channels = %s, want the seeded channels", text)
+	}
+
+	subscribe := mcp.SubscribeRequest{}
+	subscribe.Params.URI = "m2a://live/channels/ch-0001"
+	if err := c.Subscribe(ctx, subscribe); err != nil {
+		t.Errorf("Subscribe: %v", err)
+	}
+	subscribe.Params.URI = "m2a://live/nonsense/1"
+	if err := c.Subscribe(ctx, subscribe); err == nil {
+		t.Errorf("subscribing to an unknown resource succeeded")
+	}
+}
+
+// Ending the context, as SIGTERM does, shuts the server down cleanly
+func TestHTTPShutdown(t *testing.T) {
+	svc, authenticate := newHTTPService(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- serveHTTPListener(ctx, svc, authenticate, listener) }()
+
+	c := newHTTPClient(t, url+"/mcp")
+	if _, err := c.ListTools(context.Background(), mcp.ListToolsRequest{}); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveHTTPListener = %v, want a clean shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server didn't shut down")
+	}
+	if _, err := http.Get(url + "/healthz"); err == nil {
+		t.Error("server still answering after shutdown")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/andy-wilson/m2a-mcp/internal/fake"
+)
+
+func TestChannelWait(t *testing.T) {
+	c, _ := newStdioClient(t, fake.Options{StartDelay: 50 * time.Millisecond, StopDelay: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var progress []map[string]interface{}
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == "notifications/progress" {
+			mu.Lock()
+			progress = append(progress, notification.Params.AdditionalFields)
+			mu.Unlock()
+		}
+	})
+
+	call := func(name string, args map[string]interface{}, progressToken mcp.ProgressToken) map[string]interface{} {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		if progressToken != nil {
+			request.Params.Meta = &mcp.Meta{ProgressToken: progressToken}
+		}
+		result, err := c.CallTool(ctx, request)
+		if err != nil || result.IsError {
+			t.Fatalf("%s: %v %+v", request.Params.Name, err, result)
+		}
+		var output map[string]interface{}
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &output); err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+
+	started := call("start_channel", map[string]interface{}{"channel_id": "ch-0001", "wait": true}, "start-1")
+	if started["final_state"] != "RUNNING" {
+		t.Errorf("final_state = %v, want RUNNING", started["final_state"])
+	}
+	if _, ok := started["waited_seconds"].(float64); !ok {
+		t.Errorf("missing waited_seconds: %v", started)
+	}
+
+	// Notifications are delivered asynchronously
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(progress)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mu.Lock()
+	if len(progress) == 0 {
+		t.Errorf("no progress notifications")
+	} else if progress[0]["progressToken"] != "start-1" {
+		t.Errorf("progress token = %v, want start-1", progress[0]["progressToken"])
+	}
+	mu.Unlock()
+
+	preview := call("stop_channel", map[string]interface{}{"channel_id": "ch-0001"}, nil)
+	stopped := call("stop_channel", map[string]interface{}{
+		"channel_id":    "ch-0001",
+		"confirm_token": preview["confirm_token"],
+		"wait":          true,
+	}, nil)
+	if stopped["final_state"] != "IDLE" {
+		t.Errorf("final_state = %v, want IDLE", stopped["final_state"])
+	}
+}
+
+func TestChannelWaitTimeout(t *testing.T) {
+	// The harness clock never moves, so the channel stays STARTING
+	h := newHarness(t)
+
+	toolErr := h.mustFail("start_channel", map[string]interface{}{
+		"channel_id":      "ch-0001",
+		"wait":            true,
+		"timeout_seconds": 0.1,
+	}, "timeout")
+	if message, _ := toolErr["message"].(string); !strings.Contains(message, "STARTING") {
+		t.Errorf("message %q doesn't mention the last state", message)
+	}
+}